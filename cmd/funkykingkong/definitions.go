@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games/classic"
+)
+
+// loadDefinitions adds every *.json classic-slot definition in dir to the
+// registry as another version of its game, presenting losses under policy.
+// The versions are only played once activated through the admin API.
+func loadDefinitions(dir string, registry *games.Registry, policy classic.LossPolicy) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		game, err := classic.Load(data)
+		if err == nil {
+			game, err = game.WithLossPolicy(policy)
+		}
+		if err == nil {
+			err = registry.AddVersion(game)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		info := game.Info()
+		slog.Info("Loaded game definition version",
+			slog.String("game", info.ID),
+			slog.String("version", info.DefinitionVersion),
+			slog.Float64("rtp", info.RTP),
+		)
+	}
+	return nil
+}
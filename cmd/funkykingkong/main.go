@@ -1,72 +1,492 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 
+	"github.com/JILI-GAMES/b_backend_games11/pkg/admin"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/auth"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/compliance"
 	"github.com/JILI-GAMES/b_backend_games11/pkg/common/config"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/environment"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/health"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/logging"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/metrics"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/ratelimit"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/responsible"
 	"github.com/JILI-GAMES/b_backend_games11/pkg/common/rng"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/rounds"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/session"
 	"github.com/JILI-GAMES/b_backend_games11/pkg/common/settings"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/tracing"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/wallet"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games/classic"
 	"github.com/JILI-GAMES/b_backend_games11/pkg/games/funkykingkong"
 )
 
 func main() {
 	// Load configuration
-	prodCfg, testCfg := config.LoadAll()
+	cfg := config.LoadAll()
 
-	fmt.Println("Production Configuration:", prodCfg)
-	fmt.Println("Test Configuration:", testCfg)
+	// Set up logging with rotation; SIGHUP reopens the file
+	logFile := logging.NewRotatingFile(logging.RotationConfig{
+		Path:       cfg.LogFile,
+		MaxSizeMB:  cfg.LogMaxSizeMB,
+		MaxAgeDays: cfg.LogMaxAgeDays,
+		MaxBackups: cfg.LogMaxBackups,
+		Compress:   cfg.LogCompress,
+		Daily:      cfg.LogRotateDaily,
+	})
+	defer logFile.Close()
+	logging.ReopenOnSIGHUP(logFile)
+	slog.SetDefault(logging.New(logFile, logging.ParseLevel(cfg.LogLevel)))
+	if err := cfg.Validate(); err != nil {
+		fatal("Invalid configuration", err)
+	}
 
-	// Set up logging
-	logFile, err := os.OpenFile(prodCfg.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	for _, env := range cfg.Environments {
+		slog.Info("Loaded environment",
+			slog.String("environment", env.Name),
+			slog.String("rng_url", env.RNGServiceURL),
+			slog.String("settings_url", env.SettingsServiceURL),
+		)
+	}
+	slog.Info("Loaded configuration",
+		slog.String("default_environment", cfg.Routing.Default),
+		slog.Int("operator_routes", len(cfg.Routing.Operators)),
+		slog.Int("api_key_routes", len(cfg.Routing.APIKeys)),
+		slog.Int("origin_routes", len(cfg.Routing.Origins)),
+		slog.String("port", cfg.ServerPort),
+		slog.Bool("admin_enabled", cfg.AdminEnabled()),
+		slog.Bool("operator_auth_enabled", cfg.OperatorsFile != ""),
+		slog.Bool("player_sessions_enabled", len(cfg.SessionSigningKeys) > 0),
+	)
+
+	// Set up tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "funky-king-kong",
+		Exporter:    cfg.TraceExporter,
+		File:        cfg.TraceFile,
+		SampleRatio: cfg.TraceSampleRatio,
+	})
 	if err != nil {
-		log.Fatalf("Error opening log file: %v", err)
+		fatal("Error setting up tracing", err)
 	}
-	defer logFile.Close()
-	log.SetOutput(logFile)
 
-	// Create shared clients
-	rngClient := rng.NewClient(prodCfg.RNGServiceURL)
-	settingsClient := settings.NewClient(prodCfg.SettingsServiceURL)
-	
-	// Create test clients
-	rngTestClient := rng.NewClient(testCfg.RNGServiceURL)
-	settingsTestClient := settings.NewClient(testCfg.SettingsServiceURL)
+	// Create the clients of every environment and the router choosing between them
+	var envClients []*environment.Clients
+	settingsCaches := make(map[string]*settings.Cache, len(cfg.Environments))
+	wallets := make(map[string]rounds.Wallet)
+	var breakers []*resilience.Policy
+	for _, env := range cfg.Environments {
+		rngPolicy, settingsPolicy := newRNGPolicy(env.Name, cfg), newSettingsPolicy(env.Name, cfg)
+		breakers = append(breakers, rngPolicy, settingsPolicy)
+		if env.WalletServiceURL != "" {
+			walletPolicy := newWalletPolicy(env.Name, cfg)
+			breakers = append(breakers, walletPolicy)
+			wallets[env.Name] = wallet.NewClient(env.WalletServiceURL, walletPolicy)
+		}
+		cache := newSettingsCache(env.Name, settings.NewClient(env.SettingsServiceURL, settingsPolicy), cfg)
+		settingsCaches[env.Name] = cache
+		envClients = append(envClients, &environment.Clients{
+			Name:     env.Name,
+			RNG:      rng.NewClient(env.RNGServiceURL, rngPolicy),
+			Settings: cache,
+		})
+	}
+	router, err := environment.NewRouter(cfg.Routing, envClients...)
+	if err != nil {
+		fatal("Invalid environment routing", err)
+	}
 
 	// Create Fiber app
-	app := fiber.New(fiber.Config{
-		ErrorHandler: customErrorHandler,
-	})
+	if cfg.ProxyHeader != "" && len(cfg.TrustedProxies) == 0 {
+		slog.Warn("PROXY_HEADER is ignored until TRUSTED_PROXIES lists the load balancers setting it")
+	}
+	app := fiber.New(serverConfig(cfg))
 
 	// Add middleware
 	app.Use(recover.New())
-	app.Use(logger.New(logger.Config{
-		Format:     "[${time}] ${status} - ${method} ${path}\n",
-		TimeFormat: "2006-01-02 15:04:05",
-		Output:     logFile,
-	}))
+	app.Use(tracing.Middleware())
+	app.Use(logging.Middleware())
+
+	// In-flight spins are only abandoned once the shutdown deadline has passed
+	lifetime, abandonInFlight := context.WithCancel(context.Background())
+	defer abandonInFlight()
+
+	// Rounds, ledger, idempotent responses and session revocations; opening
+	// the store runs its schema migrations
+	st, err := store.Open(cfg.StoreDriver, cfg.StorePath)
+	if err != nil {
+		fatal("Error opening store", err)
+	}
+	defer st.Close()
+	go purgeExpired(lifetime, st, time.Hour)
+
+	// Operator credentials; SIGHUP reloads them
+	var authenticator *auth.Authenticator
+	if cfg.OperatorsFile != "" {
+		operators, err := auth.NewStore(cfg.OperatorsFile)
+		if err != nil {
+			fatal("Error loading operator credentials", err)
+		}
+		operators.ReloadOnSIGHUP()
+		authenticator = auth.NewAuthenticator(operators, auth.Config{
+			RequireSignature: cfg.AuthRequireSignature,
+			MaxClockSkew:     cfg.AuthMaxClockSkew,
+			Observe:          metrics.RecordAuthFailure,
+		})
+	}
+
+	// Losses are presented under the configured policy
+	lossPolicy := classic.LossPolicy{
+		Mode:            cfg.LossPolicy,
+		MaxNearMissRate: cfg.NearMissMaxRate,
+		CapAtChance:     cfg.NearMissCapAtChance,
+	}
+	kingKong, err := funkykingkong.New().WithLossPolicy(lossPolicy)
+	if err != nil {
+		fatal("Invalid loss policy", err)
+	}
+	chanceRate, nearMissRate := kingKong.NearMissRates()
+	slog.Info("Loss policy",
+		slog.String("game", funkykingkong.GameID),
+		slog.String("policy", kingKong.LossPolicy().String()),
+		slog.Float64("chance_near_miss_rate", chanceRate),
+		slog.Float64("near_miss_rate", nearMissRate),
+	)
+
+	// Every game served here shares the spin pipeline below
+	registry, err := games.NewRegistry(kingKong)
+	if err != nil {
+		fatal("Invalid game definition", err)
+	}
+	if cfg.DefinitionsDir != "" {
+		if err := loadDefinitions(cfg.DefinitionsDir, registry, lossPolicy); err != nil {
+			fatal("Invalid game definition", err)
+		}
+	}
+	gameRoutes := games.NewRouteGroup(registry, router, cfg.SpinTimeout)
+
+	// Market rules; a game below a profile's minimum RTP, or history that a
+	// profile requires kept but the store would lose, stops the server
+	if cfg.ComplianceFile != "" {
+		profiles, err := compliance.Load(cfg.ComplianceFile)
+		if err != nil {
+			fatal("Error loading compliance profiles", err)
+		}
+		for _, info := range registry.Infos() {
+			if err := profiles.CheckRTP(info.ID, info.RTP); err != nil {
+				fatal("Game does not meet a compliance profile", err)
+			}
+		}
+		if retention := profiles.Retention(); retention > 0 && cfg.StoreDriver == "memory" {
+			fatal("Compliance profiles require round history retention", errors.New("STORE_DRIVER=memory does not keep round history"))
+		}
+		gameRoutes.Compliance = profiles
+		slog.Info("Loaded compliance profiles",
+			slog.Int("profiles", len(profiles.All())),
+			slog.Duration("retention", profiles.Retention()),
+		)
+	}
+	gameRoutes.Lifetime = lifetime
+	gameRoutes.Store = st
+	gameRoutes.Selections = games.NewSelections(st, games.SelectionIdle)
+	gameRoutes.IdempotencyTTL = cfg.IdempotencyTTL
+	gameRoutes.Rounds = rounds.NewLifecycle(st, cfg.MinRoundDuration)
+
+	// Rounds a previous process left open are completed or voided before
+	// any spin is served, since their players cannot play until they are
+	reconciler := &rounds.Reconciler{
+		Lifecycle: gameRoutes.Rounds,
+		Wallets:   wallets,
+		Settled:   gameRoutes.KeepReplay,
+		ReportDir: cfg.ReconcileReportDir,
+	}
+	if cfg.ReconcileOnStart {
+		report, err := reconciler.Run(context.Background())
+		if err != nil {
+			fatal("Error reconciling open rounds", err)
+		}
+		logReconciled(report)
+	}
 
-	// Register routes for Funky King Kong
-	funkyKingKongRoutes := funkykingkong.NewRouteGroup(rngClient, settingsClient, rngTestClient, settingsTestClient)
-	funkyKingKongRoutes.Register(app)
+	// Later runs pick up rounds a spin could not settle, once the spin
+	// that owns them is past its deadline
+	if cfg.ReconcileInterval > 0 {
+		reconciler.MinAge = 2 * cfg.SpinTimeout
+		go reconcilePeriodically(lifetime, reconciler, cfg.ReconcileInterval)
+	}
+	gameRoutes.Limits = ratelimit.SpinLimits{
+		IP:          ratelimit.NewLimiter(cfg.RateLimitIPRPS, cfg.RateLimitIPBurst),
+		Player:      ratelimit.NewLimiter(cfg.RateLimitPlayerRPS, cfg.RateLimitPlayerBurst),
+		Operator:    ratelimit.NewLimiter(cfg.RateLimitOperatorRPS, cfg.RateLimitOperatorBurst),
+		PlayerSpins: ratelimit.NewConcurrency(cfg.MaxConcurrentSpinsPerPlayer),
+	}
 
-	// Add a simple status endpoint
+	// Responsible-gambling limits; operators manage them through /rg
+	responsibleGambling := responsible.NewService(st, responsible.Limits{
+		Loss:                responsible.WindowAmounts{Day: cfg.RGLossLimitDay},
+		Wager:               responsible.WindowAmounts{Day: cfg.RGWagerLimitDay},
+		MaxSessionMinutes:   cfg.RGMaxSessionMinutes,
+		RealityCheckMinutes: cfg.RGRealityCheckMinutes,
+	}, cfg.RGSessionIdleTimeout)
+	if err := responsibleGambling.Restore(context.Background()); err != nil {
+		fatal("Error restoring responsible gambling limits", err)
+	}
+	gameRoutes.Responsible = responsibleGambling
+
+	// Changes made through the admin API outlive restarts: they are applied
+	// again before any request is served
+	adminHandlers := &admin.Handlers{
+		Config:       cfg,
+		Store:        st,
+		Games:        registry,
+		Maintenance:  gameRoutes.Maintenance,
+		Environments: router,
+		Compliance:   gameRoutes.Compliance,
+		Responsible:  responsibleGambling,
+		Caches:       settingsCaches,
+		Breakers:     breakers,
+	}
+	adminState, err := adminHandlers.Restore(context.Background())
+	if err != nil {
+		fatal("Error restoring admin settings", err)
+	}
+	slog.Info("Restored admin settings",
+		slog.Bool("maintenance", adminState.Maintenance.Enabled),
+		slog.Int("maintenance_games", len(adminState.Maintenance.Games)),
+		slog.Int("definitions", len(adminState.Definitions)),
+		slog.Int("operators", len(adminState.Operators)),
+	)
+	if authenticator != nil {
+		rgHandlers := &responsible.Handlers{Service: responsibleGambling}
+		rgHandlers.Register(app.Group("/rg", authenticator.Middleware()))
+	}
+
+	// With player sessions, operators authenticate at launch and spins carry
+	// the session token; otherwise operators authenticate every spin
+	switch {
+	case len(cfg.SessionSigningKeys) > 0:
+		if authenticator == nil {
+			fatal("Player sessions need operator authentication", errors.New("OPERATORS_FILE is not set"))
+		}
+		sessions, err := session.NewManager(cfg.SessionSigningKeys, cfg.SessionTTL, store.SessionRevocations{Store: st})
+		if err != nil {
+			fatal("Invalid session configuration", err)
+		}
+		gameRoutes.Sessions = sessions
+		sessionHandlers := &session.Handlers{Manager: sessions, Environments: router}
+		sessionHandlers.Register(app.Group("/session", authenticator.Middleware()))
+	case authenticator != nil:
+		app.Use("/spin", authenticator.Middleware())
+	default:
+		slog.Warn("OPERATORS_FILE is not set, the spin API accepts unauthenticated requests")
+	}
+
+	// Mount /spin/{game} for every registered game
+	gameRoutes.Register(app)
+
+	// Round history for players (session token), operators and support (admin token)
+	roundHandlers := &rounds.Handlers{Store: st, Sessions: gameRoutes.Sessions}
+	if roundHandlers.Sessions != nil {
+		roundHandlers.RegisterPlayer(app)
+	}
+	if authenticator != nil {
+		roundHandlers.RegisterOperator(app.Group("/rounds", authenticator.Middleware()))
+	}
+
+	// The admin API listens on its own port, so it can be kept off the
+	// public network; it is only served when an admin token is configured
+	var adminApp *fiber.App
+	if cfg.AdminEnabled() {
+		tokens := make(map[string]string, len(cfg.AdminTokens)+1)
+		maps.Copy(tokens, cfg.AdminTokens)
+		if cfg.AdminToken != "" {
+			tokens["admin"] = cfg.AdminToken
+		}
+		adminApp = fiber.New(serverConfig(cfg))
+		adminApp.Use(recover.New())
+		adminApp.Use(tracing.Middleware())
+		adminApp.Use(logging.Middleware())
+		group := adminApp.Group("/admin", admin.RequireToken(tokens))
+		adminHandlers.Register(group)
+		roundHandlers.RegisterSupport(group.Group("/rounds"))
+	}
+
+	// Expose Prometheus metrics
+	app.Get("/metrics", metrics.Handler())
+
+	// Liveness and readiness probes; only the default environment gates readiness
+	checker := health.NewChecker(cfg.ReadinessTimeout)
+	for _, env := range cfg.Environments {
+		critical := env.Name == cfg.Routing.Default
+		checker.Add("rng_"+env.Name, critical, health.DialCheck(env.RNGServiceURL))
+		checker.Add("settings_"+env.Name, critical, health.DialCheck(env.SettingsServiceURL))
+	}
+	for _, id := range registry.IDs() {
+		checker.Add("game_definition_"+id, true, func(context.Context) error {
+			game, ok := registry.Get(id)
+			if !ok {
+				return games.ErrGameNotFound
+			}
+			return game.CheckDefinition()
+		})
+	}
+	app.Get("/healthz", checker.LivenessHandler)
+	app.Get("/readyz", checker.ReadinessHandler)
+
+	// Status endpoint, reporting readiness alongside the games served
 	app.Get("/status", func(c *fiber.Ctx) error {
+		status := "ok"
+		if ready, _ := checker.Ready(c.UserContext()); !ready {
+			status = "unavailable"
+		}
 		return c.JSON(fiber.Map{
-			"status": "ok",
+			"status": status,
 			"game":   "funky-king-kong",
+			"games":  registry.IDs(),
 		})
 	})
 
-	// Start the server 
-	port := prodCfg.ServerPort
-	log.Printf("Starting Funky King Kong server on port %s", port)
-	log.Fatal(app.Listen(":" + port))
+	// Start the server
+	port := cfg.ServerPort
+	listenErr := make(chan error, 2)
+	go func() {
+		slog.Info("Starting Funky King Kong server", slog.String("port", port))
+		listenErr <- app.Listen(":" + port)
+	}()
+	if adminApp != nil {
+		go func() {
+			slog.Info("Starting admin API", slog.String("port", cfg.AdminPort))
+			listenErr <- adminApp.Listen(":" + cfg.AdminPort)
+		}()
+	}
+
+	// Wait for a termination signal
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-listenErr:
+		fatal("Server stopped", err)
+	case <-signals.Done():
+	}
+
+	// Fail readiness first so load balancers move traffic away, then stop
+	// accepting connections and let in-flight spins finish
+	slog.Info("Shutting down", slog.String("drain_delay", cfg.ShutdownDrainDelay.String()), slog.String("timeout", cfg.ShutdownTimeout.String()))
+	checker.SetDraining()
+	time.Sleep(cfg.ShutdownDrainDelay)
+	if adminApp != nil {
+		if err := adminApp.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+			slog.Warn("Error stopping the admin API", slog.Any("error", err))
+		}
+	}
+	if err := app.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+		slog.Warn("Shutdown deadline passed, abandoning in-flight spins", slog.Any("error", err))
+	}
+	abandonInFlight()
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Warn("Error flushing traces", slog.Any("error", err))
+	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs an unrecoverable error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
+
+// purgeExpired drops expired idempotent responses and revocations every
+// interval until ctx is cancelled
+func purgeExpired(ctx context.Context, st store.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := st.Update(ctx, func(tx store.Tx) error { return tx.PurgeExpired(now) }); err != nil {
+				slog.Error("Error purging expired records", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// reconcilePeriodically runs the reconciler every interval until ctx is cancelled.
+// After a run that fails, or leaves rounds it could not complete, the wait
+// grows, up to ten intervals, so a wallet that is down is not hammered.
+func reconcilePeriodically(ctx context.Context, r *rounds.Reconciler, interval time.Duration) {
+	wait := backoff.NewExponentialBackOff()
+	wait.InitialInterval = interval
+	wait.MaxInterval = 10 * interval
+	wait.MaxElapsedTime = 0
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			report, err := r.Run(ctx)
+			switch {
+			case err != nil:
+				slog.Error("Error reconciling open rounds", slog.Any("error", err))
+			case len(report.Rounds) > 0:
+				logReconciled(report)
+			}
+			if err != nil || report.Failed > 0 {
+				timer.Reset(wait.NextBackOff())
+			} else {
+				wait.Reset()
+				timer.Reset(interval)
+			}
+		}
+	}
+}
+
+// logReconciled logs the outcome of a reconciliation run
+func logReconciled(report rounds.Report) {
+	level := slog.LevelInfo
+	if report.Failed > 0 {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "Reconciled open rounds",
+		slog.Int("settled", report.Settled),
+		slog.Int("voided", report.Voided),
+		slog.Int("failed", report.Failed),
+		slog.String("report", report.File),
+	)
+}
+
+// serverConfig returns the Fiber settings shared by the public and admin apps.
+// The proxy header only replaces the connection address on requests from a
+// trusted proxy, so clients cannot pick the IP they are rate limited under.
+func serverConfig(cfg config.Config) fiber.Config {
+	return fiber.Config{
+		ErrorHandler:            customErrorHandler,
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
+	}
 }
 
 // Custom error handler
@@ -78,9 +498,62 @@ func customErrorHandler(c *fiber.Ctx, err error) error {
 		code = e.Code
 	}
 
-	log.Printf("Request failed: %v", err)
+	slog.ErrorContext(c.UserContext(), "Request failed", slog.Int("status", code), slog.Any("error", err))
 	return c.Status(code).JSON(fiber.Map{
 		"status":  "error",
 		"message": err.Error(),
 	})
-}
\ No newline at end of file
+}
+
+// newRNGPolicy builds the resilience policy guarding an environment's RNG client
+func newRNGPolicy(env string, cfg config.Config) *resilience.Policy {
+	policy := resilience.NewPolicy("rng-"+env, resilience.Config{
+		Timeout:          cfg.RNGTimeout,
+		FailureThreshold: cfg.BreakerFailureThreshold,
+		OpenTimeout:      cfg.BreakerOpenTimeout,
+		HalfOpenProbes:   cfg.BreakerHalfOpenProbes,
+		MaxConcurrent:    cfg.RNGMaxConcurrent,
+		Observe:          metrics.DependencyObserver("rng", env),
+	})
+	metrics.RegisterBreaker("rng", env, policy)
+	return policy
+}
+
+// newWalletPolicy builds the resilience policy guarding an environment's wallet client
+func newWalletPolicy(env string, cfg config.Config) *resilience.Policy {
+	policy := resilience.NewPolicy("wallet-"+env, resilience.Config{
+		Timeout:          cfg.WalletTimeout,
+		FailureThreshold: cfg.BreakerFailureThreshold,
+		OpenTimeout:      cfg.BreakerOpenTimeout,
+		HalfOpenProbes:   cfg.BreakerHalfOpenProbes,
+		Observe:          metrics.DependencyObserver("wallet", env),
+	})
+	metrics.RegisterBreaker("wallet", env, policy)
+	return policy
+}
+
+// newSettingsPolicy builds the resilience policy guarding an environment's settings client
+func newSettingsPolicy(env string, cfg config.Config) *resilience.Policy {
+	policy := resilience.NewPolicy("settings-"+env, resilience.Config{
+		Timeout:          cfg.SettingsTimeout,
+		FailureThreshold: cfg.BreakerFailureThreshold,
+		OpenTimeout:      cfg.BreakerOpenTimeout,
+		HalfOpenProbes:   cfg.BreakerHalfOpenProbes,
+		MaxConcurrent:    cfg.SettingsMaxConcurrent,
+		Observe:          metrics.DependencyObserver("settings", env),
+	})
+	metrics.RegisterBreaker("settings", env, policy)
+	return policy
+}
+
+// newSettingsCache wraps an environment's settings client in the RTP cache
+func newSettingsCache(env string, client *settings.Client, cfg config.Config) *settings.Cache {
+	cache := settings.NewCache(client, settings.CacheConfig{
+		TTL:            cfg.SettingsCacheTTL,
+		StaleTTL:       cfg.SettingsCacheStaleTTL,
+		RefreshTimeout: cfg.SpinTimeout,
+		MaxFallbackAge: cfg.SettingsCacheMaxFallbackAge,
+	})
+	metrics.RegisterSettingsCache(env, cache)
+	return cache
+}
@@ -0,0 +1,161 @@
+// Command simulator draws losing outcomes under each loss policy and reports
+// how often they are near misses, for certification and policy review
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games/classic"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games/funkykingkong"
+)
+
+// definitions are the games the simulator can load by ID
+var definitions = map[string]func() *classic.Game{
+	funkykingkong.GameID: funkykingkong.New,
+}
+
+// PolicyReport is the measured presentation of losses under one policy
+type PolicyReport struct {
+	Policy         string  `json:"policy"`
+	Losses         int     `json:"losses"`
+	NearMisses     int     `json:"near_misses"`
+	NearMissRate   float64 `json:"near_miss_rate"`
+	ExpectedRate   float64 `json:"expected_rate"`
+	ChanceRate     float64 `json:"chance_rate"`
+	AboveChance    bool    `json:"above_chance"` // measured rate exceeds chance by more than sampling error
+	DistinctLosses int     `json:"distinct_losses"`
+}
+
+// Report is the simulator's output for one game
+type Report struct {
+	Game              string         `json:"game"`
+	DefinitionVersion string         `json:"definition_version"`
+	Policies          []PolicyReport `json:"policies"`
+}
+
+func main() {
+	gameID := flag.String("game", funkykingkong.GameID, "game to simulate")
+	losses := flag.Int("losses", 100_000, "losing outcomes drawn per policy")
+	policies := flag.String("policies", "curated,curated:chance,reel_strip,reel_strip:0.05",
+		"comma-separated policies: mode, mode:<max near-miss rate> or mode:chance")
+	format := flag.String("format", "text", "report format: text or json")
+	out := flag.String("out", "", "write the report to this file instead of stdout")
+	flag.Parse()
+
+	load, ok := definitions[*gameID]
+	if !ok {
+		fail(fmt.Errorf("unknown game %q", *gameID))
+	}
+	game := load()
+
+	report := Report{Game: *gameID, DefinitionVersion: game.Info().DefinitionVersion}
+	for _, spec := range strings.Split(*policies, ",") {
+		policy, err := parsePolicy(strings.TrimSpace(spec))
+		if err != nil {
+			fail(err)
+		}
+		result, err := simulate(game, policy, *losses)
+		if err != nil {
+			fail(err)
+		}
+		report.Policies = append(report.Policies, result)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fail(err)
+		}
+	case "text":
+		writeText(w, report)
+	default:
+		fail(fmt.Errorf("unknown format %q", *format))
+	}
+}
+
+// parsePolicy reads "mode", "mode:0.05" or "mode:chance"
+func parsePolicy(spec string) (classic.LossPolicy, error) {
+	mode, limit, _ := strings.Cut(spec, ":")
+	policy := classic.LossPolicy{Mode: mode}
+	switch limit {
+	case "":
+	case "chance":
+		policy.CapAtChance = true
+	default:
+		rate, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return policy, fmt.Errorf("policy %q: %w", spec, err)
+		}
+		policy.MaxNearMissRate = rate
+	}
+	return policy, nil
+}
+
+// simulate draws losses under policy and counts the near misses
+func simulate(base *classic.Game, policy classic.LossPolicy, losses int) (PolicyReport, error) {
+	game, err := base.WithLossPolicy(policy)
+	if err != nil {
+		return PolicyReport{}, err
+	}
+	chance, expected := game.NearMissRates()
+	result := PolicyReport{
+		Policy:       game.LossPolicy().String(),
+		Losses:       losses,
+		ExpectedRate: expected,
+		ChanceRate:   chance,
+	}
+
+	distinct := make(map[string]bool)
+	for range losses {
+		reels := game.LosingReels()
+		distinct[strings.Join(reels, " ")] = true
+		if game.NearMiss(reels) {
+			result.NearMisses++
+		}
+	}
+	result.DistinctLosses = len(distinct)
+	if losses > 0 {
+		result.NearMissRate = float64(result.NearMisses) / float64(losses)
+	}
+	// Three standard errors of a binomial share at the chance rate
+	tolerance := 3 * math.Sqrt(chance*(1-chance)/float64(max(losses, 1)))
+	result.AboveChance = result.NearMissRate > chance+tolerance
+	return result, nil
+}
+
+// writeText prints the report as a table
+func writeText(w io.Writer, report Report) {
+	fmt.Fprintf(w, "Near-miss report for %s (definition %s)\n\n", report.Game, report.DefinitionVersion)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POLICY\tLOSSES\tNEAR MISSES\tMEASURED\tEXPECTED\tCHANCE\tABOVE CHANCE\tDISTINCT LOSSES")
+	for _, p := range report.Policies {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f%%\t%.2f%%\t%.2f%%\t%v\t%d\n",
+			p.Policy, p.Losses, p.NearMisses, p.NearMissRate*100, p.ExpectedRate*100, p.ChanceRate*100, p.AboveChance, p.DistinctLosses)
+	}
+	tw.Flush()
+}
+
+// fail reports an error and exits
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "simulator:", err)
+	os.Exit(1)
+}
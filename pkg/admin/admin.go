@@ -0,0 +1,471 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/compliance"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/config"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/environment"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/logging"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/responsible"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/settings"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games"
+)
+
+// TokenHeader carries the admin token
+const TokenHeader = "X-Admin-Token"
+
+// actorKey is the Fiber local holding the actor an admin token belongs to
+const actorKey = "admin_actor"
+
+// Handlers serve the admin API. Every change is written to the store with its
+// audit entry in one transaction, and only then applied to the running server.
+type Handlers struct {
+	Config       config.Config // shown redacted
+	Store        store.Store
+	Games        *games.Registry
+	Maintenance  *games.Maintenance
+	Environments *environment.Router
+	Compliance   *compliance.Profiles
+	Responsible  *responsible.Service       // keeps its operator limits in Store
+	Caches       map[string]*settings.Cache // RTP settings cache per environment
+	Breakers     []*resilience.Policy
+
+	mu    sync.Mutex // serialises changes
+	state State
+}
+
+// RequireToken admits requests carrying one of the tokens in X-Admin-Token,
+// keyed by the actor they belong to, and records that actor for the audit trail
+func RequireToken(tokens map[string]string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		given := []byte(c.Get(TokenHeader))
+		for actor, token := range tokens {
+			if token != "" && subtle.ConstantTimeCompare(given, []byte(token)) == 1 {
+				c.Locals(actorKey, actor)
+				c.SetUserContext(logging.With(c.UserContext(), slog.String(actorKey, actor)))
+				return c.Next()
+			}
+		}
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid admin token")
+	}
+}
+
+// Register mounts the admin endpoints on a group behind RequireToken
+func (h *Handlers) Register(router fiber.Router) {
+	router.Get("/config", h.getConfig)
+	router.Get("/games", h.listGames)
+	router.Get("/games/:game", h.getGame)
+	router.Post("/games/:game/activate", h.activateDefinition)
+	router.Get("/operators", h.listOperators)
+	router.Get("/operators/:operator", h.getOperator)
+	router.Put("/operators/:operator", h.putOperator)
+	router.Delete("/operators/:operator", h.deleteOperator)
+	router.Get("/maintenance", h.getMaintenance)
+	router.Put("/maintenance", h.putMaintenance)
+	router.Get("/settings/cache", h.cacheStats)
+	router.Delete("/settings/cache", h.invalidateCache)
+	router.Get("/breakers", h.listBreakers)
+	router.Get("/audit", h.listAudit)
+}
+
+// Restore applies the admin state kept in the store. It runs once, before the
+// server takes traffic; a state referring to something the configuration no
+// longer has is an error.
+func (h *Handlers) Restore(ctx context.Context) (State, error) {
+	var data []byte
+	err := h.Store.View(ctx, func(tx store.Tx) (err error) {
+		data, err = tx.GetSetting(stateKey)
+		return err
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("stored admin state: %w", err)
+	}
+	if err := h.validate(state); err != nil {
+		return State{}, fmt.Errorf("stored admin state: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.apply(h.state, state)
+	h.state = state
+	return state.clone(), nil
+}
+
+// change prepares a new admin state with mutate, persists it with an audit
+// entry recording part of the state before and after, then applies it.
+// A refused change returns a *fiber.Error.
+func (h *Handlers) change(c *fiber.Ctx, action, target string, mutate func(*State) error, part func(State) any) (State, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	next := h.state.clone()
+	if err := mutate(&next); err != nil {
+		return State{}, err
+	}
+	if err := h.validate(next); err != nil {
+		return State{}, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	data, err := json.Marshal(next)
+	if err != nil {
+		return State{}, err
+	}
+	entry := h.auditEntry(c, action, target, part(h.state), part(next))
+	save := func(tx store.Tx) error {
+		if err := tx.PutSetting(stateKey, data); err != nil {
+			return err
+		}
+		return tx.AppendAudit(entry)
+	}
+	if changes := limitChanges(h.state, next); len(changes) > 0 && h.Responsible != nil {
+		// Operator limits are kept by the responsible-gambling service
+		err = h.Responsible.UpdateOperatorLimits(c.UserContext(), changes, save)
+	} else {
+		err = h.Store.Update(c.UserContext(), save)
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error saving admin change", slog.String("action", action), slog.Any("error", err))
+		return State{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to save the change")
+	}
+
+	h.apply(h.state, next)
+	h.state = next
+	slog.InfoContext(c.UserContext(), "Admin change applied", slog.String("action", action), slog.String("target", target))
+	return next.clone(), nil
+}
+
+// record writes an audit entry for a change that is not part of the admin state
+func (h *Handlers) record(c *fiber.Ctx, action, target string, after any) error {
+	entry := h.auditEntry(c, action, target, nil, after)
+	err := h.Store.Update(c.UserContext(), func(tx store.Tx) error {
+		return tx.AppendAudit(entry)
+	})
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error writing admin audit entry", slog.String("action", action), slog.Any("error", err))
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to write the audit entry")
+	}
+	return nil
+}
+
+// auditEntry describes a change made by the request's actor
+func (h *Handlers) auditEntry(c *fiber.Ctx, action, target string, before, after any) store.AuditEntry {
+	actor, _ := c.Locals(actorKey).(string)
+	return store.AuditEntry{
+		At:        time.Now(),
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		Before:    raw(before),
+		After:     raw(after),
+		RemoteIP:  c.IP(),
+		RequestID: logging.RequestID(c.UserContext()),
+	}
+}
+
+// raw marshals an audited value; values that cannot be marshalled are left out
+func raw(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// getConfig shows the effective configuration with secrets redacted, and
+// the admin state applied on top of it
+func (h *Handlers) getConfig(c *fiber.Ctx) error {
+	h.mu.Lock()
+	state := h.state.clone()
+	h.mu.Unlock()
+	return c.JSON(fiber.Map{
+		"config":      h.Config.Redacted(),
+		"admin_state": state,
+	})
+}
+
+// gameVersions is a game's definition versions as listed by the admin API
+type gameVersions struct {
+	ID       string       `json:"id"`
+	Active   string       `json:"active_version"`
+	Versions []games.Info `json:"versions"`
+}
+
+func (h *Handlers) versions(id string) (gameVersions, bool) {
+	infos, active, ok := h.Games.Versions(id)
+	return gameVersions{ID: id, Active: active, Versions: infos}, ok
+}
+
+func (h *Handlers) listGames(c *fiber.Ctx) error {
+	list := []gameVersions{}
+	for _, id := range h.Games.IDs() {
+		if v, ok := h.versions(id); ok {
+			list = append(list, v)
+		}
+	}
+	return c.JSON(fiber.Map{"games": list})
+}
+
+func (h *Handlers) getGame(c *fiber.Ctx) error {
+	v, ok := h.versions(c.Params("game"))
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "game not found")
+	}
+	return c.JSON(v)
+}
+
+// activateDefinition switches the definition version new spins of a game are played on
+func (h *Handlers) activateDefinition(c *fiber.Ctx) error {
+	id := param(c, "game")
+	var body struct {
+		Version string `json:"version"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.Version == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "version is required")
+	}
+	if _, ok := h.Games.Get(id); !ok {
+		return fiber.NewError(fiber.StatusNotFound, "game not found")
+	}
+	_, err := h.change(c, "definition.activate", id, func(s *State) error {
+		if s.Definitions == nil {
+			s.Definitions = make(map[string]string)
+		}
+		s.Definitions[id] = body.Version
+		return nil
+	}, func(s State) any {
+		version, ok := s.Definitions[id]
+		if !ok {
+			_, info, _ := h.Games.Active(id)
+			version = info.DefinitionVersion
+		}
+		return fiber.Map{"version": version}
+	})
+	if err != nil {
+		return err
+	}
+	v, _ := h.versions(id)
+	return c.JSON(v)
+}
+
+func (h *Handlers) listOperators(c *fiber.Ctx) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	operators := h.state.clone().Operators
+	return c.JSON(fiber.Map{"operators": operators})
+}
+
+// getOperator shows an operator's admin settings and what applies to it
+func (h *Handlers) getOperator(c *fiber.Ctx) error {
+	op := c.Params("operator")
+	h.mu.Lock()
+	settings := h.state.clone().Operators[op]
+	h.mu.Unlock()
+
+	effective := fiber.Map{}
+	if env, ok := h.Environments.OperatorRoute(op); ok {
+		effective["environment"] = env
+	} else {
+		effective["environment"] = h.Environments.Default().Name
+	}
+	if profile := h.Compliance.For(op); profile != nil {
+		effective["compliance_profile"] = profile.Name
+	}
+	if h.Responsible != nil {
+		effective["limits"] = h.Responsible.OperatorLimits(op)
+	}
+	return c.JSON(fiber.Map{
+		"operator_id": op,
+		"settings":    settings,
+		"effective":   effective,
+	})
+}
+
+// putOperator replaces an operator's settings
+func (h *Handlers) putOperator(c *fiber.Ctx) error {
+	op := param(c, "operator")
+	var settings OperatorSettings
+	if err := c.BodyParser(&settings); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	state, err := h.change(c, "operator.update", op, func(s *State) error {
+		s.Operators[op] = settings
+		return nil
+	}, operatorPart(op))
+	if err != nil {
+		return err
+	}
+	return c.JSON(state.Operators[op])
+}
+
+// deleteOperator drops an operator's settings, returning it to the configuration
+func (h *Handlers) deleteOperator(c *fiber.Ctx) error {
+	op := param(c, "operator")
+	_, err := h.change(c, "operator.delete", op, func(s *State) error {
+		if _, ok := s.Operators[op]; !ok {
+			return fiber.NewError(fiber.StatusNotFound, "operator has no admin settings")
+		}
+		delete(s.Operators, op)
+		return nil
+	}, operatorPart(op))
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// operatorPart selects an operator's settings for the audit trail
+func operatorPart(op string) func(State) any {
+	return func(s State) any {
+		if settings, ok := s.Operators[op]; ok {
+			return settings
+		}
+		return nil
+	}
+}
+
+func (h *Handlers) getMaintenance(c *fiber.Ctx) error {
+	return c.JSON(h.Maintenance.State())
+}
+
+// putMaintenance replaces what is under maintenance
+func (h *Handlers) putMaintenance(c *fiber.Ctx) error {
+	var maintenance games.MaintenanceState
+	if err := c.BodyParser(&maintenance); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	state, err := h.change(c, "maintenance.update", "", func(s *State) error {
+		s.Maintenance = maintenance
+		return nil
+	}, func(s State) any { return s.Maintenance })
+	if err != nil {
+		return err
+	}
+	return c.JSON(state.Maintenance)
+}
+
+// cacheStats reports the RTP settings cache counters per environment
+func (h *Handlers) cacheStats(c *fiber.Ctx) error {
+	stats := make(map[string]settings.CacheStats, len(h.Caches))
+	for env, cache := range h.Caches {
+		stats[env] = cache.Stats()
+	}
+	return c.JSON(stats)
+}
+
+// invalidateCache drops cached RTP entries; client_id, game_id, player_id and env narrow the match
+func (h *Handlers) invalidateCache(c *fiber.Ctx) error {
+	env := c.Query("env")
+	if _, ok := h.Caches[env]; env != "" && !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "Unknown environment: "+env)
+	}
+	match := fiber.Map{
+		"env":       env,
+		"client_id": c.Query("client_id"),
+		"game_id":   c.Query("game_id"),
+		"player_id": c.Query("player_id"),
+	}
+	if err := h.record(c, "settings_cache.invalidate", env, match); err != nil {
+		return err
+	}
+
+	removed := 0
+	for name, cache := range h.Caches {
+		if env == "" || env == name {
+			removed += cache.Invalidate(c.Query("client_id"), c.Query("game_id"), c.Query("player_id"))
+		}
+	}
+	slog.InfoContext(c.UserContext(), "Settings cache invalidated",
+		slog.String("env", env),
+		slog.String("client_id", c.Query("client_id")),
+		slog.String("game_id", c.Query("game_id")),
+		slog.String("player_id", c.Query("player_id")),
+		slog.Int("removed", removed),
+	)
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"removed": removed,
+	})
+}
+
+// breakerState is a dependency's circuit breaker as reported by the admin API
+type breakerState struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	InFlight int    `json:"in_flight"`
+}
+
+func (h *Handlers) listBreakers(c *fiber.Ctx) error {
+	breakers := make([]breakerState, 0, len(h.Breakers))
+	for _, p := range h.Breakers {
+		breakers = append(breakers, breakerState{Name: p.Name, State: p.State().String(), InFlight: p.InFlight()})
+	}
+	return c.JSON(fiber.Map{"breakers": breakers})
+}
+
+// listAudit pages through the audit trail, newest first; before is the next_before of the previous page
+func (h *Handlers) listAudit(c *fiber.Ctx) error {
+	limit, err := optionalInt(c, "limit")
+	if err != nil {
+		return err
+	}
+	before, err := optionalInt(c, "before")
+	if err != nil {
+		return err
+	}
+	var entries []store.AuditEntry
+	err = h.Store.View(c.UserContext(), func(tx store.Tx) (err error) {
+		entries, err = tx.ListAudit(int64(before), limit)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	response := fiber.Map{"entries": entries}
+	// IDs have no gaps, so anything above 1 has older entries
+	if n := len(entries); n > 0 && entries[n-1].ID > 1 {
+		response["next_before"] = entries[n-1].ID
+	}
+	return c.JSON(response)
+}
+
+// param copies a route parameter, which Fiber reuses after the request, so it
+// can be kept in the admin state
+func param(c *fiber.Ctx, name string) string {
+	return utils.CopyString(c.Params(name))
+}
+
+// optionalInt parses a non-negative integer query parameter, 0 when absent
+func optionalInt(c *fiber.Ctx, name string) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, name+" must be a non-negative integer")
+	}
+	return n, nil
+}
@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/responsible"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games"
+)
+
+// stateKey is the store setting holding the admin state
+const stateKey = "admin_state"
+
+// State is everything changed through the admin API. It is kept in the store
+// and applied again when the server starts, on top of the configuration.
+type State struct {
+	Maintenance games.MaintenanceState      `json:"maintenance"`
+	Definitions map[string]string           `json:"definitions,omitempty"` // game ID to active definition version
+	Operators   map[string]OperatorSettings `json:"operators,omitempty"`
+}
+
+// OperatorSettings override what the configuration applies to one operator;
+// an empty field keeps the configured behaviour
+type OperatorSettings struct {
+	Environment       string              `json:"environment,omitempty"`        // routes the operator ahead of ENV_ROUTE_OPERATORS
+	ComplianceProfile string              `json:"compliance_profile,omitempty"` // applies ahead of the compliance file's assignment
+	Limits            *responsible.Limits `json:"limits,omitempty"`             // the operator's responsible-gambling limits
+}
+
+// clone copies the state so a change can be prepared without touching it
+func (s State) clone() State {
+	s.Maintenance.Games = slices.Clone(s.Maintenance.Games)
+	s.Definitions = maps.Clone(s.Definitions)
+	operators := make(map[string]OperatorSettings, len(s.Operators))
+	for op, settings := range s.Operators {
+		if settings.Limits != nil {
+			limits := *settings.Limits
+			settings.Limits = &limits
+		}
+		operators[op] = settings
+	}
+	s.Operators = operators
+	return s
+}
+
+// validate checks that a state only refers to games, definition versions,
+// environments and compliance profiles that exist, and that every active
+// definition meets the compliance profiles' minimum RTP
+func (h *Handlers) validate(s State) error {
+	for _, id := range s.Maintenance.Games {
+		if _, ok := h.Games.Get(id); !ok {
+			return fmt.Errorf("%w: %s", games.ErrGameNotFound, id)
+		}
+	}
+	for id, version := range s.Definitions {
+		infos, _, ok := h.Games.Versions(id)
+		if !ok {
+			return fmt.Errorf("%w: %s", games.ErrGameNotFound, id)
+		}
+		i := slices.IndexFunc(infos, func(info games.Info) bool { return info.DefinitionVersion == version })
+		if i < 0 {
+			return fmt.Errorf("%w: %s %s", games.ErrVersionNotFound, id, version)
+		}
+		if err := h.Compliance.CheckRTP(id, infos[i].RTP); err != nil {
+			return err
+		}
+	}
+	for op, settings := range s.Operators {
+		if op == "" {
+			return errors.New("operator ID must not be empty")
+		}
+		if settings.Environment != "" {
+			if _, ok := h.Environments.Get(settings.Environment); !ok {
+				return fmt.Errorf("operator %s: environment %q is not defined", op, settings.Environment)
+			}
+		}
+		if settings.ComplianceProfile != "" {
+			if _, ok := h.Compliance.Profile(settings.ComplianceProfile); !ok {
+				return fmt.Errorf("operator %s: unknown compliance profile %q", op, settings.ComplianceProfile)
+			}
+		}
+		if settings.Limits != nil {
+			if h.Responsible == nil {
+				return errors.New("responsible gambling limits are not enabled")
+			}
+			if err := settings.Limits.Validate(); err != nil {
+				return fmt.Errorf("operator %s: %w", op, err)
+			}
+		}
+	}
+	return nil
+}
+
+// apply brings the running server from prev to next, which must be valid.
+// Operator limits are not applied here: change saves them with the state, and
+// the responsible-gambling service restores them itself.
+func (h *Handlers) apply(prev, next State) {
+	h.Maintenance.Set(next.Maintenance)
+	for id, version := range next.Definitions {
+		if err := h.Games.Activate(id, version); err != nil {
+			slog.Error("Error activating game definition", slog.String("game", id), slog.String("version", version), slog.Any("error", err))
+		}
+	}
+	for op := range prev.Operators {
+		if _, kept := next.Operators[op]; !kept {
+			h.applyOperator(op, OperatorSettings{})
+		}
+	}
+	for op, settings := range next.Operators {
+		h.applyOperator(op, settings)
+	}
+}
+
+// applyOperator replaces an operator's routing and compliance profile
+func (h *Handlers) applyOperator(op string, next OperatorSettings) {
+	if next.Environment != "" {
+		h.Environments.SetOperatorRoute(op, next.Environment)
+	} else {
+		h.Environments.ClearOperatorRoute(op)
+	}
+	if next.ComplianceProfile != "" {
+		h.Compliance.Assign(op, next.ComplianceProfile)
+	} else {
+		h.Compliance.Unassign(op)
+	}
+}
+
+// limitChanges returns the responsible-gambling limits that differ from prev
+// to next by operator; limits that were dropped are empty
+func limitChanges(prev, next State) map[string]responsible.Limits {
+	changes := make(map[string]responsible.Limits)
+	for op, settings := range prev.Operators {
+		if settings.Limits != nil && next.Operators[op].Limits == nil {
+			changes[op] = responsible.Limits{}
+		}
+	}
+	for op, settings := range next.Operators {
+		before := prev.Operators[op].Limits
+		if settings.Limits != nil && (before == nil || *before != *settings.Limits) {
+			changes[op] = *settings.Limits
+		}
+	}
+	return changes
+}
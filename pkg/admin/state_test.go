@@ -0,0 +1,206 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/config"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/environment"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/responsible"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games"
+)
+
+type stubGame struct{ version string }
+
+func (g stubGame) Info() games.Info {
+	return games.Info{ID: "slot", DefinitionVersion: g.version, RTP: 96}
+}
+func (stubGame) ValidateBet(int, float64) error                    { return nil }
+func (stubGame) Multiplier(float64, int) (int, bool)               { return 1, true }
+func (stubGame) WinningReels() []string                            { return nil }
+func (stubGame) LosingReels() []string                             { return nil }
+func (stubGame) Evaluate([]string, int, float64) (float64, string) { return 0, "" }
+func (stubGame) CheckDefinition() error                            { return nil }
+
+// newHandlers returns admin handlers over a game with versions 1 (active) and
+// 2, and the environments prod (default) and test
+func newHandlers(t *testing.T, st store.Store) *Handlers {
+	t.Helper()
+	registry, err := games.NewRegistry(stubGame{version: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.AddVersion(stubGame{version: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	router, err := environment.NewRouter(config.Routing{Default: "prod"},
+		&environment.Clients{Name: "prod"}, &environment.Clients{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Handlers{
+		Store:        st,
+		Games:        registry,
+		Maintenance:  &games.Maintenance{},
+		Environments: router,
+		Responsible:  responsible.NewService(st, responsible.Limits{}, time.Hour),
+	}
+}
+
+// saveState stores an admin state as a previous process would have
+func saveState(t *testing.T, st store.Store, state State) {
+	t.Helper()
+	data, _ := json.Marshal(state)
+	if err := st.Update(context.Background(), func(tx store.Tx) error { return tx.PutSetting(stateKey, data) }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreAppliesStoredState(t *testing.T) {
+	st := store.NewMemory()
+	saveState(t, st, State{
+		Maintenance: games.MaintenanceState{Games: []string{"slot"}, Message: "Back soon"},
+		Definitions: map[string]string{"slot": "2"},
+		Operators: map[string]OperatorSettings{
+			"op1": {Environment: "test"},
+		},
+	})
+	h := newHandlers(t, st)
+	if _, err := h.Restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, info, _ := h.Games.Active("slot"); info.DefinitionVersion != "2" {
+		t.Errorf("active version = %q, want 2", info.DefinitionVersion)
+	}
+	if message, down := h.Maintenance.Blocks("slot"); !down || message != "Back soon" {
+		t.Errorf("Blocks = %q, %v; want the game under maintenance", message, down)
+	}
+	if env := h.Environments.Resolve(environment.Request{OperatorID: "op1"}); env.Name != "test" {
+		t.Errorf("op1 routed to %s, want test", env.Name)
+	}
+
+	// Dropping the operator returns it to the configuration
+	next := h.state.clone()
+	delete(next.Operators, "op1")
+	h.apply(h.state, next)
+	if env := h.Environments.Resolve(environment.Request{OperatorID: "op1"}); env.Name != "prod" {
+		t.Errorf("op1 routed to %s after its settings were dropped, want prod", env.Name)
+	}
+}
+
+func TestRestoreKeepsLatestLimits(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	saveState(t, st, State{Operators: map[string]OperatorSettings{
+		"op1": {Limits: &responsible.Limits{Loss: responsible.WindowAmounts{Day: 50}}},
+	}})
+	// The operator changed its limits through /rg after the admin did
+	previous := responsible.NewService(st, responsible.Limits{}, time.Hour)
+	if err := previous.SetOperatorLimits(ctx, "op1", responsible.Limits{Loss: responsible.WindowAmounts{Day: 80}}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHandlers(t, st)
+	if err := h.Responsible.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if limits := h.Responsible.OperatorLimits("op1"); limits.Loss.Day != 80 {
+		t.Errorf("op1 daily loss limit = %v, want the latest 80", limits.Loss.Day)
+	}
+}
+
+// failingStore refuses every write once failing is set
+type failingStore struct {
+	store.Store
+	failing bool
+}
+
+func (s *failingStore) Update(ctx context.Context, fn func(store.Tx) error) error {
+	if s.failing {
+		return errors.New("disk full")
+	}
+	return s.Store.Update(ctx, fn)
+}
+
+func TestOperatorLimitsSavedWithAudit(t *testing.T) {
+	st := &failingStore{Store: store.NewMemory()}
+	h := newHandlers(t, st)
+	app := fiber.New()
+	h.Register(app.Group("/admin"))
+	put := func(limits string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/admin/operators/op1", strings.NewReader(`{"limits":`+limits+`}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	audited := func() int {
+		t.Helper()
+		var entries []store.AuditEntry
+		err := st.View(context.Background(), func(tx store.Tx) (err error) {
+			entries, err = tx.ListAudit(0, 10)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+
+	if status := put(`{"loss_limits":{"day":50}}`); status != http.StatusOK {
+		t.Fatalf("PUT = %d, want 200", status)
+	}
+	if limits := h.Responsible.OperatorLimits("op1"); limits.Loss.Day != 50 || audited() != 1 {
+		t.Fatalf("daily loss limit %v with %d audit entries, want 50 with 1", limits.Loss.Day, audited())
+	}
+
+	// A failed write changes nothing and records nothing
+	st.failing = true
+	if status := put(`{"loss_limits":{"day":20}}`); status != http.StatusInternalServerError {
+		t.Errorf("PUT with a failing store = %d, want 500", status)
+	}
+	st.failing = false
+	if limits := h.Responsible.OperatorLimits("op1"); limits.Loss.Day != 50 || audited() != 1 {
+		t.Errorf("daily loss limit %v with %d audit entries after a failed change, want 50 with 1", limits.Loss.Day, audited())
+	}
+	if got := h.state.Operators["op1"].Limits.Loss.Day; got != 50 {
+		t.Errorf("admin state daily loss limit = %v, want 50", got)
+	}
+}
+
+func TestRestoreRefusesUnknownReferences(t *testing.T) {
+	for name, state := range map[string]State{
+		"version":     {Definitions: map[string]string{"slot": "3"}},
+		"game":        {Maintenance: games.MaintenanceState{Games: []string{"other"}}},
+		"environment": {Operators: map[string]OperatorSettings{"op1": {Environment: "staging"}}},
+		"profile":     {Operators: map[string]OperatorSettings{"op1": {ComplianceProfile: "uk"}}},
+		"limits":      {Operators: map[string]OperatorSettings{"op1": {Limits: &responsible.Limits{MaxSessionMinutes: -1}}}},
+	} {
+		st := store.NewMemory()
+		saveState(t, st, state)
+		h := newHandlers(t, st)
+		if _, err := h.Restore(context.Background()); err == nil {
+			t.Errorf("%s: Restore succeeded", name)
+		}
+		if _, info, _ := h.Games.Active("slot"); info.DefinitionVersion != "1" {
+			t.Errorf("%s: a refused state was applied", name)
+		}
+	}
+}
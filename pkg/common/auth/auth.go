@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/logging"
+)
+
+// Request headers used to authenticate operators
+const (
+	APIKeyHeader    = "X-API-Key"
+	OperatorHeader  = "X-Operator-ID"
+	TimestampHeader = "X-Timestamp"
+	NonceHeader     = "X-Nonce"
+	SignatureHeader = "X-Signature"
+)
+
+const (
+	maxNonceLength   = 128
+	nonceSweepPeriod = time.Minute
+)
+
+// Config controls how requests are authenticated
+type Config struct {
+	RequireSignature bool          // reject plain API keys, accept only HMAC signatures
+	MaxClockSkew     time.Duration // how far X-Timestamp may be from the server clock
+
+	// Observe, when set, is called with the reason of every rejected request
+	Observe func(reason string)
+}
+
+// Authenticator verifies operator credentials on incoming requests
+type Authenticator struct {
+	store  *Store
+	cfg    Config
+	mu     sync.Mutex
+	nonces map[string]time.Time // operator ID + nonce -> expiry
+	swept  time.Time
+}
+
+// NewAuthenticator creates an authenticator over the given credentials
+func NewAuthenticator(store *Store, cfg Config) *Authenticator {
+	if cfg.MaxClockSkew <= 0 {
+		cfg.MaxClockSkew = 5 * time.Minute
+	}
+	return &Authenticator{store: store, cfg: cfg, nonces: make(map[string]time.Time)}
+}
+
+// Sign computes the signature an operator sends in X-Signature: the hex
+// HMAC-SHA256, keyed with the operator's secret, of the timestamp, nonce,
+// method, request URI and raw body joined by newlines. The request URI is the
+// path with its query string exactly as sent, such as /rounds?player_id=p1.
+func Sign(secret, timestamp, nonce, method, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n" + method + "\n" + requestURI + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware rejects requests without valid operator credentials and stores
+// the authenticated operator in the request context
+func (a *Authenticator) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		op, reason := a.authenticate(c)
+		if op == nil {
+			if a.cfg.Observe != nil {
+				a.cfg.Observe(reason)
+			}
+			slog.WarnContext(c.UserContext(), "Operator authentication failed", slog.String("reason", reason))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operator authentication failed: " + reason,
+			})
+		}
+
+		ctx := context.WithValue(c.UserContext(), operatorKey{}, op)
+		c.SetUserContext(logging.With(ctx, slog.String(logging.KeyOperatorID, op.ID)))
+		return c.Next()
+	}
+}
+
+// authenticate returns the caller's operator, or nil and the rejection reason
+func (a *Authenticator) authenticate(c *fiber.Ctx) (*Operator, string) {
+	if c.Get(SignatureHeader) != "" {
+		return a.verifySignature(c)
+	}
+	if key := c.Get(APIKeyHeader); key != "" {
+		if a.cfg.RequireSignature {
+			return nil, "signature_required"
+		}
+		op, ok := a.store.OperatorByAPIKey(key)
+		if !ok {
+			return nil, "invalid_api_key"
+		}
+		return op, ""
+	}
+	return nil, "missing_credentials"
+}
+
+// verifySignature checks an HMAC-signed request and records its nonce
+func (a *Authenticator) verifySignature(c *fiber.Ctx) (*Operator, string) {
+	op, ok := a.store.Operator(c.Get(OperatorHeader))
+	if !ok {
+		return nil, "unknown_operator"
+	}
+
+	timestamp := c.Get(TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, "invalid_timestamp"
+	}
+	now := time.Now()
+	if skew := now.Sub(time.Unix(seconds, 0)).Abs(); skew > a.cfg.MaxClockSkew {
+		return nil, "stale_timestamp"
+	}
+
+	nonce := c.Get(NonceHeader)
+	if nonce == "" || len(nonce) > maxNonceLength {
+		return nil, "invalid_nonce"
+	}
+
+	signature, err := hex.DecodeString(c.Get(SignatureHeader))
+	if err != nil {
+		return nil, "invalid_signature"
+	}
+	valid := false
+	for _, secret := range op.Secrets {
+		expected, _ := hex.DecodeString(Sign(secret, timestamp, nonce, c.Method(), c.OriginalURL(), c.Body()))
+		if hmac.Equal(signature, expected) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, "invalid_signature"
+	}
+
+	// Only a correctly signed request may consume a nonce
+	if !a.useNonce(op.ID+"\x00"+nonce, now) {
+		return nil, "replayed_nonce"
+	}
+	return op, ""
+}
+
+// useNonce records a nonce and reports whether it was unused. Nonces are
+// remembered for twice the allowed clock skew, after which their timestamp
+// alone gets the request rejected.
+func (a *Authenticator) useNonce(key string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if now.Sub(a.swept) > nonceSweepPeriod {
+		for k, expiry := range a.nonces {
+			if now.After(expiry) {
+				delete(a.nonces, k)
+			}
+		}
+		a.swept = now
+	}
+
+	if expiry, seen := a.nonces[key]; seen && now.Before(expiry) {
+		return false
+	}
+	a.nonces[key] = now.Add(2 * a.cfg.MaxClockSkew)
+	return true
+}
+
+type operatorKey struct{}
+
+// OperatorFrom returns the operator authenticated for the request, if any
+func OperatorFrom(ctx context.Context) (*Operator, bool) {
+	op, ok := ctx.Value(operatorKey{}).(*Operator)
+	return op, ok
+}
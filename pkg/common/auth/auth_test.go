@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const testOperators = `{"operators": [
+	{"id": "op-1", "client_ids": ["1001"], "api_keys": ["key-1"], "secrets": ["new-secret", "old-secret"]},
+	{"id": "op-2", "client_ids": ["2001"], "api_keys": ["key-2"], "secrets": ["secret-2"]}
+]}`
+
+func newTestStore(t *testing.T, operators string) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "operators.json")
+	if err := os.WriteFile(path, []byte(operators), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, path
+}
+
+// signed builds a request carrying body with a signature computed over signedBody
+func signed(operator, secret string, at time.Time, nonce, body, signedBody string) *http.Request {
+	return signedTo("/spin", "/spin", operator, secret, at, nonce, body, signedBody)
+}
+
+// signedTo builds a request to target with a signature computed over
+// signedTarget and signedBody
+func signedTo(target, signedTarget, operator, secret string, at time.Time, nonce, body, signedBody string) *http.Request {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(OperatorHeader, operator)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(NonceHeader, nonce)
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, nonce, http.MethodPost, signedTarget, []byte(signedBody)))
+	return req
+}
+
+func withAPIKey(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/spin", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(APIKeyHeader, key)
+	return req
+}
+
+func TestMiddleware(t *testing.T) {
+	creds, _ := newTestStore(t, testOperators)
+	var rejected []string
+	authenticator := NewAuthenticator(creds, Config{
+		MaxClockSkew: time.Minute,
+		Observe:      func(reason string) { rejected = append(rejected, reason) },
+	})
+
+	// The handler mirrors the spin handlers: the operator must own the body's client_id
+	app := fiber.New()
+	app.Post("/spin", authenticator.Middleware(), func(c *fiber.Ctx) error {
+		op, ok := OperatorFrom(c.UserContext())
+		if !ok {
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+		var body struct {
+			ClientID string `json:"client_id"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return err
+		}
+		if !op.Allows(body.ClientID) {
+			return c.SendStatus(fiber.StatusForbidden)
+		}
+		return c.SendString(op.ID)
+	})
+
+	now := time.Now()
+	body := `{"client_id":"1001"}`
+	for _, tt := range []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantReason string
+	}{
+		{"valid signature", signed("op-1", "new-secret", now, "n-1", body, body), http.StatusOK, ""},
+		{"previous secret", signed("op-1", "old-secret", now, "n-2", body, body), http.StatusOK, ""},
+		{"tampered body", signed("op-1", "new-secret", now, "n-3", `{"client_id":"1002"}`, body), http.StatusUnauthorized, "invalid_signature"},
+		{"wrong secret", signed("op-1", "secret-2", now, "n-4", body, body), http.StatusUnauthorized, "invalid_signature"},
+		{"timestamp too old", signed("op-1", "new-secret", now.Add(-2*time.Minute), "n-5", body, body), http.StatusUnauthorized, "stale_timestamp"},
+		{"timestamp too new", signed("op-1", "new-secret", now.Add(2*time.Minute), "n-6", body, body), http.StatusUnauthorized, "stale_timestamp"},
+		{"reused nonce", signed("op-1", "new-secret", now, "n-1", body, body), http.StatusUnauthorized, "replayed_nonce"},
+		{"nonce of another operator", signed("op-2", "secret-2", now, "n-1", `{"client_id":"2001"}`, `{"client_id":"2001"}`), http.StatusOK, ""},
+		{"unknown operator", signed("op-9", "new-secret", now, "n-7", body, body), http.StatusUnauthorized, "unknown_operator"},
+		{"signed query", signedTo("/spin?player_id=p1", "/spin?player_id=p1", "op-1", "new-secret", now, "n-8", body, body), http.StatusOK, ""},
+		{"tampered query", signedTo("/spin?player_id=p2", "/spin?player_id=p1", "op-1", "new-secret", now, "n-9", body, body), http.StatusUnauthorized, "invalid_signature"},
+		{"unsigned query", signedTo("/spin?player_id=p1", "/spin", "op-1", "new-secret", now, "n-10", body, body), http.StatusUnauthorized, "invalid_signature"},
+		{"valid API key", withAPIKey("key-1", body), http.StatusOK, ""},
+		{"unknown API key", withAPIKey("key-9", body), http.StatusUnauthorized, "invalid_api_key"},
+		{"no credentials", httptest.NewRequest(http.MethodPost, "/spin", nil), http.StatusUnauthorized, "missing_credentials"},
+		{"client_id of another operator", withAPIKey("key-2", body), http.StatusForbidden, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rejected = nil
+			resp, err := app.Test(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantReason == "" && len(rejected) > 0 {
+				t.Errorf("rejected with %v, want accepted", rejected)
+			}
+			if tt.wantReason != "" && (len(rejected) != 1 || rejected[0] != tt.wantReason) {
+				t.Errorf("rejection reasons = %v, want [%s]", rejected, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRequireSignature(t *testing.T) {
+	creds, _ := newTestStore(t, testOperators)
+	app := fiber.New()
+	app.Post("/spin", NewAuthenticator(creds, Config{RequireSignature: true}).Middleware(), func(c *fiber.Ctx) error {
+		return nil
+	})
+
+	resp, err := app.Test(withAPIKey("key-1", `{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("API key with signatures required: status %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestNonceExpiry(t *testing.T) {
+	a := NewAuthenticator(nil, Config{MaxClockSkew: time.Minute})
+	now := time.Now()
+	if !a.useNonce("op\x00n", now) {
+		t.Fatal("first use of a nonce refused")
+	}
+	if a.useNonce("op\x00n", now.Add(time.Minute)) {
+		t.Fatal("nonce reused within twice the clock skew")
+	}
+	if !a.useNonce("op\x00n", now.Add(2*time.Minute+time.Second)) {
+		t.Fatal("nonce still refused after it expired")
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sync/atomic"
+	"syscall"
+)
+
+// Operator is a casino operator allowed to call the spin API
+type Operator struct {
+	ID        string   `json:"id"`
+	ClientIDs []string `json:"client_ids"` // client_id values this operator may spin for
+	APIKeys   []string `json:"api_keys"`   // accepted X-API-Key values
+	Secrets   []string `json:"secrets"`    // accepted HMAC signing secrets
+}
+
+// Allows reports whether the operator may act for the given client_id
+func (o *Operator) Allows(clientID string) bool {
+	return slices.Contains(o.ClientIDs, clientID)
+}
+
+// operatorsFile is the layout of the credentials file
+type operatorsFile struct {
+	Operators []Operator `json:"operators"`
+}
+
+// registry is an immutable snapshot of the credentials file
+type registry struct {
+	byID     map[string]*Operator
+	byAPIKey map[[sha256.Size]byte]*Operator
+}
+
+// Store holds the operator credentials. Reload swaps them atomically, so keys
+// can be rotated by listing old and new values together, reloading, and
+// removing the old value once every caller has switched.
+type Store struct {
+	path    string
+	current atomic.Pointer[registry]
+}
+
+// NewStore loads the operator credentials from a JSON file
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the credentials file. On error the previous credentials stay active.
+func (s *Store) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("reading operators file: %w", err)
+	}
+	var file operatorsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parsing operators file: %w", err)
+	}
+
+	reg := &registry{
+		byID:     make(map[string]*Operator, len(file.Operators)),
+		byAPIKey: make(map[[sha256.Size]byte]*Operator),
+	}
+	for i := range file.Operators {
+		op := &file.Operators[i]
+		if op.ID == "" {
+			return fmt.Errorf("operator %d has no id", i)
+		}
+		if _, dup := reg.byID[op.ID]; dup {
+			return fmt.Errorf("operator %q defined twice", op.ID)
+		}
+		if len(op.ClientIDs) == 0 {
+			return fmt.Errorf("operator %q has no client_ids", op.ID)
+		}
+		reg.byID[op.ID] = op
+		for _, key := range op.APIKeys {
+			digest := sha256.Sum256([]byte(key))
+			if _, dup := reg.byAPIKey[digest]; dup || key == "" {
+				return fmt.Errorf("operator %q has an empty or duplicate API key", op.ID)
+			}
+			reg.byAPIKey[digest] = op
+		}
+	}
+	s.current.Store(reg)
+	return nil
+}
+
+// Operator returns the operator with the given ID
+func (s *Store) Operator(id string) (*Operator, bool) {
+	op, ok := s.current.Load().byID[id]
+	return op, ok
+}
+
+// OperatorByAPIKey returns the operator owning an API key. Keys are looked up
+// by digest so the comparison does not leak timing about the key's contents.
+func (s *Store) OperatorByAPIKey(key string) (*Operator, bool) {
+	op, ok := s.current.Load().byAPIKey[sha256.Sum256([]byte(key))]
+	return op, ok
+}
+
+// Len returns the number of configured operators
+func (s *Store) Len() int {
+	return len(s.current.Load().byID)
+}
+
+// ReloadOnSIGHUP re-reads the credentials file whenever the process receives SIGHUP
+func (s *Store) ReloadOnSIGHUP() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := s.Reload(); err != nil {
+				slog.Error("Error reloading operator credentials, keeping previous ones", slog.Any("error", err))
+				continue
+			}
+			slog.Info("Operator credentials reloaded", slog.Int("operators", s.Len()))
+		}
+	}()
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStoreValidation(t *testing.T) {
+	for name, operators := range map[string]string{
+		"missing id":         `{"operators": [{"client_ids": ["1"]}]}`,
+		"duplicate id":       `{"operators": [{"id": "a", "client_ids": ["1"]}, {"id": "a", "client_ids": ["2"]}]}`,
+		"no client_ids":      `{"operators": [{"id": "a"}]}`,
+		"empty API key":      `{"operators": [{"id": "a", "client_ids": ["1"], "api_keys": [""]}]}`,
+		"shared API key":     `{"operators": [{"id": "a", "client_ids": ["1"], "api_keys": ["k"]}, {"id": "b", "client_ids": ["2"], "api_keys": ["k"]}]}`,
+		"malformed document": `{"operators": [`,
+	} {
+		path := t.TempDir() + "/operators.json"
+		if err := os.WriteFile(path, []byte(operators), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewStore(path); err == nil {
+			t.Errorf("%s: NewStore succeeded", name)
+		}
+	}
+}
+
+func TestStoreReloadKeepsPreviousOnError(t *testing.T) {
+	s, path := newTestStore(t, testOperators)
+	if op, ok := s.OperatorByAPIKey("key-1"); !ok || op.ID != "op-1" {
+		t.Fatalf("OperatorByAPIKey(key-1) = %v, %v", op, ok)
+	}
+	if op, _ := s.Operator("op-1"); !op.Allows("1001") || op.Allows("2001") {
+		t.Fatal("op-1 Allows does not match its client_ids")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"operators": [{"id": "op-1"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(); err == nil {
+		t.Fatal("Reload accepted an invalid file")
+	}
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d after a failed reload, want the previous 2", s.Len())
+	}
+
+	rotated := `{"operators": [{"id": "op-1", "client_ids": ["1001"], "api_keys": ["key-1b"]}]}`
+	if err := os.WriteFile(path, []byte(rotated), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.OperatorByAPIKey("key-1"); ok {
+		t.Error("removed API key still accepted after Reload")
+	}
+	if _, ok := s.OperatorByAPIKey("key-1b"); !ok {
+		t.Error("new API key not accepted after Reload")
+	}
+}
@@ -0,0 +1,270 @@
+package compliance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Duration is a time.Duration written as "2.5s" in the profiles file
+type Duration time.Duration
+
+// UnmarshalJSON parses a Go duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration as a Go duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Profile is the set of rules a market imposes on play
+type Profile struct {
+	Name            string   `json:"name"`
+	MinRTP          float64  `json:"min_rtp,omitempty"`           // percent; 0 for no minimum
+	MaxBet          float64  `json:"max_bet,omitempty"`           // 0 for no maximum
+	MaxWin          float64  `json:"max_win,omitempty"`           // 0 for no maximum
+	RetentionDays   int      `json:"retention_days,omitempty"`    // round history must be kept this long
+	GambleAllowed   bool     `json:"gamble_allowed"`              // double-or-nothing after a win
+	AutoplayAllowed bool     `json:"autoplay_allowed"`            // spins started by the client without a player action
+	MinSpinInterval Duration `json:"min_spin_interval,omitempty"` // shortest time between a player's spins
+}
+
+// CapWin returns the lower of an operator's maximum win and the profile's;
+// 0 means uncapped
+func (p *Profile) CapWin(operatorMax float64) float64 {
+	switch {
+	case p.MaxWin <= 0:
+		return operatorMax
+	case operatorMax <= 0:
+		return p.MaxWin
+	default:
+		return min(operatorMax, p.MaxWin)
+	}
+}
+
+// profilesFile is the layout of the profiles file
+type profilesFile struct {
+	Default   string            `json:"default"`
+	Profiles  []Profile         `json:"profiles"`
+	Operators map[string]string `json:"operators"` // operator ID to profile name
+}
+
+// Profiles holds the compliance profiles and which operators they apply to
+type Profiles struct {
+	byName     map[string]*Profile
+	byOperator map[string]*Profile
+	fallback   *Profile
+
+	// Assignments made while the server runs, ahead of the file's
+	mu       sync.RWMutex
+	assigned map[string]*Profile
+}
+
+// Load reads compliance profiles from a JSON file
+func Load(path string) (*Profiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading compliance file: %w", err)
+	}
+	var file profilesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing compliance file: %w", err)
+	}
+
+	p := &Profiles{
+		byName:     make(map[string]*Profile, len(file.Profiles)),
+		byOperator: make(map[string]*Profile, len(file.Operators)),
+		assigned:   make(map[string]*Profile),
+	}
+	for i := range file.Profiles {
+		profile := &file.Profiles[i]
+		if err := profile.validate(); err != nil {
+			return nil, err
+		}
+		if _, dup := p.byName[profile.Name]; dup {
+			return nil, fmt.Errorf("compliance profile %q defined twice", profile.Name)
+		}
+		p.byName[profile.Name] = profile
+	}
+	for operator, name := range file.Operators {
+		profile, ok := p.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("operator %q uses unknown compliance profile %q", operator, name)
+		}
+		p.byOperator[operator] = profile
+	}
+	if file.Default != "" {
+		profile, ok := p.byName[file.Default]
+		if !ok {
+			return nil, fmt.Errorf("unknown default compliance profile %q", file.Default)
+		}
+		p.fallback = profile
+	}
+	return p, nil
+}
+
+// validate rejects a profile that cannot be enforced
+func (p *Profile) validate() error {
+	if p.Name == "" {
+		return errors.New("compliance profile has no name")
+	}
+	if p.MinRTP < 0 || p.MinRTP > 100 {
+		return fmt.Errorf("compliance profile %q: min_rtp %v is outside 0..100", p.Name, p.MinRTP)
+	}
+	if p.MaxBet < 0 || p.MaxWin < 0 || p.RetentionDays < 0 || p.MinSpinInterval < 0 {
+		return fmt.Errorf("compliance profile %q: limits must not be negative", p.Name)
+	}
+	return nil
+}
+
+// For returns the profile applying to an operator: its own, the default, or
+// nil when neither is configured. A nil *Profiles applies none.
+func (p *Profiles) For(operatorID string) *Profile {
+	if p == nil {
+		return nil
+	}
+	p.mu.RLock()
+	profile, ok := p.assigned[operatorID]
+	p.mu.RUnlock()
+	if ok {
+		return profile
+	}
+	if profile, ok := p.byOperator[operatorID]; ok {
+		return profile
+	}
+	return p.fallback
+}
+
+// Profile returns the profile with the given name
+func (p *Profiles) Profile(name string) (*Profile, bool) {
+	if p == nil {
+		return nil, false
+	}
+	profile, ok := p.byName[name]
+	return profile, ok
+}
+
+// Assign applies the named profile to an operator, ahead of the file's assignment
+func (p *Profiles) Assign(operatorID, name string) error {
+	if p == nil {
+		return errors.New("no compliance profiles are loaded")
+	}
+	profile, ok := p.Profile(name)
+	if !ok {
+		return fmt.Errorf("unknown compliance profile %q", name)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.assigned[operatorID] = profile
+	return nil
+}
+
+// Unassign drops an assignment made by Assign
+func (p *Profiles) Unassign(operatorID string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.assigned, operatorID)
+}
+
+// All returns every profile ordered by name
+func (p *Profiles) All() []*Profile {
+	if p == nil {
+		return nil
+	}
+	profiles := make([]*Profile, 0, len(p.byName))
+	for _, profile := range p.byName {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles
+}
+
+// CheckRTP reports the first profile whose minimum RTP a game with a
+// theoretical RTP of rtp percent does not meet
+func (p *Profiles) CheckRTP(game string, rtp float64) error {
+	for _, profile := range p.All() {
+		if profile.MinRTP > 0 && rtp < profile.MinRTP {
+			return fmt.Errorf("game %s has a theoretical RTP of %v%%, below the %v%% minimum of compliance profile %q",
+				game, rtp, profile.MinRTP, profile.Name)
+		}
+	}
+	return nil
+}
+
+// Retention returns the longest round-history retention any profile requires
+func (p *Profiles) Retention() time.Duration {
+	days := 0
+	for _, profile := range p.All() {
+		days = max(days, profile.RetentionDays)
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Pacer enforces a minimum interval between each player's spins. A spin is
+// checked with Wait before its round begins and recorded once it has.
+type Pacer struct {
+	mu        sync.Mutex
+	last      map[string]time.Time
+	longest   time.Duration // the longest interval recorded; older spins no longer matter
+	lastSweep time.Time
+}
+
+// NewPacer creates a pacer with no spins recorded
+func NewPacer() *Pacer {
+	return &Pacer{last: make(map[string]time.Time)}
+}
+
+// pacerSweepInterval is how often spins older than any interval are forgotten
+const pacerSweepInterval = time.Hour
+
+// Wait returns how long a spin by key at now must wait to come interval after
+// the previous one, or 0 when it may go ahead
+func (p *Pacer) Wait(key string, interval time.Duration, now time.Time) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	last, ok := p.last[key]
+	if !ok {
+		return 0
+	}
+	return max(interval-now.Sub(last), 0)
+}
+
+// Record records a spin by key at now, which the next must follow by interval
+func (p *Pacer) Record(key string, interval time.Duration, now time.Time) {
+	if interval <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last[key] = now
+	p.longest = max(p.longest, interval)
+	if now.Sub(p.lastSweep) > pacerSweepInterval {
+		p.lastSweep = now
+		for k, t := range p.last {
+			if now.Sub(t) > p.longest {
+				delete(p.last, k)
+			}
+		}
+	}
+}
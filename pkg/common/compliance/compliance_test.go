@@ -0,0 +1,214 @@
+package compliance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeProfiles writes a profiles file and returns its path
+func writeProfiles(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "compliance.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testProfiles = `{
+	"default": "eu",
+	"profiles": [
+		{"name": "eu", "min_rtp": 92, "max_bet": 100, "retention_days": 365, "gamble_allowed": true, "autoplay_allowed": true},
+		{"name": "uk", "min_rtp": 94, "max_bet": 5, "retention_days": 1825, "min_spin_interval": "2.5s"},
+		{"name": "open", "autoplay_allowed": true}
+	],
+	"operators": {"op-uk": "uk"}
+}`
+
+func TestLoad(t *testing.T) {
+	p, err := Load(writeProfiles(t, testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uk, ok := p.Profile("uk")
+	if !ok {
+		t.Fatal("profile uk not loaded")
+	}
+	if time.Duration(uk.MinSpinInterval) != 2500*time.Millisecond || uk.MaxBet != 5 || uk.AutoplayAllowed {
+		t.Errorf("uk = %+v", uk)
+	}
+	var names []string
+	for _, profile := range p.All() {
+		names = append(names, profile.Name)
+	}
+	if got := strings.Join(names, ","); got != "eu,open,uk" {
+		t.Errorf("All() = %s, want eu,open,uk", got)
+	}
+}
+
+func TestLoadRejectsInvalidProfiles(t *testing.T) {
+	for _, tt := range []struct {
+		name, content, want string
+	}{
+		{"unnamed profile", `{"profiles": [{"min_rtp": 90}]}`, "has no name"},
+		{"min_rtp above 100", `{"profiles": [{"name": "a", "min_rtp": 101}]}`, "outside 0..100"},
+		{"negative min_rtp", `{"profiles": [{"name": "a", "min_rtp": -1}]}`, "outside 0..100"},
+		{"negative max_bet", `{"profiles": [{"name": "a", "max_bet": -1}]}`, "must not be negative"},
+		{"negative max_win", `{"profiles": [{"name": "a", "max_win": -1}]}`, "must not be negative"},
+		{"negative retention", `{"profiles": [{"name": "a", "retention_days": -1}]}`, "must not be negative"},
+		{"negative spin interval", `{"profiles": [{"name": "a", "min_spin_interval": "-1s"}]}`, "must not be negative"},
+		{"invalid spin interval", `{"profiles": [{"name": "a", "min_spin_interval": "soon"}]}`, "parsing compliance file"},
+		{"duplicate profile", `{"profiles": [{"name": "a"}, {"name": "a"}]}`, "defined twice"},
+		{"unknown operator profile", `{"profiles": [{"name": "a"}], "operators": {"op": "b"}}`, "unknown compliance profile"},
+		{"unknown default", `{"default": "b", "profiles": [{"name": "a"}]}`, "unknown default"},
+		{"malformed file", `{`, "parsing compliance file"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeProfiles(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
+
+func TestFor(t *testing.T) {
+	p, err := Load(writeProfiles(t, testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := func(profile *Profile) string {
+		if profile == nil {
+			return ""
+		}
+		return profile.Name
+	}
+
+	if got := name(p.For("op-uk")); got != "uk" {
+		t.Errorf("For(op-uk) = %q, want the file's assignment uk", got)
+	}
+	if got := name(p.For("op-other")); got != "eu" {
+		t.Errorf("For(op-other) = %q, want the default eu", got)
+	}
+
+	// An assignment made at runtime comes ahead of the file's, until dropped
+	if err := p.Assign("op-uk", "open"); err != nil {
+		t.Fatal(err)
+	}
+	if got := name(p.For("op-uk")); got != "open" {
+		t.Errorf("For(op-uk) after Assign = %q, want open", got)
+	}
+	p.Unassign("op-uk")
+	if got := name(p.For("op-uk")); got != "uk" {
+		t.Errorf("For(op-uk) after Unassign = %q, want uk", got)
+	}
+	if err := p.Assign("op-uk", "missing"); err == nil {
+		t.Error("Assign of an unknown profile succeeded")
+	}
+
+	// Without a default, unassigned operators have no profile
+	noDefault, err := Load(writeProfiles(t, `{"profiles": [{"name": "a"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := noDefault.For("op"); got != nil {
+		t.Errorf("For without a default = %+v, want nil", got)
+	}
+
+	// No profiles loaded applies none
+	var none *Profiles
+	if got := none.For("op"); got != nil {
+		t.Errorf("nil Profiles For = %+v, want nil", got)
+	}
+	if err := none.Assign("op", "a"); err == nil {
+		t.Error("Assign on nil Profiles succeeded")
+	}
+}
+
+func TestRetention(t *testing.T) {
+	p, err := Load(writeProfiles(t, testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.Retention(), 1825*24*time.Hour; got != want {
+		t.Errorf("Retention = %v, want %v", got, want)
+	}
+	var none *Profiles
+	if got := none.Retention(); got != 0 {
+		t.Errorf("nil Profiles Retention = %v, want 0", got)
+	}
+}
+
+func TestCheckRTP(t *testing.T) {
+	p, err := Load(writeProfiles(t, testProfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CheckRTP("slot", 96); err != nil {
+		t.Errorf("CheckRTP(96) = %v, want nil", err)
+	}
+	if err := p.CheckRTP("slot", 93); err == nil || !strings.Contains(err.Error(), `"uk"`) {
+		t.Errorf("CheckRTP(93) = %v, want the uk minimum refused", err)
+	}
+}
+
+func TestCapWin(t *testing.T) {
+	for _, tt := range []struct {
+		profile, operator, want float64
+	}{
+		{0, 0, 0},
+		{0, 500, 500},
+		{1000, 0, 1000},
+		{1000, 500, 500},
+		{250, 500, 250},
+	} {
+		p := &Profile{MaxWin: tt.profile}
+		if got := p.CapWin(tt.operator); got != tt.want {
+			t.Errorf("CapWin with profile %v, operator %v = %v, want %v", tt.profile, tt.operator, got, tt.want)
+		}
+	}
+}
+
+func TestPacer(t *testing.T) {
+	p := NewPacer()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if wait := p.Wait("p1", 2*time.Second, start); wait != 0 {
+		t.Fatalf("first spin waits %v", wait)
+	}
+	if wait := p.Wait("p1", 2*time.Second, start.Add(500*time.Millisecond)); wait != 0 {
+		t.Errorf("spin after an unrecorded one waits %v", wait)
+	}
+	p.Record("p1", 2*time.Second, start)
+	if wait := p.Wait("p1", 2*time.Second, start.Add(500*time.Millisecond)); wait != 1500*time.Millisecond {
+		t.Errorf("early spin waits %v, want 1.5s", wait)
+	}
+	if wait := p.Wait("p2", 2*time.Second, start.Add(500*time.Millisecond)); wait != 0 {
+		t.Errorf("another player's spin waits %v", wait)
+	}
+	if wait := p.Wait("p1", 2*time.Second, start.Add(2*time.Second)); wait != 0 {
+		t.Errorf("spin after the interval waits %v", wait)
+	}
+	if wait := p.Wait("p1", 0, start.Add(time.Second)); wait != 0 {
+		t.Errorf("spin without an interval waits %v", wait)
+	}
+}
+
+func TestPacerKeepsLongIntervals(t *testing.T) {
+	p := NewPacer()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	p.Record("p1", 3*time.Hour, start)
+	p.Record("p2", 3*time.Hour, start.Add(2*time.Hour)) // sweeps
+
+	if wait := p.Wait("p1", 3*time.Hour, start.Add(2*time.Hour)); wait != time.Hour {
+		t.Errorf("spin 2h into a 3h interval waits %v after a sweep, want 1h", wait)
+	}
+}
@@ -1,33 +1,134 @@
 package config
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration from environment
 type Config struct {
-	RNGServiceURL      string
-	SettingsServiceURL string
-	ServerPort         string
-	LogFile            string
+	Environments []Environment // named RNG/settings backends, see environments.go
+	Routing      Routing       // how requests are assigned to environments
+	ServerPort   string
+	LogFile      string
+	SpinTimeout  time.Duration // deadline for a spin's settings and RNG calls
+	LogLevel     string        // debug, info, warn or error
+
+	// Graceful shutdown and probes
+	ShutdownTimeout    time.Duration // how long in-flight spins get to finish
+	ShutdownDrainDelay time.Duration // how long /readyz fails before the listener closes
+	ReadinessTimeout   time.Duration // per-check timeout for /readyz
+
+	// Log rotation
+	LogMaxSizeMB   int
+	LogMaxAgeDays  int
+	LogMaxBackups  int
+	LogCompress    bool
+	LogRotateDaily bool
+
+	// Resilience settings for the RNG, settings and wallet clients
+	RNGTimeout              time.Duration
+	SettingsTimeout         time.Duration
+	WalletTimeout           time.Duration
+	RNGMaxConcurrent        int
+	SettingsMaxConcurrent   int
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      time.Duration
+	BreakerHalfOpenProbes   int
+
+	// RTP settings cache
+	SettingsCacheTTL            time.Duration
+	SettingsCacheStaleTTL       time.Duration
+	SettingsCacheMaxFallbackAge time.Duration // oldest value served while the settings service fails
+
+	// Admin API, served on its own port when any admin token is set
+	AdminToken     string            // token of the "admin" actor
+	AdminTokens    map[string]string // actor name to token, so the audit trail names who made a change
+	AdminPort      string
+	DefinitionsDir string // extra game definition versions the admin API can activate
+
+	// Operator authentication
+	OperatorsFile        string        // JSON operator credentials; authentication is off when empty
+	AuthRequireSignature bool          // accept only HMAC-signed requests, not plain API keys
+	AuthMaxClockSkew     time.Duration // allowed distance between X-Timestamp and the server clock
+
+	// Client addresses behind a load balancer. ProxyHeader is only believed
+	// on connections from TrustedProxies (IPs or CIDR ranges).
+	ProxyHeader    string
+	TrustedProxies []string
+
+	// Spin rate limits; a rate of 0 disables that limit
+	RateLimitIPRPS              float64
+	RateLimitIPBurst            int
+	RateLimitPlayerRPS          float64
+	RateLimitPlayerBurst        int
+	RateLimitOperatorRPS        float64
+	RateLimitOperatorBurst      int
+	MaxConcurrentSpinsPerPlayer int
+
+	// Responsible gambling defaults; operators and players can only tighten them
+	RGLossLimitDay        float64
+	RGWagerLimitDay       float64
+	RGMaxSessionMinutes   int
+	RGRealityCheckMinutes int
+	RGSessionIdleTimeout  time.Duration // a play session ends after this long without spins
+
+	// Per-market compliance profiles; none apply when empty
+	ComplianceFile string
+
+	// How losses are presented; empty keeps each game definition's policy
+	LossPolicy          string  // curated or reel_strip
+	NearMissMaxRate     float64 // largest share of losses shown as near misses; 0 for no cap
+	NearMissCapAtChance bool    // never show near misses more often than the reel strips give by chance
+
+	// Storage for rounds, ledger entries, idempotent responses and revocations
+	StoreDriver    string        // memory, bolt or sqlite
+	StorePath      string        // database file for bolt and sqlite
+	IdempotencyTTL time.Duration // how long a retried bet_id replays its response
+
+	// Shortest time from a round's creation to its result being shown
+	MinRoundDuration time.Duration
+
+	// Rounds left open by a previous process are completed or voided at
+	// startup, and rounds a spin failed to complete every interval after
+	ReconcileOnStart   bool
+	ReconcileInterval  time.Duration // 0 disables the periodic runs
+	ReconcileReportDir string        // a JSON report is written here when rounds were reconciled
+
+	// Player sessions; spins require a session token when signing keys are set
+	SessionSigningKeys []string // the first key signs, all of them verify
+	SessionTTL         time.Duration
+
+	// Tracing
+	TraceExporter    string // none, stdout, file or otlp
+	TraceFile        string
+	TraceSampleRatio float64
 }
 
 // Load loads configuration from environment variables
 func Load() Config {
 	// Try to load .env file, but don't fail if it doesn't exist
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found or error loading it")
+		slog.Info("No .env file found or error loading it")
 	}
 
-	return Config{
-		RNGServiceURL:      getEnv("RNG_API_URL", "http://159.89.235.166:17003/api/proxy/rng/1"),
-		SettingsServiceURL: getEnv("SETTINGS_API_URL", "https://t3.ibibe.africa/get-game-settings"),
-		ServerPort:         getEnv("PORT", "11400"),
-		LogFile:            getEnv("LOG_FILE", "app.log"),
+	cfg := Config{
+		Environments: []Environment{{
+			Name:               "default",
+			RNGServiceURL:      getEnv("RNG_API_URL", "http://159.89.235.166:17003/api/proxy/rng/1"),
+			SettingsServiceURL: getEnv("SETTINGS_API_URL", "https://t3.ibibe.africa/get-game-settings"),
+		}},
+		Routing:    Routing{Default: "default"},
+		ServerPort: getEnv("PORT", "11400"),
+		LogFile:    getEnv("LOG_FILE", "app.log"),
 	}
+	loadShared(&cfg)
+	return cfg
 }
 
 // Function to get an environment variable or a default value
@@ -39,24 +140,129 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-// LoadAll loads both production and test configurations from environment variables
-func LoadAll() (prod Config, test Config) {
+// getEnvInt gets an integer environment variable or a default value
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool gets a boolean environment variable ("true", "1", "false", ...) or a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloat gets a float environment variable or a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "2s") or a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// loadShared fills in the server-wide settings (timeouts, logging, breakers,
+// concurrency, cache, admin API, authentication, sessions, rate limits, responsible gambling, round history and tracing), which are shared by every environment
+func loadShared(cfg *Config) {
+	cfg.SpinTimeout = getEnvDuration("SPIN_TIMEOUT", 15*time.Second)
+	cfg.LogLevel = getEnv("LOG_LEVEL", "info")
+	cfg.ShutdownTimeout = getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+	cfg.ShutdownDrainDelay = getEnvDuration("SHUTDOWN_DRAIN_DELAY", 0)
+	cfg.ReadinessTimeout = getEnvDuration("READINESS_TIMEOUT", 2*time.Second)
+	cfg.LogMaxSizeMB = getEnvInt("LOG_MAX_SIZE_MB", 100)
+	cfg.LogMaxAgeDays = getEnvInt("LOG_MAX_AGE_DAYS", 30)
+	cfg.LogMaxBackups = getEnvInt("LOG_MAX_BACKUPS", 10)
+	cfg.LogCompress = getEnvBool("LOG_COMPRESS", true)
+	cfg.LogRotateDaily = getEnvBool("LOG_ROTATE_DAILY", true)
+	cfg.RNGTimeout = getEnvDuration("RNG_TIMEOUT", 5*time.Second)
+	cfg.SettingsTimeout = getEnvDuration("SETTINGS_TIMEOUT", 2*time.Second)
+	cfg.WalletTimeout = getEnvDuration("WALLET_TIMEOUT", 5*time.Second)
+	cfg.RNGMaxConcurrent = getEnvInt("RNG_MAX_CONCURRENT", 100)
+	cfg.SettingsMaxConcurrent = getEnvInt("SETTINGS_MAX_CONCURRENT", 100)
+	cfg.BreakerFailureThreshold = getEnvInt("BREAKER_FAILURE_THRESHOLD", 5)
+	cfg.BreakerOpenTimeout = getEnvDuration("BREAKER_OPEN_TIMEOUT", 30*time.Second)
+	cfg.BreakerHalfOpenProbes = getEnvInt("BREAKER_HALF_OPEN_PROBES", 1)
+	cfg.SettingsCacheTTL = getEnvDuration("SETTINGS_CACHE_TTL", time.Minute)
+	cfg.SettingsCacheStaleTTL = getEnvDuration("SETTINGS_CACHE_STALE_TTL", 5*time.Minute)
+	cfg.SettingsCacheMaxFallbackAge = getEnvDuration("SETTINGS_CACHE_MAX_FALLBACK_AGE", 30*time.Minute)
+	cfg.AdminToken = getEnv("ADMIN_TOKEN", "")
+	cfg.AdminTokens = parseTokens(getEnv("ADMIN_TOKENS", ""))
+	cfg.AdminPort = getEnv("ADMIN_PORT", "11401")
+	cfg.DefinitionsDir = getEnv("DEFINITIONS_DIR", "")
+	cfg.OperatorsFile = getEnv("OPERATORS_FILE", "")
+	cfg.AuthRequireSignature = getEnvBool("AUTH_REQUIRE_SIGNATURE", false)
+	cfg.AuthMaxClockSkew = getEnvDuration("AUTH_MAX_CLOCK_SKEW", 5*time.Minute)
+	cfg.ProxyHeader = getEnv("PROXY_HEADER", "")
+	cfg.TrustedProxies = splitList(getEnv("TRUSTED_PROXIES", ""), ",")
+	cfg.RateLimitIPRPS = getEnvFloat("RATE_LIMIT_IP_RPS", 20)
+	cfg.RateLimitIPBurst = getEnvInt("RATE_LIMIT_IP_BURST", 40)
+	cfg.RateLimitPlayerRPS = getEnvFloat("RATE_LIMIT_PLAYER_RPS", 2)
+	cfg.RateLimitPlayerBurst = getEnvInt("RATE_LIMIT_PLAYER_BURST", 5)
+	cfg.RateLimitOperatorRPS = getEnvFloat("RATE_LIMIT_OPERATOR_RPS", 500)
+	cfg.RateLimitOperatorBurst = getEnvInt("RATE_LIMIT_OPERATOR_BURST", 1000)
+	cfg.MaxConcurrentSpinsPerPlayer = getEnvInt("MAX_CONCURRENT_SPINS_PER_PLAYER", 1)
+	cfg.RGLossLimitDay = getEnvFloat("RG_LOSS_LIMIT_DAY", 0)
+	cfg.RGWagerLimitDay = getEnvFloat("RG_WAGER_LIMIT_DAY", 0)
+	cfg.RGMaxSessionMinutes = getEnvInt("RG_MAX_SESSION_MINUTES", 0)
+	cfg.RGRealityCheckMinutes = getEnvInt("RG_REALITY_CHECK_MINUTES", 60)
+	cfg.RGSessionIdleTimeout = getEnvDuration("RG_SESSION_IDLE_TIMEOUT", 30*time.Minute)
+	cfg.ComplianceFile = getEnv("COMPLIANCE_FILE", "")
+	cfg.LossPolicy = getEnv("LOSS_POLICY", "")
+	cfg.NearMissMaxRate = getEnvFloat("NEAR_MISS_MAX_RATE", 0)
+	cfg.NearMissCapAtChance = getEnvBool("NEAR_MISS_CAP_AT_CHANCE", false)
+	cfg.StoreDriver = getEnv("STORE_DRIVER", "bolt")
+	cfg.StorePath = getEnv("STORE_PATH", getEnv("ROUNDS_DB", "rounds.db"))
+	cfg.IdempotencyTTL = getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	cfg.MinRoundDuration = getEnvDuration("MIN_ROUND_DURATION", 0)
+	cfg.ReconcileOnStart = getEnvBool("RECONCILE_ON_START", true)
+	cfg.ReconcileInterval = getEnvDuration("RECONCILE_INTERVAL", time.Minute)
+	cfg.ReconcileReportDir = getEnv("RECONCILE_REPORT_DIR", "reconciliation")
+	cfg.SessionSigningKeys = splitList(getEnv("SESSION_SIGNING_KEYS", ""), ",")
+	cfg.SessionTTL = getEnvDuration("SESSION_TTL", 12*time.Hour)
+	cfg.TraceExporter = getEnv("TRACE_EXPORTER", "none")
+	cfg.TraceFile = getEnv("TRACE_FILE", "traces.jsonl")
+	cfg.TraceSampleRatio = getEnvFloat("TRACE_SAMPLE_RATIO", 1)
+}
+
+// Validate reports settings that cannot work together
+func (c Config) Validate() error {
+	// A round is held within the spin's deadline, so a minimum that does not
+	// fit in it would silently not be enforced
+	if c.MinRoundDuration > 0 && c.MinRoundDuration >= c.SpinTimeout {
+		return fmt.Errorf("MIN_ROUND_DURATION (%s) must be shorter than SPIN_TIMEOUT (%s)", c.MinRoundDuration, c.SpinTimeout)
+	}
+	return nil
+}
+
+// LoadAll loads the server configuration with every named environment
+// listed in ENVIRONMENTS and the rules routing requests to them
+func LoadAll() Config {
 	// Try to load .env file, but don't fail if it doesn't exist
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found or error loading it")
+		slog.Info("No .env file found or error loading it")
 	}
 
-	prod = Config{
-		RNGServiceURL:      getEnv("PROD_RNG_API_URL", "http://159.89.235.166:17003/api/proxy/rng/1"),
-		SettingsServiceURL: getEnv("PROD_SETTINGS_API_URL", "https://t3.ibibe.africa/get-game-settings"),
-		ServerPort:         getEnv("PORT", "11400"),
-		LogFile:            getEnv("LOG_FILE", "app.log"),
-	}
-	test = Config{
-		RNGServiceURL:      getEnv("TEST_RNG_API_URL", "http://test-rng-url"),
-		SettingsServiceURL: getEnv("TEST_SETTINGS_API_URL", "https://test-settings-url"),
-		ServerPort:         getEnv("PORT", "11400"),
-		LogFile:            getEnv("LOG_FILE", "app.log"),
+	cfg := Config{
+		Environments: loadEnvironments(),
+		Routing:      loadRouting(),
+		ServerPort:   getEnv("PORT", "11400"),
+		LogFile:      getEnv("LOG_FILE", "app.log"),
 	}
-	return
+	loadShared(&cfg)
+	return cfg
 }
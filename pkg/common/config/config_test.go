@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name                 string
+		spinTimeout, minimum time.Duration
+		wantErr              bool
+	}{
+		{"no minimum", 15 * time.Second, 0, false},
+		{"minimum within the deadline", 15 * time.Second, 3 * time.Second, false},
+		{"minimum equal to the deadline", 3 * time.Second, 3 * time.Second, true},
+		{"minimum past the deadline", 3 * time.Second, 5 * time.Second, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Config{SpinTimeout: tt.spinTimeout, MinRoundDuration: tt.minimum}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Environment holds the service endpoints of one named environment
+type Environment struct {
+	Name               string
+	RNGServiceURL      string
+	SettingsServiceURL string
+	WalletServiceURL   string // optional; credits and rollbacks are not sent without it
+}
+
+// Routing decides which environment serves a request. Each map goes from a
+// request attribute to an environment name; unmatched requests use Default.
+type Routing struct {
+	Default   string
+	Operators map[string]string // authenticated operator ID
+	APIKeys   map[string]string // value of the X-API-Key header
+	Origins   map[string]string // Origin header, matched exactly
+}
+
+// defaultEnvironmentURLs keeps the historical defaults for prod and test
+var defaultEnvironmentURLs = map[string][2]string{
+	"prod": {"http://159.89.235.166:17003/api/proxy/rng/1", "https://t3.ibibe.africa/get-game-settings"},
+	"test": {"http://test-rng-url", "https://test-settings-url"},
+}
+
+// loadEnvironments reads ENVIRONMENTS (default "prod,test") and, for each
+// name, <NAME>_RNG_API_URL, <NAME>_SETTINGS_API_URL and <NAME>_WALLET_API_URL
+func loadEnvironments() []Environment {
+	var envs []Environment
+	for _, name := range splitList(getEnv("ENVIRONMENTS", "prod,test"), ",") {
+		prefix := strings.ToUpper(name) + "_"
+		defaults := defaultEnvironmentURLs[name]
+		env := Environment{
+			Name:               name,
+			RNGServiceURL:      getEnv(prefix+"RNG_API_URL", defaults[0]),
+			SettingsServiceURL: getEnv(prefix+"SETTINGS_API_URL", defaults[1]),
+			WalletServiceURL:   getEnv(prefix+"WALLET_API_URL", ""),
+		}
+		if env.RNGServiceURL == "" || env.SettingsServiceURL == "" {
+			slog.Warn("Skipping environment without service URLs", slog.String("environment", name))
+			continue
+		}
+		envs = append(envs, env)
+	}
+	return envs
+}
+
+// loadRouting reads the environment routing rules. Rules use the form
+// "env:value1,value2;env2:value3", for example ENV_ROUTE_OPERATORS="test:1001,1002".
+func loadRouting() Routing {
+	return Routing{
+		Default:   getEnv("ENV_DEFAULT", "prod"),
+		Operators: parseRoutes(getEnv("ENV_ROUTE_OPERATORS", "")),
+		APIKeys:   parseRoutes(getEnv("ENV_ROUTE_API_KEYS", "")),
+		Origins:   parseRoutes(getEnv("ENV_ROUTE_ORIGINS", "")),
+	}
+}
+
+// parseRoutes turns "env:a,b;env2:c" into {"a": "env", "b": "env", "c": "env2"}
+func parseRoutes(raw string) map[string]string {
+	routes := make(map[string]string)
+	for _, rule := range splitList(raw, ";") {
+		env, values, ok := strings.Cut(rule, ":")
+		env = strings.TrimSpace(env)
+		if !ok || env == "" {
+			slog.Warn("Ignoring malformed routing rule", slog.String("rule", rule))
+			continue
+		}
+		for _, value := range splitList(values, ",") {
+			routes[value] = env
+		}
+	}
+	return routes
+}
+
+// splitList splits on sep, trimming blanks and dropping empty entries
+func splitList(raw, sep string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
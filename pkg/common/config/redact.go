@@ -0,0 +1,84 @@
+package config
+
+import (
+	"log/slog"
+	"maps"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces a secret in the configuration shown by the admin API
+const redacted = "[redacted]"
+
+// AdminEnabled reports whether any admin token is configured
+func (c Config) AdminEnabled() bool {
+	return c.AdminToken != "" || len(c.AdminTokens) > 0
+}
+
+// Redacted returns a copy of the configuration that is safe to show: tokens
+// and signing keys are replaced, API keys keep their last four characters and
+// service URLs lose their passwords
+func (c Config) Redacted() Config {
+	if c.AdminToken != "" {
+		c.AdminToken = redacted
+	}
+	tokens := make(map[string]string, len(c.AdminTokens))
+	for actor := range c.AdminTokens {
+		tokens[actor] = redacted
+	}
+	c.AdminTokens = tokens
+	keys := make([]string, len(c.SessionSigningKeys))
+	for i := range keys {
+		keys[i] = redacted
+	}
+	c.SessionSigningKeys = keys
+
+	c.Environments = append([]Environment(nil), c.Environments...)
+	for i, env := range c.Environments {
+		env.RNGServiceURL = redactURL(env.RNGServiceURL)
+		env.SettingsServiceURL = redactURL(env.SettingsServiceURL)
+		env.WalletServiceURL = redactURL(env.WalletServiceURL)
+		c.Environments[i] = env
+	}
+
+	c.Routing.Operators = maps.Clone(c.Routing.Operators)
+	c.Routing.Origins = maps.Clone(c.Routing.Origins)
+	apiKeys := make(map[string]string, len(c.Routing.APIKeys))
+	for key, env := range c.Routing.APIKeys {
+		apiKeys[maskKey(key)] = env
+	}
+	c.Routing.APIKeys = apiKeys
+	return c
+}
+
+// redactURL hides the password of a URL carrying credentials
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	return u.Redacted()
+}
+
+// maskKey keeps the last four characters of a key so it can be recognised
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", len(key)-4) + key[len(key)-4:]
+}
+
+// parseTokens turns "alice:token1,bob:token2" into {"alice": "token1", "bob": "token2"}
+func parseTokens(raw string) map[string]string {
+	tokens := make(map[string]string)
+	for _, pair := range splitList(raw, ",") {
+		actor, token, ok := strings.Cut(pair, ":")
+		actor, token = strings.TrimSpace(actor), strings.TrimSpace(token)
+		if !ok || actor == "" || token == "" {
+			slog.Warn("Ignoring malformed admin token", slog.String("actor", actor))
+			continue
+		}
+		tokens[actor] = token
+	}
+	return tokens
+}
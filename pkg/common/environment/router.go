@@ -0,0 +1,141 @@
+package environment
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/config"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/rng"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/settings"
+)
+
+// APIKeyHeader is the request header matched against the API key routes
+const APIKeyHeader = "X-API-Key"
+
+// Clients are the backends of one named environment
+type Clients struct {
+	Name     string
+	RNG      *rng.Client
+	Settings settings.Provider
+}
+
+// Request holds the attributes a request can be routed on
+type Request struct {
+	OperatorID string
+	APIKey     string
+	Origin     string
+}
+
+// Router assigns requests to environments using explicit rules only
+type Router struct {
+	envs     map[string]*Clients
+	routing  config.Routing
+	fallback *Clients
+
+	// Operator routes set while the server runs, tried before the configured ones
+	mu        sync.RWMutex
+	overrides map[string]string
+}
+
+// NewRouter creates a router over the given environments. Every environment
+// named by a routing rule, including the default, must exist.
+func NewRouter(routing config.Routing, envs ...*Clients) (*Router, error) {
+	r := &Router{envs: make(map[string]*Clients, len(envs)), routing: routing, overrides: make(map[string]string)}
+	for _, env := range envs {
+		if _, dup := r.envs[env.Name]; dup {
+			return nil, fmt.Errorf("environment %q defined twice", env.Name)
+		}
+		r.envs[env.Name] = env
+	}
+
+	fallback, ok := r.envs[routing.Default]
+	if !ok {
+		return nil, fmt.Errorf("default environment %q is not defined", routing.Default)
+	}
+	r.fallback = fallback
+
+	for kind, routes := range map[string]map[string]string{
+		"operator": routing.Operators,
+		"api key":  routing.APIKeys,
+		"origin":   routing.Origins,
+	} {
+		for _, name := range routes {
+			if _, ok := r.envs[name]; !ok {
+				return nil, fmt.Errorf("%s route refers to undefined environment %q", kind, name)
+			}
+		}
+	}
+	return r, nil
+}
+
+// Resolve picks the environment for a request. Rules are tried in order:
+// operator ID, API key, then exact Origin; anything else goes to the default.
+func (r *Router) Resolve(req Request) *Clients {
+	if name, ok := r.OperatorRoute(req.OperatorID); ok {
+		return r.envs[name]
+	}
+	if name, ok := r.routing.APIKeys[req.APIKey]; ok && req.APIKey != "" {
+		return r.envs[name]
+	}
+	if name, ok := r.routing.Origins[req.Origin]; ok && req.Origin != "" {
+		return r.envs[name]
+	}
+	return r.fallback
+}
+
+// SetOperatorRoute routes an operator to the named environment, ahead of the
+// configured operator routes
+func (r *Router) SetOperatorRoute(operatorID, name string) error {
+	if _, ok := r.envs[name]; !ok {
+		return fmt.Errorf("environment %q is not defined", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides[operatorID] = name
+	return nil
+}
+
+// ClearOperatorRoute drops an operator route set by SetOperatorRoute
+func (r *Router) ClearOperatorRoute(operatorID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.overrides, operatorID)
+}
+
+// OperatorRoute returns the environment an operator is routed to by its
+// operator ID alone, set at runtime or configured
+func (r *Router) OperatorRoute(operatorID string) (string, bool) {
+	if operatorID == "" {
+		return "", false
+	}
+	r.mu.RLock()
+	name, ok := r.overrides[operatorID]
+	r.mu.RUnlock()
+	if ok {
+		return name, true
+	}
+	name, ok = r.routing.Operators[operatorID]
+	return name, ok
+}
+
+// Get returns the environment with the given name
+func (r *Router) Get(name string) (*Clients, bool) {
+	env, ok := r.envs[name]
+	return env, ok
+}
+
+// Default returns the environment used when no rule matches
+func (r *Router) Default() *Clients {
+	return r.fallback
+}
+
+// Names lists the configured environments in alphabetical order
+func (r *Router) Names() []string {
+	names := make([]string, 0, len(r.envs))
+	for name := range r.envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrDraining is reported by readiness once shutdown has begun
+var ErrDraining = errors.New("server is shutting down")
+
+// Check reports whether one dependency or component is usable
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name     string
+	check    Check
+	critical bool
+}
+
+// Checker serves the liveness and readiness probes
+type Checker struct {
+	timeout  time.Duration
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// CheckResult is the outcome of one readiness check
+type CheckResult struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewChecker creates a checker whose readiness checks each get the given timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a readiness check. Only failing critical checks make the
+// server unready; the others are reported for information.
+func (h *Checker) Add(name string, critical bool, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check, critical: critical})
+}
+
+// SetDraining makes readiness fail so load balancers stop sending traffic
+func (h *Checker) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Ready runs every check concurrently and reports whether the server can take traffic
+func (h *Checker) Ready(ctx context.Context) (bool, map[string]CheckResult) {
+	results := make(map[string]CheckResult, len(h.checks))
+	if h.draining.Load() {
+		results["shutdown"] = CheckResult{Status: "fail", Critical: true, Error: ErrDraining.Error()}
+		return false, results
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		ready = true
+	)
+	for _, nc := range h.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			result := CheckResult{Status: "ok", Critical: nc.critical}
+			if err := nc.check(checkCtx); err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[nc.name] = result
+			if result.Status != "ok" && nc.critical {
+				ready = false
+			}
+		}(nc)
+	}
+	wg.Wait()
+	return ready, results
+}
+
+// LivenessHandler answers as long as the process can serve requests at all
+func (h *Checker) LivenessHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// ReadinessHandler answers 200 when every critical check passes and 503 otherwise
+func (h *Checker) ReadinessHandler(c *fiber.Ctx) error {
+	ready, results := h.Ready(c.UserContext())
+	status := "ok"
+	code := fiber.StatusOK
+	if !ready {
+		status = "unavailable"
+		code = fiber.StatusServiceUnavailable
+	}
+	return c.Status(code).JSON(fiber.Map{
+		"status": status,
+		"checks": results,
+	})
+}
+
+// DialCheck reports whether a TCP connection can be opened to the service behind rawURL
+func DialCheck(rawURL string) Check {
+	return func(ctx context.Context) error {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+		host := u.Host
+		if u.Port() == "" {
+			port := "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+			host = net.JoinHostPort(u.Hostname(), port)
+		}
+
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
@@ -0,0 +1,127 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func pass(context.Context) error { return nil }
+
+func failWith(msg string) Check {
+	return func(context.Context) error { return errors.New(msg) }
+}
+
+func TestReadyAggregation(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		critical  Check
+		optional  Check
+		wantReady bool
+	}{
+		{"all pass", pass, pass, true},
+		{"optional failure", pass, failWith("wallet down"), true},
+		{"critical failure", failWith("rng down"), pass, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewChecker(time.Second)
+			h.Add("rng", true, tt.critical)
+			h.Add("wallet", false, tt.optional)
+
+			ready, results := h.Ready(context.Background())
+			if ready != tt.wantReady {
+				t.Errorf("Ready() = %v, want %v", ready, tt.wantReady)
+			}
+			if len(results) != 2 || !results["rng"].Critical || results["wallet"].Critical {
+				t.Errorf("results = %+v", results)
+			}
+			for name, check := range map[string]Check{"rng": tt.critical, "wallet": tt.optional} {
+				wantOK := check(context.Background()) == nil
+				if (results[name].Status == "ok") != wantOK || (results[name].Error == "") != wantOK {
+					t.Errorf("%s result = %+v", name, results[name])
+				}
+			}
+		})
+	}
+}
+
+func TestReadyCheckTimeout(t *testing.T) {
+	h := NewChecker(20 * time.Millisecond)
+	h.Add("slow", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	ready, results := h.Ready(context.Background())
+	if ready || results["slow"].Status != "fail" {
+		t.Fatalf("Ready() = %v, %+v; want a failed slow check", ready, results)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Ready() took %s, want the check cut off at its timeout", elapsed)
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	h := NewChecker(time.Second)
+	h.Add("rng", true, pass)
+
+	app := fiber.New()
+	app.Get("/healthz", h.LivenessHandler)
+	app.Get("/readyz", h.ReadinessHandler)
+
+	get := func(path string) (int, map[string]any) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, body
+	}
+
+	if code, body := get("/readyz"); code != http.StatusOK || body["status"] != "ok" {
+		t.Errorf("ready: %d %v", code, body)
+	}
+
+	h.SetDraining()
+	code, body := get("/readyz")
+	if code != http.StatusServiceUnavailable || body["status"] != "unavailable" {
+		t.Errorf("draining: %d %v", code, body)
+	}
+	if checks, _ := body["checks"].(map[string]any); checks["shutdown"] == nil {
+		t.Errorf("draining checks = %v, want a shutdown entry", body["checks"])
+	}
+
+	// Liveness stays up while draining
+	if code, _ := get("/healthz"); code != http.StatusOK {
+		t.Errorf("liveness while draining = %d, want 200", code)
+	}
+}
+
+func TestDialCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	ctx := context.Background()
+	if err := DialCheck("http://" + addr + "/rng")(ctx); err != nil {
+		t.Errorf("DialCheck(open port) = %v", err)
+	}
+	ln.Close()
+	if err := DialCheck("http://" + addr + "/rng")(ctx); err == nil {
+		t.Error("DialCheck(closed port) succeeded")
+	}
+}
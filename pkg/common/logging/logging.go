@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Field names shared by every log line so lines about one spin can be joined
+const (
+	KeyRequestID   = "request_id"
+	KeyTraceID     = "trace_id"
+	KeyBetID       = "bet_id"
+	KeyPlayerID    = "player_id"
+	KeyClientID    = "client_id"
+	KeyGameID      = "game_id"
+	KeyEnvironment = "environment"
+	KeyOperatorID  = "operator_id"
+)
+
+// redactedKeys are never written in clear text
+var redactedKeys = map[string]bool{
+	"authorization": true,
+	"api_key":       true,
+	"token":         true,
+	"session_token": true,
+	"signature":     true,
+	"secret":        true,
+	"password":      true,
+	"admin_token":   true,
+}
+
+// New creates a levelled JSON logger. Request IDs, trace IDs and fields added
+// with With are taken from the context of each *Context logging call.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})
+	return slog.New(contextHandler{handler})
+}
+
+// ParseLevel converts "debug", "info", "warn" or "error" into a level, defaulting to info
+func ParseLevel(s string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// redact hides secrets and masks the host part of IP addresses
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	if redactedKeys[key] {
+		return slog.String(a.Key, "[REDACTED]")
+	}
+	if key == "ip" || key == "ip_address" {
+		return slog.String(a.Key, maskIP(a.Value.String()))
+	}
+	return a
+}
+
+// maskIP keeps the network part of an address: 203.0.113.7 becomes 203.0.113.0
+func maskIP(raw string) string {
+	ip := net.ParseIP(raw)
+	if ip == nil {
+		return "[REDACTED]"
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+type ctxKey struct{}
+
+// With returns a context whose log lines carry the given attributes
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, ctxKey{}, merged)
+}
+
+// RequestID returns the correlation ID stored in ctx, if any
+func RequestID(ctx context.Context) string {
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	for _, a := range attrs {
+		if a.Key == KeyRequestID {
+			return a.Value.String()
+		}
+	}
+	return ""
+}
+
+// contextHandler adds context attributes and the active trace ID to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		record.AddAttrs(slog.String(KeyTraceID, span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+// decode parses the single JSON log line in buf
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log line %q: %v", buf.String(), err)
+	}
+	return line
+}
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+	logger.Info("test",
+		slog.String("api_key", "key-1"),
+		slog.String("Authorization", "Bearer abc"),
+		slog.String("session_token", "tok"),
+		slog.String("ip", "203.0.113.7"),
+		slog.String("ip_address", "2001:db8:1:2::7"),
+		slog.String(KeyPlayerID, "player-1"),
+	)
+
+	line := decode(t, &buf)
+	for key, want := range map[string]string{
+		"api_key":       "[REDACTED]",
+		"Authorization": "[REDACTED]",
+		"session_token": "[REDACTED]",
+		"ip":            "203.0.113.0",
+		"ip_address":    "2001:db8:1::",
+		KeyPlayerID:     "player-1",
+	} {
+		if line[key] != want {
+			t.Errorf("%s = %v, want %q", key, line[key], want)
+		}
+	}
+}
+
+func TestMaskIP(t *testing.T) {
+	for raw, want := range map[string]string{
+		"203.0.113.7":      "203.0.113.0",
+		"::ffff:10.1.2.3":  "10.1.2.0",
+		"2001:db8:a:b::1":  "2001:db8:a::",
+		"not-an-ip":        "[REDACTED]",
+		"203.0.113.7:8080": "[REDACTED]",
+	} {
+		if got := maskIP(raw); got != want {
+			t.Errorf("maskIP(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelDebug)
+
+	ctx := With(context.Background(), slog.String(KeyRequestID, "req-1"))
+	ctx = With(ctx, slog.String(KeyBetID, "bet-1"))
+	logger.InfoContext(ctx, "test")
+
+	line := decode(t, &buf)
+	if line[KeyRequestID] != "req-1" || line[KeyBetID] != "bet-1" {
+		t.Errorf("log line %v is missing the context attributes", line)
+	}
+	if id := RequestID(ctx); id != "req-1" {
+		t.Errorf("RequestID() = %q, want req-1", id)
+	}
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("RequestID() without one = %q, want empty", id)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for raw, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"WARN":  slog.LevelWarn,
+		"error": slog.LevelError,
+		"":      slog.LevelInfo,
+		"loud":  slog.LevelInfo,
+	} {
+		if got := ParseLevel(raw); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
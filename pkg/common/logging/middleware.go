@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the correlation ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits caller-supplied IDs to something safe to log and echo
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Middleware assigns each request a correlation ID, taken from X-Request-ID
+// when the caller sends a usable one, echoes it in the response and writes
+// one access log line per request
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := c.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Set(RequestIDHeader, requestID)
+		c.SetUserContext(With(c.UserContext(), slog.String(KeyRequestID, requestID)))
+
+		err := c.Next()
+		if err != nil {
+			// Let the error handler write the response so the logged status is final
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Default().Log(c.UserContext(), level, "Request completed",
+			slog.Int("status", status),
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		)
+		return nil
+	}
+}
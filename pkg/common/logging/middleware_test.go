@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMiddlewareRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(New(&buf, slog.LevelInfo))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var seen string
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/", func(c *fiber.Ctx) error {
+		seen = RequestID(c.UserContext())
+		return nil
+	})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusBadGateway, "upstream down")
+	})
+
+	for _, tt := range []struct {
+		name, path, sent string
+		keep             bool
+		status           int
+	}{
+		{"caller id kept", "/", "abc-123", true, http.StatusOK},
+		{"invalid id replaced", "/", "bad id\nwith newline", false, http.StatusOK},
+		{"missing id generated", "/", "", false, http.StatusOK},
+		{"error status logged", "/fail", "err-1", true, http.StatusBadGateway},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			seen = ""
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.sent != "" {
+				req.Header.Set(RequestIDHeader, tt.sent)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+
+			echoed := resp.Header.Get(RequestIDHeader)
+			if tt.keep && echoed != tt.sent {
+				t.Errorf("echoed ID = %q, want the caller's %q", echoed, tt.sent)
+			}
+			if !tt.keep && (echoed == "" || echoed == tt.sent) {
+				t.Errorf("echoed ID = %q, want a generated one", echoed)
+			}
+			if tt.path == "/" && seen != echoed {
+				t.Errorf("handler saw ID %q, response carries %q", seen, echoed)
+			}
+
+			line := decode(t, &buf)
+			if line[KeyRequestID] != echoed {
+				t.Errorf("access log request_id = %v, want %q", line[KeyRequestID], echoed)
+			}
+			if int(line["status"].(float64)) != tt.status {
+				t.Errorf("access log status = %v, want %d", line["status"], tt.status)
+			}
+			if line["ip"] != "0.0.0.0" {
+				t.Errorf("access log ip = %v, want the masked 0.0.0.0", line["ip"])
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// RotationConfig controls how the log file is rotated and retained
+type RotationConfig struct {
+	Path       string
+	MaxSizeMB  int  // rotate once the file reaches this size
+	MaxAgeDays int  // delete rotated files older than this, 0 keeps them
+	MaxBackups int  // keep at most this many rotated files, 0 keeps them all
+	Compress   bool // gzip rotated files
+	Daily      bool // also rotate at local midnight
+}
+
+// NewRotatingFile returns a log writer that rotates by size, and daily when
+// configured, compresses old segments and prunes them by age and count
+func NewRotatingFile(cfg RotationConfig) *lumberjack.Logger {
+	file := &lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     cfg.MaxAgeDays,
+		MaxBackups: cfg.MaxBackups,
+		Compress:   cfg.Compress,
+		LocalTime:  true,
+	}
+	if cfg.Daily {
+		rotateDaily(file)
+	}
+	return file
+}
+
+// rotateDaily starts a segment at every local midnight, so a quiet server
+// that never reaches MaxSizeMB still gets one file per day that MaxAgeDays
+// can prune
+func rotateDaily(file *lumberjack.Logger) {
+	go func() {
+		for {
+			time.Sleep(untilMidnight(time.Now()))
+			if err := file.Rotate(); err != nil {
+				slog.Error("Error rotating log file at midnight", slog.Any("error", err))
+			}
+		}
+	}()
+}
+
+// untilMidnight returns the time left until the next local midnight
+func untilMidnight(now time.Time) time.Duration {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Sub(now)
+}
+
+// ReopenOnSIGHUP closes the log file whenever the process receives SIGHUP.
+// The next write reopens the configured path, so a file moved away by an
+// external tool such as logrotate is replaced instead of written to forever.
+func ReopenOnSIGHUP(file *lumberjack.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := file.Close(); err != nil {
+				slog.Error("Error closing log file on SIGHUP", slog.Any("error", err))
+				continue
+			}
+			slog.Info("Log file reopened on SIGHUP", slog.String("path", file.Filename))
+		}
+	}()
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUntilMidnight(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	for _, tt := range []struct {
+		now  time.Time
+		want time.Duration
+	}{
+		{time.Date(2026, 10, 19, 23, 59, 0, 0, loc), time.Minute},
+		{time.Date(2026, 10, 19, 0, 0, 0, 0, loc), 24 * time.Hour},
+		{time.Date(2026, 12, 31, 12, 0, 0, 0, loc), 12 * time.Hour},
+	} {
+		if got := untilMidnight(tt.now); got != tt.want {
+			t.Errorf("untilMidnight(%s) = %s, want %s", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	file := NewRotatingFile(RotationConfig{Path: filepath.Join(dir, "server.log"), MaxSizeMB: 1, MaxBackups: 1})
+	defer file.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := file.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Pruning runs in the background after each rotation
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) == 2 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d files in the log directory, want the live file and 1 backup", len(entries))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/settings"
+)
+
+// Labels are limited to game, bet level, paytable combination, dependency,
+// environment and a fixed set of reasons so cardinality stays bounded.
+// Player, client and bet identifiers must never become labels.
+
+var (
+	spins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slot_spins_total",
+		Help: "Completed spins by game, bet level and outcome.",
+	}, []string{"game", "level", "outcome"})
+
+	betAmount = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "slot_bet_amount",
+		Help:    "Bet amount per spin by game and bet level.",
+		Buckets: []float64{0.01, 0.02, 0.05, 0.1, 0.2, 0.25, 0.5, 0.75, 1, 5, 10},
+	}, []string{"game", "level"})
+
+	wagered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slot_wagered_total",
+		Help: "Total amount wagered by game and bet level.",
+	}, []string{"game", "level"})
+
+	paid = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slot_paid_total",
+		Help: "Total amount paid out by game and bet level.",
+	}, []string{"game", "level"})
+
+	observedRTP = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slot_observed_rtp",
+		Help: "Paid divided by wagered since process start, by game.",
+	}, []string{"game"})
+
+	combinationHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slot_combination_hits_total",
+		Help: "Winning spins by game and paytable combination.",
+	}, []string{"game", "combination"})
+
+	rejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slot_validation_rejections_total",
+		Help: "Spin requests rejected by validation, by game and reason.",
+	}, []string{"game", "reason"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slot_rate_limited_total",
+		Help: "Spin requests refused with 429, by game and limit scope.",
+	}, []string{"game", "scope"})
+
+	authFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "operator_auth_failures_total",
+		Help: "Requests rejected by operator authentication, by reason.",
+	}, []string{"reason"})
+
+	reconciled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "slot_rounds_reconciled_total",
+		Help: "Rounds left open by a previous process, by game and reconciliation action.",
+	}, []string{"game", "action"})
+
+	dependencyDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dependency_request_duration_seconds",
+		Help:    "Latency of RNG, settings and wallet calls by dependency, environment and result.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"dependency", "environment", "result"})
+
+	dependencyErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dependency_errors_total",
+		Help: "Failed RNG, settings and wallet calls by dependency, environment and result.",
+	}, []string{"dependency", "environment", "result"})
+)
+
+// rtpTotals keeps the running sums behind slot_observed_rtp
+var rtpTotals = struct {
+	sync.Mutex
+	wagered map[string]float64
+	paid    map[string]float64
+}{
+	wagered: make(map[string]float64),
+	paid:    make(map[string]float64),
+}
+
+// RecordSpin records a completed spin
+func RecordSpin(game string, level int, amount, win float64, combination string) {
+	levelLabel := strconv.Itoa(level)
+	outcome := "loss"
+	if win > 0 {
+		outcome = "win"
+		combinationHits.WithLabelValues(game, combination).Inc()
+	}
+
+	spins.WithLabelValues(game, levelLabel, outcome).Inc()
+	betAmount.WithLabelValues(game, levelLabel).Observe(amount)
+	wagered.WithLabelValues(game, levelLabel).Add(amount)
+	paid.WithLabelValues(game, levelLabel).Add(win)
+
+	rtpTotals.Lock()
+	rtpTotals.wagered[game] += amount
+	rtpTotals.paid[game] += win
+	if rtpTotals.wagered[game] > 0 {
+		observedRTP.WithLabelValues(game).Set(rtpTotals.paid[game] / rtpTotals.wagered[game])
+	}
+	rtpTotals.Unlock()
+}
+
+// RecordRejection records a spin request rejected by validation
+func RecordRejection(game, reason string) {
+	rejections.WithLabelValues(game, reason).Inc()
+}
+
+// RecordRateLimited records a spin refused by a rate or concurrency limit
+func RecordRateLimited(game, scope string) {
+	rateLimited.WithLabelValues(game, scope).Inc()
+}
+
+// RecordReconciled records a round completed, voided or left open by reconciliation
+func RecordReconciled(game, action string) {
+	reconciled.WithLabelValues(game, action).Inc()
+}
+
+// RecordAuthFailure records a request rejected by operator authentication
+func RecordAuthFailure(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
+
+// DependencyObserver returns a resilience observer recording latency and
+// errors for one dependency in one environment
+func DependencyObserver(dependency, environment string) func(time.Duration, error) {
+	return func(elapsed time.Duration, err error) {
+		result := dependencyResult(err)
+		dependencyDuration.WithLabelValues(dependency, environment, result).Observe(elapsed.Seconds())
+		if err != nil {
+			dependencyErrors.WithLabelValues(dependency, environment, result).Inc()
+		}
+	}
+}
+
+// dependencyResult maps a call error onto a fixed set of label values
+func dependencyResult(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, resilience.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, resilience.ErrConcurrencyLimit):
+		return "concurrency_limit"
+	case resilience.IsRejected(err):
+		return "rejected"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	}
+	return "error"
+}
+
+// RegisterBreaker exposes a dependency's circuit breaker state
+// (0 closed, 1 open, 2 half-open)
+func RegisterBreaker(dependency, environment string, policy *resilience.Policy) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "dependency_circuit_state",
+		Help:        "Circuit breaker state: 0 closed, 1 open, 2 half-open.",
+		ConstLabels: prometheus.Labels{"dependency": dependency, "environment": environment},
+	}, func() float64 {
+		return float64(policy.State())
+	})
+}
+
+// RegisterSettingsCache exposes a settings cache's hit, stale-hit, miss and fallback counters
+func RegisterSettingsCache(environment string, cache *settings.Cache) {
+	labels := prometheus.Labels{"environment": environment}
+	counters := map[string]func(settings.CacheStats) uint64{
+		"settings_cache_hits_total":       func(s settings.CacheStats) uint64 { return s.Hits },
+		"settings_cache_stale_hits_total": func(s settings.CacheStats) uint64 { return s.StaleHits },
+		"settings_cache_misses_total":     func(s settings.CacheStats) uint64 { return s.Misses },
+		"settings_cache_fallbacks_total":  func(s settings.CacheStats) uint64 { return s.Fallbacks },
+	}
+	for name, pick := range counters {
+		promauto.NewCounterFunc(prometheus.CounterOpts{
+			Name:        name,
+			Help:        "Settings cache lookups by result.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(pick(cache.Stats()))
+		})
+	}
+}
+
+// Handler serves the Prometheus exposition format
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+)
+
+func TestDependencyResult(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want string
+	}{
+		{nil, "success"},
+		{fmt.Errorf("rng: %w", resilience.ErrCircuitOpen), "circuit_open"},
+		{fmt.Errorf("rng: %w", resilience.ErrConcurrencyLimit), "concurrency_limit"},
+		{resilience.Rejected(errors.New("status 400")), "rejected"},
+		{context.DeadlineExceeded, "timeout"},
+		{context.Canceled, "cancelled"},
+		{errors.New("status 502"), "error"},
+	} {
+		if got := dependencyResult(tt.err); got != tt.want {
+			t.Errorf("dependencyResult(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRecordSpin(t *testing.T) {
+	const game = "metrics-test"
+	RecordSpin(game, 1, 2, 0, "")
+	RecordSpin(game, 1, 2, 3, "AAA")
+
+	if got := testutil.ToFloat64(spins.WithLabelValues(game, "1", "win")); got != 1 {
+		t.Errorf("wins = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(spins.WithLabelValues(game, "1", "loss")); got != 1 {
+		t.Errorf("losses = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(combinationHits.WithLabelValues(game, "AAA")); got != 1 {
+		t.Errorf("combination hits = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(observedRTP.WithLabelValues(game)); got != 0.75 {
+		t.Errorf("observed RTP = %v, want 0.75", got)
+	}
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a set of token buckets, one per key, each refilling at Rate
+// tokens per second up to Burst
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a limiter allowing rate requests per second per key with
+// bursts of up to burst requests. It returns nil, which allows everything,
+// when rate is not positive.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow takes a token for key. When none is left it reports how long until one is.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets that have refilled completely, which behave exactly
+// like new ones. Callers hold l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Concurrency caps how many requests per key may be in progress at once
+type Concurrency struct {
+	max int
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// NewConcurrency creates a per-key concurrency cap. It returns nil, which
+// allows everything, when max is not positive.
+func NewConcurrency(max int) *Concurrency {
+	if max <= 0 {
+		return nil
+	}
+	return &Concurrency{max: max, inFlight: make(map[string]int)}
+}
+
+// Acquire reserves a slot for key. The returned release must be called once
+// the request finishes; it is a no-op when the slot was refused.
+func (c *Concurrency) Acquire(key string) (release func(), ok bool) {
+	if c == nil {
+		return func() {}, true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inFlight[key] >= c.max {
+		return func() {}, false
+	}
+	c.inFlight[key]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.inFlight[key]--; c.inFlight[key] <= 0 {
+				delete(c.inFlight, key)
+			}
+		})
+	}, true
+}
+
+// SpinLimits groups the limits applied to spin requests. Nil members allow everything.
+type SpinLimits struct {
+	IP          *Limiter
+	Player      *Limiter
+	Operator    *Limiter
+	PlayerSpins *Concurrency // rounds a player may have in progress at once
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(rate float64, burst int) (*Limiter, *time.Time) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(rate, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiterBurstAndRefill(t *testing.T) {
+	l, now := newTestLimiter(2, 3)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d of the burst refused", i+1)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("after the burst Allow() = %v, %s; want false, 500ms", ok, wait)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Fatal("another key shares the exhausted bucket")
+	}
+
+	*now = now.Add(250 * time.Millisecond)
+	if ok, wait := l.Allow("a"); ok || wait != 250*time.Millisecond {
+		t.Fatalf("half a token later Allow() = %v, %s; want false, 250ms", ok, wait)
+	}
+	*now = now.Add(250 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("refilled token refused")
+	}
+
+	// Refill stops at the burst size
+	*now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d after a long pause refused", i+1)
+		}
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("bucket refilled beyond its burst")
+	}
+}
+
+func TestLimiterEvictsFullBuckets(t *testing.T) {
+	l, now := newTestLimiter(1, 2)
+	l.Allow("idle")
+	l.Allow("busy")
+
+	*now = now.Add(59 * time.Second)
+	l.Allow("busy")
+
+	// The sweep a minute after the last one drops buckets that have refilled
+	// completely and keeps the one still refilling
+	*now = now.Add(time.Second)
+	l.Allow("other")
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("full idle bucket was not evicted")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("bucket still refilling was evicted")
+	}
+}
+
+func TestNilLimitsAllowEverything(t *testing.T) {
+	if l := NewLimiter(0, 10); l != nil {
+		t.Fatal("NewLimiter(0) returned a limiter")
+	}
+	var l *Limiter
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("nil limiter refused a request")
+	}
+	var c *Concurrency
+	if _, ok := c.Acquire("a"); !ok {
+		t.Fatal("nil concurrency cap refused a request")
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	c := NewConcurrency(1)
+	release, ok := c.Acquire("p1")
+	if !ok {
+		t.Fatal("first slot refused")
+	}
+	if _, ok := c.Acquire("p1"); ok {
+		t.Fatal("second concurrent slot allowed")
+	}
+	if _, ok := c.Acquire("p2"); !ok {
+		t.Fatal("another key shares the slot")
+	}
+	release()
+	release()
+	if _, ok := c.Acquire("p1"); !ok {
+		t.Fatal("slot not freed by release")
+	}
+	if n := c.inFlight["p1"]; n != 1 {
+		t.Fatalf("in flight = %d after a double release, want 1", n)
+	}
+}
@@ -0,0 +1,113 @@
+package resilience
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// clock is a manually advanced time source for breakers under test
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(threshold, probes int) (*Breaker, *clock) {
+	c := &clock{t: time.Unix(0, 0)}
+	b := NewBreaker(threshold, time.Minute, probes)
+	b.now = c.now
+	return b, c
+}
+
+func fail(t *testing.T, b *Breaker) {
+	t.Helper()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() = %v, want nil", err)
+	}
+	b.Failure()
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, 1)
+	fail(t, b)
+	fail(t, b)
+	if s := b.State(); s != StateClosed {
+		t.Fatalf("after 2 failures State() = %s, want closed", s)
+	}
+	fail(t, b)
+	if s := b.State(); s != StateOpen {
+		t.Fatalf("after 3 failures State() = %s, want open", s)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open Allow() = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b, _ := newTestBreaker(2, 1)
+	fail(t, b)
+	if err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.Success()
+	fail(t, b)
+	if s := b.State(); s != StateClosed {
+		t.Fatalf("State() = %s, want closed: failures are not consecutive", s)
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		probe func(*Breaker)
+		want  State
+	}{
+		{"success closes", (*Breaker).Success, StateClosed},
+		{"failure re-opens", (*Breaker).Failure, StateOpen},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b, c := newTestBreaker(1, 2)
+			fail(t, b)
+
+			c.advance(time.Minute - time.Second)
+			if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Allow() before OpenTimeout = %v, want ErrCircuitOpen", err)
+			}
+
+			c.advance(time.Second)
+			if s := b.State(); s != StateHalfOpen {
+				t.Fatalf("State() after OpenTimeout = %s, want half-open", s)
+			}
+			for i := 0; i < 2; i++ {
+				if err := b.Allow(); err != nil {
+					t.Fatalf("probe %d: Allow() = %v, want nil", i+1, err)
+				}
+			}
+			if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Allow() beyond the probes = %v, want ErrCircuitOpen", err)
+			}
+
+			tt.probe(b)
+			if s := b.State(); s != tt.want {
+				t.Fatalf("State() = %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerReleaseFreesProbe(t *testing.T) {
+	b, c := newTestBreaker(1, 1)
+	fail(t, b)
+	c.advance(time.Minute)
+
+	if err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.Release()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() after Release = %v, want nil", err)
+	}
+	if s := b.State(); s != StateHalfOpen {
+		t.Fatalf("State() = %s, want half-open", s)
+	}
+}
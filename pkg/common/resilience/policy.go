@@ -0,0 +1,137 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrConcurrencyLimit is returned when too many calls are already in flight
+var ErrConcurrencyLimit = errors.New("concurrency limit reached")
+
+// Config holds the resilience settings for one downstream service
+type Config struct {
+	Timeout          time.Duration // per-call timeout, 0 disables it
+	FailureThreshold int           // consecutive failures before the breaker opens
+	OpenTimeout      time.Duration // how long the breaker stays open before probing
+	HalfOpenProbes   int           // calls allowed through while half-open
+	MaxConcurrent    int           // in-flight call limit, 0 means unlimited
+
+	// Observe, when set, is told the duration and result of every call,
+	// including calls rejected without reaching the service
+	Observe func(elapsed time.Duration, err error)
+}
+
+// Policy combines a per-call timeout, a circuit breaker and a concurrency
+// limit in front of a downstream service
+type Policy struct {
+	Name    string
+	timeout time.Duration
+	breaker *Breaker
+	slots   chan struct{}
+	observe func(time.Duration, error)
+}
+
+// NewPolicy creates a policy for the named service
+func NewPolicy(name string, cfg Config) *Policy {
+	p := &Policy{
+		Name:    name,
+		timeout: cfg.Timeout,
+		breaker: NewBreaker(cfg.FailureThreshold, cfg.OpenTimeout, cfg.HalfOpenProbes),
+		observe: cfg.Observe,
+	}
+	if cfg.MaxConcurrent > 0 {
+		p.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return p
+}
+
+// Execute runs fn under the policy. Calls are rejected immediately with
+// ErrConcurrencyLimit or ErrCircuitOpen instead of queueing.
+func (p *Policy) Execute(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if p.observe != nil {
+		start := time.Now()
+		defer func() { p.observe(time.Since(start), err) }()
+	}
+
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+			defer func() { <-p.slots }()
+		default:
+			return fmt.Errorf("%s: %w", p.Name, ErrConcurrencyLimit)
+		}
+	}
+
+	if err := p.breaker.Allow(); err != nil {
+		return fmt.Errorf("%s: %w", p.Name, err)
+	}
+
+	callCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	err = fn(callCtx)
+	switch {
+	case err == nil, IsRejected(err):
+		// A rejected request still got an answer, so the service is healthy
+		p.breaker.Success()
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about the service's health,
+		// but a half-open probe slot still has to be released
+		p.breaker.Release()
+	default:
+		p.breaker.Failure()
+	}
+	return err
+}
+
+// State returns the breaker state
+func (p *Policy) State() State {
+	return p.breaker.State()
+}
+
+// InFlight returns the number of calls currently running under the policy
+func (p *Policy) InFlight() int {
+	return len(p.slots)
+}
+
+// RejectedError wraps an error the service answered with because the request
+// itself was refused, such as a 4xx response. It does not count against the
+// breaker and is not worth retrying.
+type RejectedError struct {
+	Err error
+}
+
+func (e *RejectedError) Error() string { return e.Err.Error() }
+
+func (e *RejectedError) Unwrap() error { return e.Err }
+
+// Rejected marks err as the service refusing the request
+func Rejected(err error) error {
+	return &RejectedError{Err: err}
+}
+
+// IsRejected reports whether err means the service refused the request
+func IsRejected(err error) bool {
+	var rejected *RejectedError
+	return errors.As(err, &rejected)
+}
+
+// RefusedStatus reports whether an HTTP status means the service refused the
+// request itself: any 4xx but 408 Request Timeout and 429 Too Many Requests,
+// which say the service is too slow or too busy and count as failures
+func RefusedStatus(code int) bool {
+	return code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+}
+
+// IsUnavailable reports whether err means the call was rejected by a policy
+// without reaching the service
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrConcurrencyLimit)
+}
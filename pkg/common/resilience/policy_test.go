@@ -0,0 +1,41 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPolicyRejectedDoesNotTrip(t *testing.T) {
+	p := NewPolicy("test", Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	refused := Rejected(errors.New("status 400"))
+
+	err := p.Execute(context.Background(), func(context.Context) error { return refused })
+	if !IsRejected(err) {
+		t.Fatalf("Execute() = %v, want a rejected error", err)
+	}
+	if s := p.State(); s != StateClosed {
+		t.Fatalf("State() = %s after a rejected call, want closed", s)
+	}
+
+	p.Execute(context.Background(), func(context.Context) error { return errors.New("status 503") })
+	if s := p.State(); s != StateOpen {
+		t.Fatalf("State() = %s after a failed call, want open", s)
+	}
+	err = p.Execute(context.Background(), func(context.Context) error { return nil })
+	if !IsUnavailable(err) {
+		t.Fatalf("Execute() on an open breaker = %v, want unavailable", err)
+	}
+}
+
+func TestPolicyCallerCancelDoesNotTrip(t *testing.T) {
+	p := NewPolicy("test", Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p.Execute(ctx, func(ctx context.Context) error { return ctx.Err() })
+	if s := p.State(); s != StateClosed {
+		t.Fatalf("State() = %s after a cancelled call, want closed", s)
+	}
+}
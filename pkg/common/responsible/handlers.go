@@ -0,0 +1,156 @@
+package responsible
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/auth"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/logging"
+)
+
+// CoolOffRequest starts or extends a player's cool-off period
+type CoolOffRequest struct {
+	Duration string `json:"duration"` // e.g. "24h" or "720h"
+}
+
+// Handlers serves the operator-facing limits API
+type Handlers struct {
+	Service *Service
+}
+
+// Register mounts the limits endpoints on a group that sits behind operator authentication
+func (h *Handlers) Register(router fiber.Router) {
+	router.Get("/limits", h.GetOperatorLimits)
+	router.Put("/limits", h.SetOperatorLimits)
+	router.Get("/players/:client_id/:player_id", h.GetPlayer)
+	router.Put("/players/:client_id/:player_id/limits", h.SetPlayerLimits)
+	router.Post("/players/:client_id/:player_id/cool-off", h.CoolOff)
+}
+
+// GetOperatorLimits returns the calling operator's limits
+func (h *Handlers) GetOperatorLimits(c *fiber.Ctx) error {
+	op, ok := auth.OperatorFrom(c.UserContext())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Operator authentication required")
+	}
+	return c.JSON(h.Service.OperatorLimits(op.ID))
+}
+
+// SetOperatorLimits replaces the calling operator's limits
+func (h *Handlers) SetOperatorLimits(c *fiber.Ctx) error {
+	op, ok := auth.OperatorFrom(c.UserContext())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Operator authentication required")
+	}
+	limits, err := parseLimits(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Service.SetOperatorLimits(c.UserContext(), op.ID, limits); err != nil {
+		return storeError(c, "Error saving operator limits", err)
+	}
+	slog.InfoContext(c.UserContext(), "Operator limits updated", slog.Any("limits", limits))
+	return c.JSON(h.Service.OperatorLimits(op.ID))
+}
+
+// GetPlayer returns a player's limits, rolling totals and session
+func (h *Handlers) GetPlayer(c *fiber.Ctx) error {
+	op, key, err := h.player(c)
+	if err != nil {
+		return err
+	}
+	return h.status(c, op, key)
+}
+
+// SetPlayerLimits replaces a player's own limits
+func (h *Handlers) SetPlayerLimits(c *fiber.Ctx) error {
+	op, key, err := h.player(c)
+	if err != nil {
+		return err
+	}
+	limits, err := parseLimits(c)
+	if err != nil {
+		return err
+	}
+	if err := h.Service.SetPlayerLimits(c.UserContext(), key, limits, time.Now()); err != nil {
+		return storeError(c, "Error saving player limits", err)
+	}
+	slog.InfoContext(c.UserContext(), "Player limits updated",
+		slog.String(logging.KeyClientID, c.Params("client_id")),
+		slog.String(logging.KeyPlayerID, c.Params("player_id")),
+		slog.Any("limits", limits),
+	)
+	return h.status(c, op, key)
+}
+
+// CoolOff blocks a player from playing for the requested duration
+func (h *Handlers) CoolOff(c *fiber.Ctx) error {
+	_, key, err := h.player(c)
+	if err != nil {
+		return err
+	}
+	var req CoolOffRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "duration must be a positive duration such as \"24h\"")
+	}
+
+	now := time.Now()
+	until, err := h.Service.CoolOff(c.UserContext(), key, now.Add(duration), now)
+	if err != nil {
+		return storeError(c, "Error saving player cool-off", err)
+	}
+	slog.InfoContext(c.UserContext(), "Player cool-off set",
+		slog.String(logging.KeyClientID, c.Params("client_id")),
+		slog.String(logging.KeyPlayerID, c.Params("player_id")),
+		slog.Time("until", until),
+	)
+	return c.JSON(fiber.Map{
+		"status":         "ok",
+		"cool_off_until": until.UTC(),
+	})
+}
+
+// status responds with a player's status
+func (h *Handlers) status(c *fiber.Ctx, op *auth.Operator, key string) error {
+	status, err := h.Service.Status(c.UserContext(), op.ID, key, time.Now())
+	if err != nil {
+		return storeError(c, "Error reading player status", err)
+	}
+	return c.JSON(status)
+}
+
+// storeError logs a failure to read or write the limits state and hides it from the caller
+func storeError(c *fiber.Ctx, msg string, err error) error {
+	slog.ErrorContext(c.UserContext(), msg, slog.Any("error", err))
+	return fiber.NewError(fiber.StatusInternalServerError, "Failed to access the player limits")
+}
+
+// player resolves the player in the path, checking the operator may manage it
+func (h *Handlers) player(c *fiber.Ctx) (*auth.Operator, string, error) {
+	op, ok := auth.OperatorFrom(c.UserContext())
+	if !ok {
+		return nil, "", fiber.NewError(fiber.StatusUnauthorized, "Operator authentication required")
+	}
+	clientID := c.Params("client_id")
+	if !op.Allows(clientID) {
+		return nil, "", fiber.NewError(fiber.StatusForbidden, "Operator is not allowed to manage this client_id")
+	}
+	return op, PlayerKey(clientID, c.Params("player_id")), nil
+}
+
+func parseLimits(c *fiber.Ctx) (Limits, error) {
+	var limits Limits
+	if err := c.BodyParser(&limits); err != nil {
+		return Limits{}, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	if err := limits.Validate(); err != nil {
+		return Limits{}, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return limits, nil
+}
@@ -0,0 +1,111 @@
+package responsible
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/auth"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+// newTestApp serves the limits API behind API key authentication for op-test,
+// which owns client 1001
+func newTestApp(t *testing.T, s *Service) *fiber.App {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "operators.json")
+	operators := `{"operators": [
+		{"id": "op-test", "client_ids": ["1001"], "api_keys": ["key-test"]}
+	]}`
+	if err := os.WriteFile(path, []byte(operators), 0600); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := auth.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	h := &Handlers{Service: s}
+	h.Register(app.Group("/rg", auth.NewAuthenticator(creds, auth.Config{}).Middleware()))
+	return app
+}
+
+func TestHandlers(t *testing.T) {
+	s := newTestService(t, store.NewMemory(), Limits{Loss: WindowAmounts{Day: 500}})
+	app := newTestApp(t, s)
+
+	for _, tt := range []struct {
+		name, method, path, body string
+		wantStatus               int
+		wantBody                 string
+	}{
+		{"operator limits", http.MethodGet, "/rg/limits", "", http.StatusOK, `"day":500`},
+		{"set operator limits", http.MethodPut, "/rg/limits", `{"loss_limits":{"day":200}}`, http.StatusOK, `"day":200`},
+		{"negative operator limits", http.MethodPut, "/rg/limits", `{"loss_limits":{"day":-1}}`, http.StatusBadRequest, "must not be negative"},
+		{"invalid body", http.MethodPut, "/rg/limits", `{`, http.StatusBadRequest, "Invalid request body"},
+		{"player status", http.MethodGet, "/rg/players/1001/p1", "", http.StatusOK, `"effective_limits"`},
+		{"set player limits", http.MethodPut, "/rg/players/1001/p1/limits", `{"max_session_minutes":60}`, http.StatusOK, `"max_session_minutes":60`},
+		{"negative player limits", http.MethodPut, "/rg/players/1001/p1/limits", `{"max_session_minutes":-1}`, http.StatusBadRequest, "must not be negative"},
+		{"player of another operator", http.MethodGet, "/rg/players/2001/p1", "", http.StatusForbidden, "not allowed"},
+		{"limits of another operator's player", http.MethodPut, "/rg/players/2001/p1/limits", `{}`, http.StatusForbidden, "not allowed"},
+		{"cool-off of another operator's player", http.MethodPost, "/rg/players/2001/p1/cool-off", `{"duration":"24h"}`, http.StatusForbidden, "not allowed"},
+		{"cool-off without a duration", http.MethodPost, "/rg/players/1001/p1/cool-off", `{}`, http.StatusBadRequest, "positive duration"},
+		{"negative cool-off", http.MethodPost, "/rg/players/1001/p1/cool-off", `{"duration":"-1h"}`, http.StatusBadRequest, "positive duration"},
+		{"cool-off", http.MethodPost, "/rg/players/1001/p1/cool-off", `{"duration":"24h"}`, http.StatusOK, `"cool_off_until"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set(auth.APIKeyHeader, "key-test")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("%s %s = %d %s, want %d containing %q", tt.method, tt.path, resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandlersCoolOffBlocksSpins(t *testing.T) {
+	s := newTestService(t, store.NewMemory(), Limits{})
+	app := newTestApp(t, s)
+
+	req := httptest.NewRequest(http.MethodPost, "/rg/players/1001/p1/cool-off", strings.NewReader(`{"duration":"1h"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(auth.APIKeyHeader, "key-test")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		CoolOffUntil time.Time `json:"cool_off_until"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Check(context.Background(), "op-test", PlayerKey("1001", "p1"), 1, time.Now())
+	if got := violationCode(t, err); got != CodeCoolOff {
+		t.Errorf("Check = %q, want %q", got, CodeCoolOff)
+	}
+	if err := s.Check(context.Background(), "op-test", PlayerKey("1001", "p1"), 1, body.CoolOffUntil); err != nil {
+		t.Errorf("Check at the end of the cool-off = %v, want nil", err)
+	}
+}
@@ -0,0 +1,107 @@
+package responsible
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Rolling windows over which loss and wagering are limited
+const (
+	Day   = 24 * time.Hour
+	Week  = 7 * Day
+	Month = 30 * Day
+)
+
+// WindowAmounts holds one amount per rolling window; 0 means no limit
+type WindowAmounts struct {
+	Day   float64 `json:"day"`
+	Week  float64 `json:"week"`
+	Month float64 `json:"month"`
+}
+
+// Limits are the responsible-gambling limits of an operator or a player
+type Limits struct {
+	Loss                WindowAmounts `json:"loss_limits"`           // maximum net loss per window
+	Wager               WindowAmounts `json:"wager_limits"`          // maximum amount wagered per window
+	MaxSessionMinutes   int           `json:"max_session_minutes"`   // 0 allows sessions of any length
+	RealityCheckMinutes int           `json:"reality_check_minutes"` // 0 disables reality checks
+}
+
+// Validate rejects negative limits
+func (l Limits) Validate() error {
+	for _, v := range []float64{l.Loss.Day, l.Loss.Week, l.Loss.Month, l.Wager.Day, l.Wager.Week, l.Wager.Month} {
+		if v < 0 {
+			return errors.New("limits must not be negative")
+		}
+	}
+	if l.MaxSessionMinutes < 0 || l.RealityCheckMinutes < 0 {
+		return errors.New("limits must not be negative")
+	}
+	return nil
+}
+
+// Stricter combines two sets of limits, keeping the tighter value of each.
+// A player's own limits can therefore lower, but never raise, the operator's.
+func (l Limits) Stricter(other Limits) Limits {
+	return Limits{
+		Loss:                l.Loss.stricter(other.Loss),
+		Wager:               l.Wager.stricter(other.Wager),
+		MaxSessionMinutes:   int(tighter(float64(l.MaxSessionMinutes), float64(other.MaxSessionMinutes))),
+		RealityCheckMinutes: int(tighter(float64(l.RealityCheckMinutes), float64(other.RealityCheckMinutes))),
+	}
+}
+
+func (w WindowAmounts) stricter(other WindowAmounts) WindowAmounts {
+	return WindowAmounts{
+		Day:   tighter(w.Day, other.Day),
+		Week:  tighter(w.Week, other.Week),
+		Month: tighter(w.Month, other.Month),
+	}
+}
+
+// tighter returns the smaller positive value, treating 0 as unlimited
+func tighter(a, b float64) float64 {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
+
+// Violation is a spin refused by a responsible-gambling limit
+type Violation struct {
+	Code    string // stable code returned to clients
+	Message string
+	Until   time.Time // when the restriction lifts, if known
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Violation codes
+const (
+	CodeCoolOff      = "rg_cool_off"
+	CodeSessionLimit = "rg_session_limit"
+	CodeLossLimit    = "rg_loss_limit"
+	CodeWagerLimit   = "rg_wager_limit"
+)
+
+func limitViolation(code, kind, window string, limit float64) *Violation {
+	return &Violation{
+		Code:    code,
+		Message: fmt.Sprintf("This bet would exceed the %s %s limit of %.2f", window, kind, limit),
+	}
+}
+
+// RealityCheck reminds the player how long they have played and their result
+type RealityCheck struct {
+	SessionMinutes int     `json:"session_minutes"`
+	Wagered        float64 `json:"wagered"`
+	Won            float64 `json:"won"`
+	NetResult      float64 `json:"net_result"` // won minus wagered
+}
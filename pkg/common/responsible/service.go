@@ -0,0 +1,380 @@
+package responsible
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+// Store keys of the responsible-gambling state
+const (
+	playerStateKind   = "responsible"
+	operatorLimitsKey = "responsible_operator_limits"
+)
+
+// Totals are a player's amounts wagered and won over one window
+type Totals struct {
+	Wagered float64 `json:"wagered"`
+	Won     float64 `json:"won"`
+}
+
+// NetLoss is the amount lost over the window, negative when the player is ahead
+func (t Totals) NetLoss() float64 {
+	return t.Wagered - t.Won
+}
+
+// hourTotals are the amounts of one clock hour
+type hourTotals struct {
+	Hour int64 `json:"hour"` // unix time / 3600
+	Totals
+}
+
+// player is the stored state of one player
+type player struct {
+	Limits       *Limits      `json:"limits,omitempty"`
+	CoolOffUntil time.Time    `json:"cool_off_until,omitzero"`
+	Hours        []hourTotals `json:"hours,omitempty"` // ascending, at most a month old
+
+	SessionStart     time.Time `json:"session_start,omitzero"`
+	LastActivity     time.Time `json:"last_activity,omitzero"`
+	LastRealityCheck time.Time `json:"last_reality_check,omitzero"`
+	Session          Totals    `json:"session"`
+}
+
+// Service tracks wagering per player and enforces responsible-gambling
+// limits. Player limits, cool-offs, rolling totals and sessions are kept in
+// the store, as are the limits operators set, so restarts forget nothing.
+type Service struct {
+	store       store.Store
+	defaults    Limits
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	operators map[string]Limits // mirrors operatorLimitsKey
+}
+
+// NewService creates a tracker keeping its state in st. defaults apply to
+// every operator, and a play session ends after idleTimeout without spins.
+// Call Restore before use to load the operator limits.
+func NewService(st store.Store, defaults Limits, idleTimeout time.Duration) *Service {
+	return &Service{
+		store:       st,
+		defaults:    defaults,
+		idleTimeout: idleTimeout,
+		operators:   make(map[string]Limits),
+	}
+}
+
+// Restore loads the operator limits saved by a previous process
+func (s *Service) Restore(ctx context.Context) error {
+	var data []byte
+	err := s.store.View(ctx, func(tx store.Tx) (err error) {
+		data, err = tx.GetSetting(operatorLimitsKey)
+		return err
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	operators := make(map[string]Limits)
+	if err := json.Unmarshal(data, &operators); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.operators = operators
+	return nil
+}
+
+// PlayerKey identifies a player across operators
+func PlayerKey(clientID, playerID string) string {
+	return clientID + "\x00" + playerID
+}
+
+// SetOperatorLimits replaces an operator's limits, which apply to all of its
+// players; empty limits remove them
+func (s *Service) SetOperatorLimits(ctx context.Context, operatorID string, limits Limits) error {
+	return s.UpdateOperatorLimits(ctx, map[string]Limits{operatorID: limits}, nil)
+}
+
+// UpdateOperatorLimits replaces the limits of several operators at once, as
+// SetOperatorLimits does. also, when set, runs in the same store transaction,
+// so other writes succeed or fail together with the limits.
+func (s *Service) UpdateOperatorLimits(ctx context.Context, changes map[string]Limits, also func(tx store.Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := maps.Clone(s.operators)
+	for operatorID, limits := range changes {
+		if limits == (Limits{}) {
+			delete(next, operatorID)
+		} else {
+			next[operatorID] = limits
+		}
+	}
+	data, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Update(ctx, func(tx store.Tx) error {
+		if err := tx.PutSetting(operatorLimitsKey, data); err != nil {
+			return err
+		}
+		if also != nil {
+			return also(tx)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	s.operators = next
+	return nil
+}
+
+// OperatorLimits returns an operator's limits, combined with the server defaults
+func (s *Service) OperatorLimits(operatorID string) Limits {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.defaults.Stricter(s.operators[operatorID])
+}
+
+// SetPlayerLimits replaces a player's own limits
+func (s *Service) SetPlayerLimits(ctx context.Context, playerKey string, limits Limits, now time.Time) error {
+	return s.update(ctx, playerKey, now, func(p *player) {
+		p.Limits = &limits
+	})
+}
+
+// CoolOff blocks a player until the given time. An active cool-off can be
+// extended but never shortened.
+func (s *Service) CoolOff(ctx context.Context, playerKey string, until, now time.Time) (time.Time, error) {
+	var result time.Time
+	err := s.update(ctx, playerKey, now, func(p *player) {
+		if until.After(p.CoolOffUntil) {
+			p.CoolOffUntil = until
+		}
+		result = p.CoolOffUntil
+	})
+	return result, err
+}
+
+// PlayerStatus is a player's limits and activity as reported by the API
+type PlayerStatus struct {
+	Limits         Limits            `json:"limits"`
+	EffectiveLimit Limits            `json:"effective_limits"`
+	CoolOffUntil   *time.Time        `json:"cool_off_until,omitempty"`
+	Windows        map[string]Totals `json:"windows"`
+	SessionMinutes int               `json:"session_minutes"`
+	Session        Totals            `json:"session"`
+}
+
+// Status reports a player's limits, rolling totals and current session
+func (s *Service) Status(ctx context.Context, operatorID, playerKey string, now time.Time) (PlayerStatus, error) {
+	p, err := s.load(ctx, playerKey, now)
+	if err != nil {
+		return PlayerStatus{}, err
+	}
+	status := PlayerStatus{
+		EffectiveLimit: s.effective(operatorID, p),
+		Windows: map[string]Totals{
+			"day":   p.window(now, Day),
+			"week":  p.window(now, Week),
+			"month": p.window(now, Month),
+		},
+	}
+	if p.Limits != nil {
+		status.Limits = *p.Limits
+	}
+	if now.Before(p.CoolOffUntil) {
+		until := p.CoolOffUntil
+		status.CoolOffUntil = &until
+	}
+	if s.inSession(p, now) {
+		status.SessionMinutes = int(now.Sub(p.SessionStart).Minutes())
+		status.Session = p.Session
+	}
+	return status, nil
+}
+
+// Check reports whether a player may place a bet, returning a *Violation when
+// not and any other error when the player's state cannot be read. It must be
+// called before the bet is taken.
+func (s *Service) Check(ctx context.Context, operatorID, playerKey string, bet float64, now time.Time) error {
+	p, err := s.load(ctx, playerKey, now)
+	if err != nil {
+		return err
+	}
+	if now.Before(p.CoolOffUntil) {
+		return &Violation{Code: CodeCoolOff, Message: "Player is in a cool-off period", Until: p.CoolOffUntil}
+	}
+
+	// A player back from a break starts a new session with this bet
+	limits := s.effective(operatorID, p)
+	if limit := time.Duration(limits.MaxSessionMinutes) * time.Minute; limit > 0 && s.inSession(p, now) && now.Sub(p.SessionStart) >= limit {
+		return &Violation{
+			Code:    CodeSessionLimit,
+			Message: "Session time limit reached, take a break before playing again",
+			Until:   p.LastActivity.Add(s.idleTimeout),
+		}
+	}
+
+	// Assume the worst case, that the bet is lost
+	for _, w := range []struct {
+		name        string
+		span        time.Duration
+		loss, wager float64
+	}{
+		{"daily", Day, limits.Loss.Day, limits.Wager.Day},
+		{"weekly", Week, limits.Loss.Week, limits.Wager.Week},
+		{"monthly", Month, limits.Loss.Month, limits.Wager.Month},
+	} {
+		totals := p.window(now, w.span)
+		if w.loss > 0 && totals.NetLoss()+bet > w.loss {
+			return limitViolation(CodeLossLimit, "loss", w.name, w.loss)
+		}
+		if w.wager > 0 && totals.Wagered+bet > w.wager {
+			return limitViolation(CodeWagerLimit, "wager", w.name, w.wager)
+		}
+	}
+	return nil
+}
+
+// Record adds a settled spin to the player's totals and returns a reality
+// check when one is due
+func (s *Service) Record(ctx context.Context, operatorID, playerKey string, bet, win float64, now time.Time) (*RealityCheck, error) {
+	var check *RealityCheck
+	err := s.update(ctx, playerKey, now, func(p *player) {
+		if !s.inSession(p, now) {
+			p.SessionStart = now
+			p.LastRealityCheck = now
+			p.Session = Totals{}
+		}
+		p.LastActivity = now
+		p.Session.Wagered += bet
+		p.Session.Won += win
+
+		hour := now.Unix() / 3600
+		if n := len(p.Hours); n > 0 && p.Hours[n-1].Hour == hour {
+			p.Hours[n-1].Wagered += bet
+			p.Hours[n-1].Won += win
+		} else {
+			p.Hours = append(p.Hours, hourTotals{Hour: hour, Totals: Totals{Wagered: bet, Won: win}})
+		}
+
+		interval := time.Duration(s.effective(operatorID, p).RealityCheckMinutes) * time.Minute
+		if interval <= 0 || now.Sub(p.LastRealityCheck) < interval {
+			return
+		}
+		p.LastRealityCheck = now
+		check = &RealityCheck{
+			SessionMinutes: int(now.Sub(p.SessionStart).Minutes()),
+			Wagered:        p.Session.Wagered,
+			Won:            p.Session.Won,
+			NetResult:      p.Session.Won - p.Session.Wagered,
+		}
+	})
+	return check, err
+}
+
+// inSession reports whether the player's last spin is recent enough for a
+// spin now to continue the same session
+func (s *Service) inSession(p *player, now time.Time) bool {
+	return !p.LastActivity.IsZero() && now.Sub(p.LastActivity) <= s.idleTimeout
+}
+
+// effective combines the server, operator and player limits
+func (s *Service) effective(operatorID string, p *player) Limits {
+	limits := s.OperatorLimits(operatorID)
+	if p.Limits != nil {
+		limits = limits.Stricter(*p.Limits)
+	}
+	return limits
+}
+
+// load reads a player's state; a player never seen before has none
+func (s *Service) load(ctx context.Context, playerKey string, now time.Time) (*player, error) {
+	p := &player{}
+	err := s.store.View(ctx, func(tx store.Tx) error {
+		return getPlayer(tx, playerKey, now, p)
+	})
+	return p, err
+}
+
+// update changes a player's state with fn in one transaction, dropping hours
+// older than the longest window
+func (s *Service) update(ctx context.Context, playerKey string, now time.Time, fn func(*player)) error {
+	return s.store.Update(ctx, func(tx store.Tx) error {
+		p := &player{}
+		if err := getPlayer(tx, playerKey, now, p); err != nil {
+			return err
+		}
+		fn(p)
+
+		oldest := now.Add(-Month).Unix() / 3600
+		i := 0
+		for i < len(p.Hours) && p.Hours[i].Hour < oldest {
+			i++
+		}
+		p.Hours = p.Hours[i:]
+
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		return tx.PutPlayerState(playerStateKind, playerKey, data, s.expiry(p))
+	})
+}
+
+// expiry is when a player's state stops mattering: never while they have
+// limits of their own, otherwise once their cool-off, hours and session are over
+func (s *Service) expiry(p *player) time.Time {
+	if p.Limits != nil {
+		return time.Time{}
+	}
+	expires := p.CoolOffUntil
+	if n := len(p.Hours); n > 0 {
+		expires = later(expires, time.Unix((p.Hours[n-1].Hour+1)*3600, 0).Add(Month))
+	}
+	return later(expires, p.LastActivity.Add(s.idleTimeout))
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// getPlayer decodes a stored player into p, leaving it empty when there is none
+func getPlayer(tx store.Tx, playerKey string, now time.Time, p *player) error {
+	data, err := tx.GetPlayerState(playerStateKind, playerKey, now)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, p)
+}
+
+// window sums the hours that fall within span of now. The hour holding the
+// start of the window counts in full, so limits err on the strict side.
+func (p *player) window(now time.Time, span time.Duration) Totals {
+	from := now.Add(-span).Unix() / 3600
+	var totals Totals
+	for _, h := range p.Hours {
+		if h.Hour >= from {
+			totals.Wagered += h.Wagered
+			totals.Won += h.Won
+		}
+	}
+	return totals
+}
@@ -0,0 +1,283 @@
+package responsible
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+var base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+const testPlayer = "1001\x00p1"
+
+func newTestService(t *testing.T, st store.Store, defaults Limits) *Service {
+	t.Helper()
+	s := NewService(st, defaults, 10*time.Minute)
+	if err := s.Restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func record(t *testing.T, s *Service, bet, win float64, at time.Time) *RealityCheck {
+	t.Helper()
+	check, err := s.Record(context.Background(), "op", testPlayer, bet, win, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return check
+}
+
+// violationCode returns the code of a refused check, or "" when it passed
+func violationCode(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		return ""
+	}
+	var violation *Violation
+	if !errors.As(err, &violation) {
+		t.Fatalf("Check error = %v, want a *Violation", err)
+	}
+	return violation.Code
+}
+
+func TestCheckLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		defaults Limits
+		operator Limits
+		player   *Limits
+		bet      float64
+		want     string
+	}{
+		{"no limits", Limits{}, Limits{}, nil, 1000, ""},
+		{"within daily loss", Limits{Loss: WindowAmounts{Day: 100}}, Limits{}, nil, 40, ""},
+		{"bet counted as lost", Limits{Loss: WindowAmounts{Day: 100}}, Limits{}, nil, 41, CodeLossLimit},
+		{"weekly wager", Limits{}, Limits{Wager: WindowAmounts{Week: 100}}, nil, 21, CodeWagerLimit},
+		{"monthly loss", Limits{}, Limits{}, &Limits{Loss: WindowAmounts{Month: 70}}, 11, CodeLossLimit},
+		{"player limit lowers the operator's", Limits{}, Limits{Loss: WindowAmounts{Day: 100}}, &Limits{Loss: WindowAmounts{Day: 65}}, 6, CodeLossLimit},
+		{"player limit cannot raise the operator's", Limits{}, Limits{Loss: WindowAmounts{Day: 65}}, &Limits{Loss: WindowAmounts{Day: 100}}, 6, CodeLossLimit},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService(t, store.NewMemory(), tt.defaults)
+			if err := s.SetOperatorLimits(ctx, "op", tt.operator); err != nil {
+				t.Fatal(err)
+			}
+			if tt.player != nil {
+				if err := s.SetPlayerLimits(ctx, testPlayer, *tt.player, base); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Wagered 80 and won 20: a net loss of 60
+			record(t, s, 80, 20, base)
+
+			err := s.Check(ctx, "op", testPlayer, tt.bet, base.Add(time.Minute))
+			if got := violationCode(t, err); got != tt.want {
+				t.Errorf("Check = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowsRollOver(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, store.NewMemory(), Limits{Loss: WindowAmounts{Day: 100, Week: 150}})
+	record(t, s, 90, 0, base)
+
+	for _, tt := range []struct {
+		name  string
+		after time.Duration
+		bet   float64
+		want  string
+	}{
+		{"same day", time.Hour, 20, CodeLossLimit},
+		{"next day", 25 * time.Hour, 20, ""},
+		{"next day within the week", 25 * time.Hour, 70, CodeLossLimit},
+		{"next week", Week + time.Hour, 70, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Check(ctx, "op", testPlayer, tt.bet, base.Add(tt.after))
+			if got := violationCode(t, err); got != tt.want {
+				t.Errorf("Check = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	status, err := s.Status(ctx, "op", testPlayer, base.Add(25*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Windows["day"].Wagered != 0 || status.Windows["week"].Wagered != 90 || status.Windows["month"].Wagered != 90 {
+		t.Errorf("Windows = %+v, want 0 for the day and 90 for the week and month", status.Windows)
+	}
+}
+
+func TestWindowCountsItsFirstHour(t *testing.T) {
+	s := newTestService(t, store.NewMemory(), Limits{Wager: WindowAmounts{Day: 100}})
+	// 12:45, in the same clock hour as the start of the window 23h30m later
+	record(t, s, 90, 0, base.Add(45*time.Minute))
+
+	err := s.Check(context.Background(), "op", testPlayer, 20, base.Add(45*time.Minute+23*time.Hour+30*time.Minute))
+	if got := violationCode(t, err); got != CodeWagerLimit {
+		t.Errorf("Check 23h30m after the wager = %q, want %q", got, CodeWagerLimit)
+	}
+}
+
+func TestSessionLimit(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, store.NewMemory(), Limits{MaxSessionMinutes: 30})
+
+	// Spins every 5 minutes keep the session going past its limit
+	for minute := 0; minute < 30; minute += 5 {
+		record(t, s, 1, 0, base.Add(time.Duration(minute)*time.Minute))
+	}
+	last := base.Add(25 * time.Minute)
+
+	err := s.Check(ctx, "op", testPlayer, 1, base.Add(30*time.Minute))
+	if got := violationCode(t, err); got != CodeSessionLimit {
+		t.Fatalf("Check = %q, want %q", got, CodeSessionLimit)
+	}
+	var violation *Violation
+	errors.As(err, &violation)
+	if want := last.Add(10 * time.Minute); !violation.Until.Equal(want) {
+		t.Errorf("Until = %v, want %v", violation.Until, want)
+	}
+
+	// A break longer than the idle timeout starts a new session
+	if err := s.Check(ctx, "op", testPlayer, 1, last.Add(11*time.Minute)); err != nil {
+		t.Errorf("Check after a break = %v, want nil", err)
+	}
+}
+
+func TestCoolOff(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, store.NewMemory(), Limits{})
+
+	until, err := s.CoolOff(ctx, testPlayer, base.Add(Day), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !until.Equal(base.Add(Day)) {
+		t.Errorf("CoolOff = %v, want %v", until, base.Add(Day))
+	}
+
+	// A shorter cool-off leaves the active one in place
+	until, err = s.CoolOff(ctx, testPlayer, base.Add(time.Hour), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !until.Equal(base.Add(Day)) {
+		t.Errorf("shortened CoolOff = %v, want %v", until, base.Add(Day))
+	}
+
+	err = s.Check(ctx, "op", testPlayer, 1, base.Add(Day-time.Second))
+	if got := violationCode(t, err); got != CodeCoolOff {
+		t.Errorf("Check during cool-off = %q, want %q", got, CodeCoolOff)
+	}
+	if err := s.Check(ctx, "op", testPlayer, 1, base.Add(Day)); err != nil {
+		t.Errorf("Check after cool-off = %v, want nil", err)
+	}
+	status, err := s.Status(ctx, "op", testPlayer, base.Add(Day))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.CoolOffUntil != nil {
+		t.Errorf("CoolOffUntil = %v after expiry, want nil", status.CoolOffUntil)
+	}
+}
+
+func TestRealityCheck(t *testing.T) {
+	s := newTestService(t, store.NewMemory(), Limits{RealityCheckMinutes: 10})
+
+	if check := record(t, s, 5, 0, base); check != nil {
+		t.Errorf("first spin reality check = %+v, want nil", check)
+	}
+	if check := record(t, s, 5, 2, base.Add(9*time.Minute)); check != nil {
+		t.Errorf("reality check after 9 minutes = %+v, want nil", check)
+	}
+	check := record(t, s, 5, 0, base.Add(10*time.Minute))
+	want := RealityCheck{SessionMinutes: 10, Wagered: 15, Won: 2, NetResult: -13}
+	if check == nil || *check != want {
+		t.Errorf("reality check = %+v, want %+v", check, want)
+	}
+}
+
+func TestStateOutlivesService(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+
+	s := newTestService(t, st, Limits{})
+	if err := s.SetOperatorLimits(ctx, "op", Limits{Loss: WindowAmounts{Day: 100}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPlayerLimits(ctx, testPlayer, Limits{Wager: WindowAmounts{Week: 500}}, base); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CoolOff(ctx, testPlayer, base.Add(time.Hour), base); err != nil {
+		t.Fatal(err)
+	}
+	record(t, s, 80, 0, base)
+
+	restarted := newTestService(t, st, Limits{})
+	if got := restarted.OperatorLimits("op").Loss.Day; got != 100 {
+		t.Errorf("restored operator loss limit = %v, want 100", got)
+	}
+	err := restarted.Check(ctx, "op", testPlayer, 1, base.Add(time.Minute))
+	if got := violationCode(t, err); got != CodeCoolOff {
+		t.Errorf("Check = %q, want %q", got, CodeCoolOff)
+	}
+	err = restarted.Check(ctx, "op", testPlayer, 30, base.Add(2*time.Hour))
+	if got := violationCode(t, err); got != CodeLossLimit {
+		t.Errorf("Check = %q, want %q", got, CodeLossLimit)
+	}
+	status, err := restarted.Status(ctx, "op", testPlayer, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Limits.Wager.Week != 500 {
+		t.Errorf("restored player limits = %+v, want a weekly wager limit of 500", status.Limits)
+	}
+
+	// Removing the operator's limits is persisted too
+	if err := restarted.SetOperatorLimits(ctx, "op", Limits{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := newTestService(t, st, Limits{}).OperatorLimits("op"); got != (Limits{}) {
+		t.Errorf("operator limits after removal = %+v, want none", got)
+	}
+}
+
+func TestPlayerStateExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t, store.NewMemory(), Limits{})
+	const limited = "1001\x00p2"
+	if err := s.SetPlayerLimits(ctx, limited, Limits{MaxSessionMinutes: 60}, base); err != nil {
+		t.Fatal(err)
+	}
+	record(t, s, 80, 0, base)
+
+	later := base.Add(Month + 2*time.Hour)
+	status, err := s.Status(ctx, "op", testPlayer, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Windows["month"] != (Totals{}) {
+		t.Errorf("month window after a month = %+v, want empty", status.Windows["month"])
+	}
+	err = s.store.View(ctx, func(tx store.Tx) error {
+		if _, err := tx.GetPlayerState(playerStateKind, testPlayer, later); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("state of an idle player after a month: %v, want ErrNotFound", err)
+		}
+		if _, err := tx.GetPlayerState(playerStateKind, limited, later); err != nil {
+			t.Errorf("state of a player with limits after a month: %v, want kept", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,23 +2,32 @@ package rng
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/tracing"
 )
 
 // Client for RNG service
 type Client struct {
 	ServiceURL string
+	HTTPClient *http.Client
+	Policy     *resilience.Policy
 }
 
-// NewClient creates a new RNG client
-func NewClient(serviceURL string) *Client {
+// NewClient creates a new RNG client guarded by the given resilience policy
+func NewClient(serviceURL string, policy *resilience.Policy) *Client {
 	return &Client{
 		ServiceURL: serviceURL,
+		HTTPClient: &http.Client{},
+		Policy:     policy,
 	}
 }
 
@@ -32,7 +41,7 @@ type Request struct {
 	RequestSalt      string  `json:"request_salt"`
 	BetAmount        float64 `json:"bet_amount"`
 	IPAddress        string  `json:"ip_address"`
-    UserAgent        string  `json:"user_agent"`
+	UserAgent        string  `json:"user_agent"`
 }
 
 type Response struct {
@@ -41,8 +50,16 @@ type Response struct {
 	WinProb     float64 `json:"win_prob"`
 }
 
-// GetOutcome calls the RNG service and returns the outcome
-func (c *Client) GetOutcome(clientID, gameID, playerID, betID string, rtp, payoutMultiplier, betAmount float64, ipAddress string, userAgent string) (Response, error) {
+// GetOutcome calls the RNG service and returns the outcome. The call is
+// abandoned as soon as ctx is cancelled or its deadline passes.
+func (c *Client) GetOutcome(ctx context.Context, clientID, gameID, playerID, betID string, rtp, payoutMultiplier, betAmount float64, ipAddress string, userAgent string) (_ Response, err error) {
+	ctx, span := tracing.Start(ctx, "rng.GetOutcome",
+		attribute.String("game.id", gameID),
+		attribute.String("bet.id", betID),
+		attribute.Float64("rng.payout_multiplier", payoutMultiplier),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	reqBody, err := json.Marshal(Request{
 		ClientID:         clientID,
 		GameID:           gameID,
@@ -56,29 +73,55 @@ func (c *Client) GetOutcome(clientID, gameID, playerID, betID string, rtp, payou
 		UserAgent:        userAgent,
 	})
 	if err != nil {
-		log.Printf("Error marshaling RNG request: %v", err)
+		slog.ErrorContext(ctx, "Error marshaling RNG request", slog.Any("error", err))
 		return Response{}, err
 	}
 
-	log.Printf("RNG request: %s", string(reqBody))
+	slog.DebugContext(ctx, "RNG request",
+		slog.Float64("rtp", rtp),
+		slog.Float64("payout_multiplier", payoutMultiplier),
+		slog.Float64("bet_amount", betAmount),
+	)
 
-	resp, err := http.Post(c.ServiceURL, "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		log.Printf("Error calling RNG API: %v", err)
-		return Response{}, err
-	}
-	defer resp.Body.Close()
+	var rngResp Response
+	err = c.Policy.Execute(ctx, func(ctx context.Context) error {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.ServiceURL, bytes.NewReader(reqBody))
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		tracing.InjectHTTP(ctx, httpReq)
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("RNG API returned non-200 status: %d", resp.StatusCode)
-		return Response{}, errors.New("RNG API call failed")
-	}
+		resp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error calling RNG API", slog.Any("error", err))
+			return err
+		}
+		defer resp.Body.Close()
 
-	var rngResp Response
-	if err := json.NewDecoder(resp.Body).Decode(&rngResp); err != nil {
-		log.Printf("Error decoding RNG response: %v", err)
+		if resp.StatusCode != http.StatusOK {
+			slog.ErrorContext(ctx, "RNG API returned non-200 status", slog.Int("status", resp.StatusCode))
+			err := fmt.Errorf("RNG API call failed with status %d", resp.StatusCode)
+			if resilience.RefusedStatus(resp.StatusCode) {
+				return resilience.Rejected(err)
+			}
+			return err
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&rngResp); err != nil {
+			slog.ErrorContext(ctx, "Error decoding RNG response", slog.Any("error", err))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if resilience.IsUnavailable(err) {
+			slog.WarnContext(ctx, "RNG API call rejected", slog.Any("error", err))
+		}
 		return Response{}, err
 	}
 
+	slog.DebugContext(ctx, "RNG response", slog.String("outcome", rngResp.PrefOutcome), slog.Float64("win_prob", rngResp.WinProb))
+	span.SetAttributes(attribute.String("rng.outcome", rngResp.PrefOutcome))
 	return rngResp, nil
 }
@@ -0,0 +1,76 @@
+package rng
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+)
+
+func TestGetOutcomeCancelAbortsCall(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	policy := resilience.NewPolicy("rng", resilience.Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	c := NewClient(srv.URL, policy)
+	start := time.Now()
+	_, err := c.GetOutcome(ctx, "client", "game", "player", "bet", 0.96, 1, 1, "127.0.0.1", "test")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetOutcome() = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetOutcome returned after %s, want it abandoned on cancel", elapsed)
+	}
+	if s := policy.State(); s != resilience.StateClosed {
+		t.Errorf("breaker State() = %s after a cancelled call, want closed", s)
+	}
+}
+
+func TestGetOutcomeClientErrorDoesNotTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer srv.Close()
+
+	policy := resilience.NewPolicy("rng", resilience.Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	c := NewClient(srv.URL, policy)
+	_, err := c.GetOutcome(context.Background(), "client", "game", "player", "bet", 0.96, 1, 1, "127.0.0.1", "test")
+	if !resilience.IsRejected(err) {
+		t.Fatalf("GetOutcome() = %v, want a rejected error", err)
+	}
+	if s := policy.State(); s != resilience.StateClosed {
+		t.Errorf("breaker State() = %s, want closed", s)
+	}
+}
+
+func TestGetOutcomeOverloadTrips(t *testing.T) {
+	for _, status := range []int{http.StatusRequestTimeout, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+			defer srv.Close()
+
+			policy := resilience.NewPolicy("rng", resilience.Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+			c := NewClient(srv.URL, policy)
+			_, err := c.GetOutcome(context.Background(), "client", "game", "player", "bet", 0.96, 1, 1, "127.0.0.1", "test")
+			if err == nil || resilience.IsRejected(err) {
+				t.Fatalf("GetOutcome() = %v, want a failure that is not a rejection", err)
+			}
+			if s := policy.State(); s != resilience.StateOpen {
+				t.Errorf("breaker State() = %s, want open", s)
+			}
+		})
+	}
+}
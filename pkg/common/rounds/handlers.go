@@ -0,0 +1,182 @@
+package rounds
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/auth"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/session"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+// Handlers serves round history to players, operators and support staff
+type Handlers struct {
+	Store    store.Store
+	Sessions *session.Manager
+}
+
+// RegisterPlayer mounts the player's own history, authenticated by session token
+func (h *Handlers) RegisterPlayer(router fiber.Router) {
+	router.Get("/history", h.playerList)
+	router.Get("/history/:bet_id", h.playerGet)
+}
+
+// RegisterOperator mounts history lookups on a group behind operator
+// authentication; operators only see their own clients
+func (h *Handlers) RegisterOperator(router fiber.Router) {
+	router.Get("/", h.list(operatorScope))
+	router.Get("/:client_id/:bet_id", h.get(operatorScope))
+}
+
+// RegisterSupport mounts unrestricted history lookups on a group behind the admin token
+func (h *Handlers) RegisterSupport(router fiber.Router) {
+	router.Get("/", h.list(nil))
+	router.Get("/:client_id/:bet_id", h.get(nil))
+}
+
+// operatorScope checks the authenticated operator may read a client's rounds
+func operatorScope(c *fiber.Ctx, clientID string) error {
+	op, ok := auth.OperatorFrom(c.UserContext())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Operator authentication required")
+	}
+	if !op.Allows(clientID) {
+		return fiber.NewError(fiber.StatusForbidden, "Operator is not allowed to read this client_id")
+	}
+	return nil
+}
+
+func (h *Handlers) list(scope func(*fiber.Ctx, string) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		q, err := parseQuery(c)
+		if err != nil {
+			return err
+		}
+		q.ClientID, q.PlayerID = c.Query("client_id"), c.Query("player_id")
+		if q.ClientID == "" || q.PlayerID == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "client_id and player_id are required")
+		}
+		if scope != nil {
+			if err := scope(c, q.ClientID); err != nil {
+				return err
+			}
+		}
+		return h.respondList(c, q)
+	}
+}
+
+func (h *Handlers) get(scope func(*fiber.Ctx, string) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		clientID := c.Params("client_id")
+		if scope != nil {
+			if err := scope(c, clientID); err != nil {
+				return err
+			}
+		}
+		return h.respondGet(c, clientID, c.Params("bet_id"), "")
+	}
+}
+
+func (h *Handlers) playerList(c *fiber.Ctx) error {
+	claims, err := h.Sessions.Verify(c.UserContext(), session.TokenFrom(c))
+	if err != nil {
+		return sessionError(c, err)
+	}
+	q, err := parseQuery(c)
+	if err != nil {
+		return err
+	}
+	q.ClientID, q.PlayerID = claims.ClientID, claims.PlayerID
+	return h.respondList(c, q)
+}
+
+func (h *Handlers) playerGet(c *fiber.Ctx) error {
+	claims, err := h.Sessions.Verify(c.UserContext(), session.TokenFrom(c))
+	if err != nil {
+		return sessionError(c, err)
+	}
+	return h.respondGet(c, claims.ClientID, c.Params("bet_id"), claims.PlayerID)
+}
+
+func (h *Handlers) respondList(c *fiber.Ctx, q store.RoundQuery) error {
+	var page store.RoundPage
+	err := h.Store.View(c.UserContext(), func(tx store.Tx) (err error) {
+		page, err = tx.ListRounds(q)
+		return err
+	})
+	if errors.Is(err, store.ErrInvalidCursor) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid cursor")
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error listing rounds", slog.Any("error", err))
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to read rounds")
+	}
+	return c.JSON(page)
+}
+
+// respondGet answers one round; a non-empty playerID hides other players' rounds
+func (h *Handlers) respondGet(c *fiber.Ctx, clientID, betID, playerID string) error {
+	var round store.Round
+	err := h.Store.View(c.UserContext(), func(tx store.Tx) (err error) {
+		round, err = tx.GetRound(clientID, betID)
+		return err
+	})
+	if errors.Is(err, store.ErrNotFound) || (err == nil && playerID != "" && round.PlayerID != playerID) {
+		return fiber.NewError(fiber.StatusNotFound, "round not found")
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error reading round", slog.Any("error", err))
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to read rounds")
+	}
+	return c.JSON(round)
+}
+
+// parseQuery reads the from, to, level, limit and cursor query parameters.
+// Dates are RFC 3339 timestamps or YYYY-MM-DD days in UTC.
+func parseQuery(c *fiber.Ctx) (store.RoundQuery, error) {
+	var q store.RoundQuery
+	var err error
+	if q.From, err = parseDate(c.Query("from")); err != nil {
+		return store.RoundQuery{}, fiber.NewError(fiber.StatusBadRequest, "Invalid from date")
+	}
+	if q.To, err = parseDate(c.Query("to")); err != nil {
+		return store.RoundQuery{}, fiber.NewError(fiber.StatusBadRequest, "Invalid to date")
+	}
+	if level := c.Query("level"); level != "" {
+		if q.Level, err = strconv.Atoi(level); err != nil || q.Level < 1 {
+			return store.RoundQuery{}, fiber.NewError(fiber.StatusBadRequest, "Invalid level")
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit < 1 {
+			return store.RoundQuery{}, fiber.NewError(fiber.StatusBadRequest, "Invalid limit")
+		}
+	}
+	q.Cursor = c.Query("cursor")
+	return q, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+func sessionError(c *fiber.Ctx, err error) error {
+	if !session.IsTokenError(err) {
+		return err
+	}
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"status":  "error",
+		"code":    session.Code(err),
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,53 @@
+package rounds
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+// brokenStore fails every read, as a store whose disk went away would
+type brokenStore struct {
+	store.Store
+}
+
+func (brokenStore) View(context.Context, func(store.Tx) error) error {
+	return errors.New("disk I/O error")
+}
+
+func TestSupportHistoryErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		st   store.Store
+		path string
+		want int
+	}{
+		{"list", store.NewMemory(), "/rounds/?client_id=c1&player_id=p1", http.StatusOK},
+		{"invalid cursor", store.NewMemory(), "/rounds/?client_id=c1&player_id=p1&cursor=!!", http.StatusBadRequest},
+		{"missing player", store.NewMemory(), "/rounds/?client_id=c1", http.StatusBadRequest},
+		{"unknown round", store.NewMemory(), "/rounds/c1/b1", http.StatusNotFound},
+		{"list from a failing store", brokenStore{store.NewMemory()}, "/rounds/?client_id=c1&player_id=p1", http.StatusInternalServerError},
+		{"round from a failing store", brokenStore{store.NewMemory()}, "/rounds/c1/b1", http.StatusInternalServerError},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			h := &Handlers{Store: tt.st}
+			h.RegisterSupport(app.Group("/rounds"))
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
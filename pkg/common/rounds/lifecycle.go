@@ -0,0 +1,260 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+// Lifecycle errors
+var (
+	ErrRoundInProgress   = errors.New("player has a round in progress")
+	ErrRoundVoided       = errors.New("round was voided")
+	ErrInvalidTransition = errors.New("invalid round transition")
+)
+
+// transitions lists the states a round may move to from each open state. A
+// round is voided before it is resolved; once the RNG has decided it, it can
+// only be settled.
+var transitions = map[string][]string{
+	store.StateCreated:  {store.StateWagered, store.StateVoided},
+	store.StateWagered:  {store.StateResolved, store.StateVoided},
+	store.StateResolved: {store.StateSettled},
+}
+
+// Lifecycle moves rounds through their states, persisting every transition so
+// a round interrupted between the RNG call and the credit can be reconciled on
+// restart. A player has at most one open round at a time.
+type Lifecycle struct {
+	store       store.Store
+	minDuration time.Duration
+}
+
+// NewLifecycle creates a lifecycle keeping round states in st. minDuration is
+// the shortest time from a round's creation to its result; 0 for none.
+func NewLifecycle(st store.Store, minDuration time.Duration) *Lifecycle {
+	return &Lifecycle{store: st, minDuration: minDuration}
+}
+
+// Outcome is what the RNG decided for a round
+type Outcome struct {
+	Outcome     string // win or loss
+	Reels       []string
+	Combination string
+	WinAmount   float64
+	WinCapped   bool
+	RNGResponse json.RawMessage // kept so the round can be settled after a crash
+}
+
+// Round is one round moving through the lifecycle
+type Round struct {
+	lifecycle *Lifecycle
+	state     store.RoundState
+}
+
+// Begin creates a round for the bet in round. It fails with
+// ErrRoundInProgress while the player has another open round, with
+// ErrRoundVoided when the bet was voided and with store.ErrDuplicate when it
+// was already played. A nil Lifecycle begins rounds tracked in memory only.
+func (l *Lifecycle) Begin(ctx context.Context, round store.Round) (*Round, error) {
+	r := &Round{lifecycle: l, state: store.RoundState{
+		Round:       round,
+		State:       store.StateCreated,
+		Transitions: []store.Transition{{State: store.StateCreated, At: round.StartedAt}},
+	}}
+	if l == nil {
+		return r, nil
+	}
+	err := l.store.Update(ctx, func(tx store.Tx) error {
+		open, err := tx.OpenRound(round.ClientID, round.PlayerID)
+		if err == nil {
+			return fmt.Errorf("%w: bet %s is %s", ErrRoundInProgress, open.Round.BetID, open.State)
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		previous, err := tx.GetRoundState(round.ClientID, round.BetID)
+		switch {
+		case err == nil && previous.State == store.StateVoided:
+			return fmt.Errorf("%w: %s", ErrRoundVoided, previous.VoidReason)
+		case err == nil:
+			return store.ErrDuplicate
+		case !errors.Is(err, store.ErrNotFound):
+			return err
+		}
+		return tx.PutRoundState(r.state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Resume continues a round a previous process left open
+func (l *Lifecycle) Resume(state store.RoundState) *Round {
+	return &Round{lifecycle: l, state: state}
+}
+
+// Open returns every round that is neither settled nor voided, oldest first
+func (l *Lifecycle) Open(ctx context.Context) (open []store.RoundState, err error) {
+	err = l.store.View(ctx, func(tx store.Tx) error {
+		open, err = tx.OpenRounds()
+		return err
+	})
+	return open, err
+}
+
+// State returns the round's current state
+func (r *Round) State() store.RoundState {
+	return r.state
+}
+
+// Unresolved reports whether the round is created or wagered, so can still be voided
+func (r *Round) Unresolved() bool {
+	return r.state.State == store.StateCreated || r.state.State == store.StateWagered
+}
+
+// Wager takes the bet, writing its debit with the transition
+func (r *Round) Wager(ctx context.Context) error {
+	return r.transition(ctx, store.StateWagered, nil, func(tx store.Tx, s store.RoundState) error {
+		return tx.AppendLedger(entry(s, store.Debit, s.Round.BetAmount))
+	})
+}
+
+// Resolve records the RNG's decision
+func (r *Round) Resolve(ctx context.Context, outcome Outcome) error {
+	return r.transition(ctx, store.StateResolved, func(s *store.RoundState) {
+		s.Round.Outcome = outcome.Outcome
+		s.Round.Reels = slices.Clone(outcome.Reels)
+		s.Round.Combination = outcome.Combination
+		s.Round.WinAmount = outcome.WinAmount
+		s.Round.WinCapped = outcome.WinCapped
+		s.RNGResponse = outcome.RNGResponse
+	}, nil)
+}
+
+// Settle completes the round: it records the round in history, credits any
+// win and runs also, when set, in the same transaction. balanceAfter is the
+// player's balance the wallet reported for the round, or nil when unknown.
+func (r *Round) Settle(ctx context.Context, balanceAfter *float64, also func(tx store.Tx, round store.Round) error) error {
+	return r.transition(ctx, store.StateSettled, func(s *store.RoundState) {
+		s.Round.BalanceAfter = balanceAfter
+	}, func(tx store.Tx, s store.RoundState) error {
+		if err := tx.InsertRound(s.Round); err != nil {
+			return err
+		}
+		if s.Round.WinAmount > 0 {
+			if err := tx.AppendLedger(entry(s, store.Credit, s.Round.WinAmount)); err != nil {
+				return err
+			}
+		}
+		if also != nil {
+			return also(tx, s.Round)
+		}
+		return nil
+	})
+}
+
+// Deliver marks a resolved round that could not be settled as returned to the
+// operator, who pays its win from the response; reconciliation then settles
+// it without crediting the win again
+func (r *Round) Deliver(ctx context.Context) error {
+	if r.state.State != store.StateResolved {
+		return fmt.Errorf("%w: deliver %s round", ErrInvalidTransition, r.state.State)
+	}
+	next := r.state
+	next.Delivered = true
+	if r.lifecycle != nil {
+		err := r.lifecycle.store.Update(context.WithoutCancel(ctx), func(tx store.Tx) error {
+			return tx.PutRoundState(next)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	r.state = next
+	return nil
+}
+
+// Void ends a round that will not be resolved. A wagered round has its debit
+// rolled back.
+func (r *Round) Void(ctx context.Context, reason string) error {
+	wagered := r.state.State == store.StateWagered
+	return r.transition(ctx, store.StateVoided, func(s *store.RoundState) {
+		s.VoidReason = reason
+	}, func(tx store.Tx, s store.RoundState) error {
+		if !wagered {
+			return nil
+		}
+		return tx.AppendLedger(entry(s, store.Rollback, s.Round.BetAmount))
+	})
+}
+
+// Hold waits until the round has lasted the minimum round duration, or ctx ends
+func (r *Round) Hold(ctx context.Context) {
+	if r.lifecycle == nil || r.lifecycle.minDuration <= 0 {
+		return
+	}
+	wait := time.Until(r.state.Round.StartedAt.Add(r.lifecycle.minDuration))
+	if wait <= 0 {
+		return
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// transition moves the round to state to. apply changes the round before it is
+// written; write adds its own writes to the transaction. Writes outlive a
+// cancelled request: they record what already happened.
+func (r *Round) transition(ctx context.Context, to string, apply func(*store.RoundState), write func(store.Tx, store.RoundState) error) error {
+	if !slices.Contains(transitions[r.state.State], to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, r.state.State, to)
+	}
+	now := time.Now()
+	next := r.state
+	next.State = to
+	next.Transitions = append(slices.Clone(r.state.Transitions), store.Transition{State: to, At: now})
+	if to == store.StateSettled || to == store.StateVoided {
+		next.Round.CompletedAt = now
+	}
+	if apply != nil {
+		apply(&next)
+	}
+	if r.lifecycle != nil {
+		err := r.lifecycle.store.Update(context.WithoutCancel(ctx), func(tx store.Tx) error {
+			if write != nil {
+				if err := write(tx, next); err != nil {
+					return err
+				}
+			}
+			return tx.PutRoundState(next)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	r.state = next
+	return nil
+}
+
+// entry builds a ledger entry for a round
+func entry(s store.RoundState, kind string, amount float64) store.LedgerEntry {
+	return store.LedgerEntry{
+		ClientID:  s.Round.ClientID,
+		PlayerID:  s.Round.PlayerID,
+		BetID:     s.Round.BetID,
+		Kind:      kind,
+		Amount:    amount,
+		Currency:  s.Round.Currency,
+		CreatedAt: s.Transitions[len(s.Transitions)-1].At,
+	}
+}
@@ -0,0 +1,125 @@
+package rounds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+func testBet(bet string) store.Round {
+	return store.Round{BetID: bet, ClientID: "c1", PlayerID: "p1", BetAmount: 1, StartedAt: time.Now()}
+}
+
+// ledgerKinds returns the kinds of a bet's ledger entries in order
+func ledgerKinds(t *testing.T, st store.Store, bet string) []string {
+	t.Helper()
+	var kinds []string
+	st.View(context.Background(), func(tx store.Tx) error {
+		entries, err := tx.Ledger("c1", bet)
+		for _, e := range entries {
+			kinds = append(kinds, e.Kind)
+		}
+		return err
+	})
+	return kinds
+}
+
+func TestSettledRound(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	l := NewLifecycle(st, 0)
+
+	r, err := l.Begin(ctx, testBet("b1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Begin(ctx, testBet("b2")); !errors.Is(err, ErrRoundInProgress) {
+		t.Fatalf("second Begin = %v, want ErrRoundInProgress", err)
+	}
+	if err := r.Resolve(ctx, Outcome{Outcome: "win"}); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Resolve before Wager = %v, want ErrInvalidTransition", err)
+	}
+
+	if err := r.Wager(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Resolve(ctx, Outcome{Outcome: "win", Reels: []string{"A", "A", "A"}, WinAmount: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Void(ctx, "late"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Void after Resolve = %v, want ErrInvalidTransition", err)
+	}
+	if err := r.Settle(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	st.View(ctx, func(tx store.Tx) error {
+		state, err := tx.GetRoundState("c1", "b1")
+		if err != nil || state.State != store.StateSettled || len(state.Transitions) != 4 {
+			t.Errorf("round state = %+v, %v; want settled after 4 transitions", state, err)
+		}
+		if round, err := tx.GetRound("c1", "b1"); err != nil || round.WinAmount != 5 || round.CompletedAt.IsZero() {
+			t.Errorf("GetRound = %+v, %v", round, err)
+		}
+		return nil
+	})
+	if kinds := ledgerKinds(t, st, "b1"); len(kinds) != 2 || kinds[0] != store.Debit || kinds[1] != store.Credit {
+		t.Errorf("ledger = %v, want debit, credit", kinds)
+	}
+
+	// The lock is released once the round is settled; the bet cannot be replayed
+	if _, err := l.Begin(ctx, testBet("b1")); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("Begin of a settled bet = %v, want ErrDuplicate", err)
+	}
+	if _, err := l.Begin(ctx, testBet("b2")); err != nil {
+		t.Errorf("Begin after settling = %v", err)
+	}
+}
+
+func TestVoidedRound(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	l := NewLifecycle(st, 0)
+
+	created, _ := l.Begin(ctx, testBet("b1"))
+	if err := created.Void(ctx, "settings_unavailable"); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := ledgerKinds(t, st, "b1"); len(kinds) != 0 {
+		t.Errorf("ledger of a round voided before the wager = %v, want none", kinds)
+	}
+
+	wagered, err := l.Begin(ctx, testBet("b2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wagered.Wager(ctx)
+	if err := wagered.Void(ctx, "rng_unavailable"); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := ledgerKinds(t, st, "b2"); len(kinds) != 2 || kinds[1] != store.Rollback {
+		t.Errorf("ledger of a voided wager = %v, want debit, rollback", kinds)
+	}
+	if got := wagered.State(); got.State != store.StateVoided || got.VoidReason != "rng_unavailable" {
+		t.Errorf("state = %s (%s), want voided (rng_unavailable)", got.State, got.VoidReason)
+	}
+	if _, err := l.Begin(ctx, testBet("b2")); !errors.Is(err, ErrRoundVoided) {
+		t.Errorf("Begin of a voided bet = %v, want ErrRoundVoided", err)
+	}
+}
+
+func TestHoldWaitsForMinimumDuration(t *testing.T) {
+	ctx := context.Background()
+	l := NewLifecycle(store.NewMemory(), 50*time.Millisecond)
+	r, err := l.Begin(ctx, testBet("b1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Hold(ctx)
+	if elapsed := time.Since(r.State().Round.StartedAt); elapsed < 50*time.Millisecond {
+		t.Errorf("Hold returned after %v, want at least 50ms", elapsed)
+	}
+}
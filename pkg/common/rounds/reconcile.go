@@ -0,0 +1,225 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/metrics"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/wallet"
+)
+
+// Reconciliation actions
+const (
+	ActionSettled = "settled"
+	ActionVoided  = "voided"
+	ActionFailed  = "failed" // left open, to be retried on the next run
+)
+
+// VoidReconciled is the void reason of rounds voided by reconciliation
+const VoidReconciled = "reconciled"
+
+// Wallet is told of the money a reconciled round moves
+type Wallet interface {
+	Notify(ctx context.Context, t wallet.Transaction) (wallet.Receipt, error)
+}
+
+// Reconciler completes or voids the rounds a previous process left open.
+// Resolved rounds are settled from their stored RNG response and their win
+// credited, unless the spin delivered the result to the operator; wagered rounds are voided and their bet rolled back; created
+// rounds, which moved no money, are voided. The same rounds always get the
+// same decision, so a run interrupted halfway can simply be repeated.
+type Reconciler struct {
+	Lifecycle *Lifecycle
+
+	// Wallets are notified by environment; rounds of an environment without
+	// one are reconciled in the store only
+	Wallets map[string]Wallet
+
+	// Settled, when set, runs in the transaction settling a round
+	Settled func(tx store.Tx, round store.Round) error
+
+	// ReportDir, when set, receives a JSON report of every run that found open rounds
+	ReportDir string
+
+	// MinAge, when set, leaves alone rounds whose last transition is more
+	// recent, since a spin may still be completing them
+	MinAge time.Duration
+}
+
+// Report describes one reconciliation run
+type Report struct {
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Settled    int               `json:"settled"`
+	Voided     int               `json:"voided"`
+	Failed     int               `json:"failed"`
+	Rounds     []ReconciledRound `json:"rounds"`
+	File       string            `json:"-"`
+}
+
+// ReconciledRound is what reconciliation did with one round
+type ReconciledRound struct {
+	ClientID    string  `json:"client_id"`
+	PlayerID    string  `json:"player_id"`
+	BetID       string  `json:"bet_id"`
+	GameID      string  `json:"game_id"`
+	Environment string  `json:"environment"`
+	FoundState  string  `json:"found_state"`
+	Action      string  `json:"action"`
+	Wallet      string  `json:"wallet,omitempty"` // credit or rollback, when money moved
+	Amount      float64 `json:"amount,omitempty"`
+	Notified    bool    `json:"wallet_notified"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Run reconciles every open round and writes the report
+func (r *Reconciler) Run(ctx context.Context) (Report, error) {
+	report := Report{StartedAt: time.Now(), Rounds: []ReconciledRound{}}
+	open, err := r.Lifecycle.Open(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, state := range open {
+		if r.MinAge > 0 && time.Since(state.Transitions[len(state.Transitions)-1].At) < r.MinAge {
+			continue
+		}
+		result := r.reconcile(ctx, state)
+		switch result.Action {
+		case ActionSettled:
+			report.Settled++
+		case ActionVoided:
+			report.Voided++
+		default:
+			report.Failed++
+		}
+		metrics.RecordReconciled(state.Round.GameID, result.Action)
+		report.Rounds = append(report.Rounds, result)
+	}
+	report.FinishedAt = time.Now()
+
+	if r.ReportDir != "" && len(report.Rounds) > 0 {
+		report.File, err = r.writeReport(report)
+	}
+	return report, err
+}
+
+// reconcile completes or voids one round
+func (r *Reconciler) reconcile(ctx context.Context, state store.RoundState) ReconciledRound {
+	round := state.Round
+	result := ReconciledRound{
+		ClientID:    round.ClientID,
+		PlayerID:    round.PlayerID,
+		BetID:       round.BetID,
+		GameID:      round.GameID,
+		Environment: round.Environment,
+		FoundState:  state.State,
+	}
+	fail := func(err error) ReconciledRound {
+		slog.ErrorContext(ctx, "Error reconciling round",
+			slog.String("bet_id", round.BetID), slog.String("state", state.State), slog.Any("error", err))
+		result.Action, result.Error = ActionFailed, err.Error()
+		return result
+	}
+
+	resumed := r.Lifecycle.Resume(state)
+	switch state.State {
+	case store.StateCreated:
+		if err := resumed.Void(ctx, VoidReconciled); err != nil {
+			return fail(err)
+		}
+		result.Action = ActionVoided
+
+	case store.StateWagered:
+		result.Wallet, result.Amount = wallet.Rollback, round.BetAmount
+		if _, err := r.notify(ctx, &result, round, VoidReconciled); err != nil {
+			return fail(err)
+		}
+		if err := resumed.Void(ctx, VoidReconciled); err != nil {
+			return fail(err)
+		}
+		result.Action = ActionVoided
+
+	case store.StateResolved:
+		if err := checkRNGResponse(state); err != nil {
+			return fail(err)
+		}
+		// A delivered round's win was paid by the operator from the spin response
+		var receipt wallet.Receipt
+		if round.WinAmount > 0 && !state.Delivered {
+			result.Wallet, result.Amount = wallet.Credit, round.WinAmount
+			var err error
+			if receipt, err = r.notify(ctx, &result, round, ""); err != nil {
+				return fail(err)
+			}
+		}
+		if err := resumed.Settle(ctx, receipt.Balance, r.Settled); err != nil {
+			return fail(err)
+		}
+		result.Action = ActionSettled
+
+	default:
+		return fail(fmt.Errorf("unexpected open state %q", state.State))
+	}
+	return result
+}
+
+// notify sends the round's credit or rollback to its environment's wallet,
+// when there is one. The round is only moved on once the wallet has it.
+func (r *Reconciler) notify(ctx context.Context, result *ReconciledRound, round store.Round, reason string) (wallet.Receipt, error) {
+	w, ok := r.Wallets[round.Environment]
+	if !ok {
+		return wallet.Receipt{}, nil
+	}
+	receipt, err := w.Notify(ctx, wallet.Transaction{
+		TransactionID: wallet.TransactionID(round.ClientID, round.BetID, result.Wallet),
+		Type:          result.Wallet,
+		ClientID:      round.ClientID,
+		PlayerID:      round.PlayerID,
+		GameID:        round.GameID,
+		BetID:         round.BetID,
+		Amount:        result.Amount,
+		Currency:      round.Currency,
+		Reason:        reason,
+	})
+	if err != nil {
+		return wallet.Receipt{}, fmt.Errorf("wallet %s: %w", result.Wallet, err)
+	}
+	result.Notified = true
+	return receipt, nil
+}
+
+// checkRNGResponse makes sure a resolved round's outcome is the one the RNG returned
+func checkRNGResponse(state store.RoundState) error {
+	var rng struct {
+		PrefOutcome string `json:"pref_outcome"`
+	}
+	if len(state.RNGResponse) == 0 {
+		return fmt.Errorf("resolved round has no stored RNG response")
+	}
+	if err := json.Unmarshal(state.RNGResponse, &rng); err != nil {
+		return fmt.Errorf("stored RNG response: %w", err)
+	}
+	if rng.PrefOutcome != state.Round.Outcome {
+		return fmt.Errorf("stored RNG outcome %q does not match round outcome %q", rng.PrefOutcome, state.Round.Outcome)
+	}
+	return nil
+}
+
+// writeReport writes the report as JSON and returns its path
+func (r *Reconciler) writeReport(report Report) (string, error) {
+	if err := os.MkdirAll(r.ReportDir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(r.ReportDir, "reconciliation-"+report.StartedAt.UTC().Format("20060102T150405Z")+".json")
+	return path, os.WriteFile(path, data, 0o644)
+}
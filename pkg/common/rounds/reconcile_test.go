@@ -0,0 +1,142 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/wallet"
+)
+
+type fakeWallet struct {
+	sent    []wallet.Transaction
+	balance *float64
+	err     error
+}
+
+func (w *fakeWallet) Notify(_ context.Context, t wallet.Transaction) (wallet.Receipt, error) {
+	if w.err != nil {
+		return wallet.Receipt{}, w.err
+	}
+	w.sent = append(w.sent, t)
+	return wallet.Receipt{Balance: w.balance}, nil
+}
+
+// openRound leaves a round of its own player in the given state, as a crashed process would
+func openRound(t *testing.T, l *Lifecycle, bet, state string) {
+	t.Helper()
+	ctx := context.Background()
+	round := testBet(bet)
+	round.PlayerID, round.Environment = "player-"+bet, "prod"
+	r, err := l.Begin(ctx, round)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state == store.StateCreated {
+		return
+	}
+	r.Wager(ctx)
+	if state == store.StateWagered {
+		return
+	}
+	r.Resolve(ctx, Outcome{Outcome: "win", Reels: []string{"A", "A", "A"}, WinAmount: 5, RNGResponse: json.RawMessage(`{"pref_outcome":"win"}`)})
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	l := NewLifecycle(st, 0)
+	openRound(t, l, "b1", store.StateCreated)
+	openRound(t, l, "b2", store.StateWagered)
+	openRound(t, l, "b3", store.StateResolved)
+
+	balance := 105.0
+	w := &fakeWallet{balance: &balance}
+	var replayed []string
+	r := &Reconciler{
+		Lifecycle: l,
+		Wallets:   map[string]Wallet{"prod": w},
+		Settled: func(_ store.Tx, round store.Round) error {
+			replayed = append(replayed, round.BetID)
+			return nil
+		},
+		ReportDir: t.TempDir(),
+	}
+	report, err := r.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Settled != 1 || report.Voided != 2 || report.Failed != 0 {
+		t.Errorf("report = %d settled, %d voided, %d failed; want 1, 2, 0", report.Settled, report.Voided, report.Failed)
+	}
+	if len(w.sent) != 2 || w.sent[0].Type != wallet.Rollback || w.sent[0].Amount != 1 || w.sent[1].Type != wallet.Credit || w.sent[1].Amount != 5 {
+		t.Errorf("wallet transactions = %+v, want a rollback of 1 then a credit of 5", w.sent)
+	}
+	if len(replayed) != 1 || replayed[0] != "b3" {
+		t.Errorf("Settled ran for %v, want b3", replayed)
+	}
+	if _, err := os.Stat(report.File); err != nil {
+		t.Errorf("report file: %v", err)
+	}
+
+	st.View(ctx, func(tx store.Tx) error {
+		if open, _ := tx.OpenRounds(); len(open) != 0 {
+			t.Errorf("%d rounds still open", len(open))
+		}
+		if round, err := tx.GetRound("c1", "b3"); err != nil || round.WinAmount != 5 || round.BalanceAfter == nil || *round.BalanceAfter != balance {
+			t.Errorf("settled round = %+v, %v; want a win of 5 and the wallet's balance", round, err)
+		}
+		return nil
+	})
+
+	// A second run finds nothing and writes no report
+	if again, err := r.Run(ctx); err != nil || len(again.Rounds) != 0 || again.File != "" {
+		t.Errorf("second run = %+v, %v; want nothing to do", again, err)
+	}
+}
+
+func TestReconcileLeavesRoundOpenWhenWalletFails(t *testing.T) {
+	ctx := context.Background()
+	l := NewLifecycle(store.NewMemory(), 0)
+	openRound(t, l, "b1", store.StateWagered)
+
+	r := &Reconciler{Lifecycle: l, Wallets: map[string]Wallet{"prod": &fakeWallet{err: errors.New("down")}}}
+	report, err := r.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Failed != 1 || report.Rounds[0].Action != ActionFailed || report.Rounds[0].Notified {
+		t.Errorf("report = %+v, want one failed round", report)
+	}
+	if open, _ := l.Open(ctx); len(open) != 1 || open[0].State != store.StateWagered {
+		t.Errorf("open rounds = %+v, want b1 still wagered", open)
+	}
+}
+
+func TestReconcileLeavesRecentRoundsAlone(t *testing.T) {
+	ctx := context.Background()
+	l := NewLifecycle(store.NewMemory(), 0)
+	openRound(t, l, "b1", store.StateResolved)
+
+	// A spin may still be settling a round it resolved moments ago
+	r := &Reconciler{Lifecycle: l, MinAge: time.Hour, ReportDir: t.TempDir()}
+	report, err := r.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rounds) != 0 || report.File != "" {
+		t.Errorf("report = %+v, want the recent round skipped and no report", report)
+	}
+	if open, _ := l.Open(ctx); len(open) != 1 {
+		t.Fatalf("%d open rounds, want b1 still open", len(open))
+	}
+
+	r.MinAge = 0
+	if report, err := r.Run(ctx); err != nil || report.Settled != 1 {
+		t.Errorf("run without a minimum age = %+v, %v; want b1 settled", report, err)
+	}
+}
@@ -0,0 +1,158 @@
+package session
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/auth"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/environment"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/logging"
+)
+
+// TokenFrom returns the bearer token of a request, if any
+func TokenFrom(c *fiber.Ctx) string {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// LaunchRequest is sent by an operator to start a player's game session
+type LaunchRequest struct {
+	ClientID string `json:"client_id"`
+	PlayerID string `json:"player_id"`
+	GameID   string `json:"game_id"`
+	Currency string `json:"currency"`
+}
+
+// LaunchResponse carries the token the game client sends on every spin
+type LaunchResponse struct {
+	Status       string    `json:"status"`
+	SessionToken string    `json:"session_token"`
+	SessionID    string    `json:"session_id"`
+	Environment  string    `json:"environment"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// RevokeRequest ends one session by token, or every session of a player
+type RevokeRequest struct {
+	SessionToken string `json:"session_token"`
+	ClientID     string `json:"client_id"`
+	PlayerID     string `json:"player_id"`
+}
+
+// Handlers serves the operator-facing session endpoints
+type Handlers struct {
+	Manager      *Manager
+	Environments *environment.Router
+}
+
+// Register mounts the launch and revoke endpoints on a group that sits
+// behind operator authentication
+func (h *Handlers) Register(router fiber.Router) {
+	router.Post("/launch", h.Launch)
+	router.Post("/revoke", h.Revoke)
+}
+
+// Launch issues a session token bound to the player, operator, currency and environment
+func (h *Handlers) Launch(c *fiber.Ctx) error {
+	op, ok := auth.OperatorFrom(c.UserContext())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Operator authentication required")
+	}
+
+	var req LaunchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	if req.ClientID == "" || req.PlayerID == "" || req.Currency == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "client_id, player_id and currency must not be empty")
+	}
+	if !op.Allows(req.ClientID) {
+		return fiber.NewError(fiber.StatusForbidden, "Operator is not allowed to launch for this client_id")
+	}
+
+	env := h.Environments.Resolve(environment.Request{
+		OperatorID: op.ID,
+		APIKey:     c.Get(environment.APIKeyHeader),
+		Origin:     c.Get(fiber.HeaderOrigin),
+	})
+	token, claims, err := h.Manager.Issue(Claims{
+		OperatorID:  op.ID,
+		ClientID:    req.ClientID,
+		PlayerID:    req.PlayerID,
+		GameID:      req.GameID,
+		Currency:    req.Currency,
+		Environment: env.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(c.UserContext(), "Session launched",
+		slog.String("session_id", claims.SessionID),
+		slog.String(logging.KeyClientID, claims.ClientID),
+		slog.String(logging.KeyPlayerID, claims.PlayerID),
+		slog.String(logging.KeyGameID, claims.GameID),
+		slog.String("currency", claims.Currency),
+		slog.String(logging.KeyEnvironment, claims.Environment),
+	)
+	return c.JSON(LaunchResponse{
+		Status:       "success",
+		SessionToken: token,
+		SessionID:    claims.SessionID,
+		Environment:  claims.Environment,
+		ExpiresAt:    claims.Expires().UTC(),
+	})
+}
+
+// Revoke ends a session, or all sessions of a player, owned by the calling operator
+func (h *Handlers) Revoke(c *fiber.Ctx) error {
+	op, ok := auth.OperatorFrom(c.UserContext())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "Operator authentication required")
+	}
+
+	var req RevokeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	switch {
+	case req.SessionToken != "":
+		claims, err := h.Manager.Verify(c.UserContext(), req.SessionToken)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"code":    Code(err),
+				"message": err.Error(),
+			})
+		}
+		if claims.OperatorID != op.ID {
+			return fiber.NewError(fiber.StatusForbidden, "Session belongs to another operator")
+		}
+		if err := h.Manager.Revoke(c.UserContext(), claims); err != nil {
+			return err
+		}
+		slog.InfoContext(c.UserContext(), "Session revoked", slog.String("session_id", claims.SessionID))
+	case req.ClientID != "" && req.PlayerID != "":
+		if !op.Allows(req.ClientID) {
+			return fiber.NewError(fiber.StatusForbidden, "Operator is not allowed to revoke for this client_id")
+		}
+		if err := h.Manager.RevokePlayer(c.UserContext(), req.ClientID, req.PlayerID); err != nil {
+			return err
+		}
+		slog.InfoContext(c.UserContext(), "Player sessions revoked",
+			slog.String(logging.KeyClientID, req.ClientID),
+			slog.String(logging.KeyPlayerID, req.PlayerID),
+		)
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "Either session_token or client_id and player_id are required")
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
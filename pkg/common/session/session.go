@@ -0,0 +1,177 @@
+package session
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Errors returned by Verify; each maps to an error code in the spin response
+var (
+	ErrMissing   = errors.New("session token missing")
+	ErrMalformed = errors.New("session token malformed")
+	ErrSignature = errors.New("session token signature invalid")
+	ErrExpired   = errors.New("session token expired")
+	ErrRevoked   = errors.New("session revoked")
+)
+
+// IsTokenError reports whether err is about the token itself rather than a
+// failure to check it
+func IsTokenError(err error) bool {
+	for _, target := range []error{ErrMissing, ErrMalformed, ErrSignature, ErrExpired, ErrRevoked} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Code returns the stable error code clients receive for a token error
+func Code(err error) string {
+	switch {
+	case errors.Is(err, ErrMissing):
+		return "session_missing"
+	case errors.Is(err, ErrExpired):
+		return "session_expired"
+	case errors.Is(err, ErrRevoked):
+		return "session_revoked"
+	default:
+		return "session_invalid"
+	}
+}
+
+// Claims identify the player a session token was issued for
+type Claims struct {
+	SessionID   string `json:"sid"`
+	OperatorID  string `json:"op"`
+	ClientID    string `json:"cid"`
+	PlayerID    string `json:"pid"`
+	GameID      string `json:"gid,omitempty"` // empty allows every game
+	Currency    string `json:"cur"`
+	Environment string `json:"env"`
+	IssuedAt    int64  `json:"iat"` // unix milliseconds
+	ExpiresAt   int64  `json:"exp"` // unix milliseconds
+}
+
+// Expires returns the expiry time of the token
+func (c Claims) Expires() time.Time {
+	return time.UnixMilli(c.ExpiresAt)
+}
+
+// Revocations persists revoked sessions so they stay revoked across restarts
+type Revocations interface {
+	RevokeSession(ctx context.Context, sessionID string, expires time.Time) error
+	RevokePlayerSessions(ctx context.Context, clientID, playerID string, at time.Time) error
+	SessionRevoked(ctx context.Context, sessionID, clientID, playerID string, issuedAt time.Time) (bool, error)
+}
+
+// Manager issues, verifies and revokes session tokens. Tokens are the
+// base64url JSON claims and their HMAC-SHA256, joined by a dot.
+type Manager struct {
+	keys        [][]byte // the first key signs, every key verifies
+	ttl         time.Duration
+	revocations Revocations
+}
+
+// NewManager creates a manager signing with keys[0]. Older keys stay valid
+// for verification so the signing key can be rotated without logging players out.
+func NewManager(keys []string, ttl time.Duration, revocations Revocations) (*Manager, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one session signing key is required")
+	}
+	m := &Manager{ttl: ttl, revocations: revocations}
+	for _, key := range keys {
+		if len(key) < 32 {
+			return nil, errors.New("session signing keys must be at least 32 characters")
+		}
+		m.keys = append(m.keys, []byte(key))
+	}
+	return m, nil
+}
+
+// Issue creates a token for the given claims, filling in the session ID and lifetime
+func (m *Manager) Issue(claims Claims) (string, Claims, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", Claims{}, err
+	}
+	now := time.Now()
+	claims.SessionID = hex.EncodeToString(id)
+	claims.IssuedAt = now.UnixMilli()
+	claims.ExpiresAt = now.Add(m.ttl).UnixMilli()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", Claims{}, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(m.keys[0], encoded)), claims, nil
+}
+
+// Verify checks a token's signature, expiry and revocation and returns its claims
+func (m *Manager) Verify(ctx context.Context, token string) (Claims, error) {
+	if token == "" {
+		return Claims{}, ErrMissing
+	}
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	valid := false
+	for _, key := range m.keys {
+		if hmac.Equal(signature, sign(key, encoded)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return Claims{}, ErrSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.SessionID == "" {
+		return Claims{}, ErrMalformed
+	}
+	if time.Now().UnixMilli() >= claims.ExpiresAt {
+		return Claims{}, ErrExpired
+	}
+	revoked, err := m.revocations.SessionRevoked(ctx, claims.SessionID, claims.ClientID, claims.PlayerID, time.UnixMilli(claims.IssuedAt))
+	if err != nil {
+		return Claims{}, err
+	}
+	if revoked {
+		return Claims{}, ErrRevoked
+	}
+	return claims, nil
+}
+
+// Revoke invalidates one session until it would have expired anyway
+func (m *Manager) Revoke(ctx context.Context, claims Claims) error {
+	return m.revocations.RevokeSession(ctx, claims.SessionID, claims.Expires())
+}
+
+// RevokePlayer invalidates every session issued so far for a player
+func (m *Manager) RevokePlayer(ctx context.Context, clientID, playerID string) error {
+	return m.revocations.RevokePlayerSessions(ctx, clientID, playerID, time.Now())
+}
+
+func sign(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
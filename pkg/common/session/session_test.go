@@ -0,0 +1,186 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/session"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+const (
+	keyA = "0123456789abcdef0123456789abcdef"
+	keyB = "fedcba9876543210fedcba9876543210"
+)
+
+func newManager(t *testing.T, st store.Store, ttl time.Duration, keys ...string) *session.Manager {
+	t.Helper()
+	m, err := session.NewManager(keys, ttl, store.SessionRevocations{Store: st})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+var player = session.Claims{OperatorID: "op-1", ClientID: "1001", PlayerID: "p1", GameID: "funkykingkong", Currency: "USD", Environment: "prod"}
+
+func TestIssueVerify(t *testing.T) {
+	m := newManager(t, store.NewMemory(), time.Hour, keyA)
+	token, issued, err := m.Issue(player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issued.SessionID == "" || issued.Expires().Sub(time.UnixMilli(issued.IssuedAt)) != time.Hour {
+		t.Fatalf("issued claims = %+v", issued)
+	}
+
+	verified, err := m.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified != issued {
+		t.Fatalf("Verify() = %+v, want %+v", verified, issued)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	ctx := context.Background()
+	m := newManager(t, store.NewMemory(), time.Hour, keyA)
+	token, _, err := m.Issue(player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig, _ := strings.Cut(token, ".")
+
+	// Swap the payload for another player's while keeping the signature
+	other := player
+	other.PlayerID = "p2"
+	otherToken, _, err := m.Issue(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPayload, _, _ := strings.Cut(otherToken, ".")
+	flipped := "A"
+	if sig[0] == 'A' {
+		flipped = "B"
+	}
+
+	expired, _, err := newManager(t, store.NewMemory(), -time.Second, keyA).Issue(player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, _, err := newManager(t, store.NewMemory(), time.Hour, keyB).Issue(player)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  error
+		code  string
+	}{
+		{"missing", "", session.ErrMissing, "session_missing"},
+		{"no signature", payload, session.ErrMalformed, "session_invalid"},
+		{"undecodable signature", payload + ".!!", session.ErrMalformed, "session_invalid"},
+		{"tampered payload", otherPayload + "." + sig, session.ErrSignature, "session_invalid"},
+		{"tampered signature", payload + "." + flipped + sig[1:], session.ErrSignature, "session_invalid"},
+		{"unknown key", foreign, session.ErrSignature, "session_invalid"},
+		{"expired", expired, session.ErrExpired, "session_expired"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Verify(ctx, tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() = %v, want %v", err, tt.want)
+			}
+			if !session.IsTokenError(err) || session.Code(err) != tt.code {
+				t.Errorf("IsTokenError = %v, Code = %q; want true, %q", session.IsTokenError(err), session.Code(err), tt.code)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	st := store.NewMemory()
+	old := newManager(t, st, time.Hour, keyA)
+	token, _, err := old.Issue(player)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keyB now signs; tokens signed with keyA stay valid while it is listed
+	rotated := newManager(t, st, time.Hour, keyB, keyA)
+	if _, err := rotated.Verify(context.Background(), token); err != nil {
+		t.Fatalf("token signed with the previous key: %v", err)
+	}
+	fresh, _, err := rotated.Issue(player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Verify(context.Background(), fresh); !errors.Is(err, session.ErrSignature) {
+		t.Fatalf("token signed with the new key verified by the old manager: %v", err)
+	}
+
+	retired := newManager(t, st, time.Hour, keyB)
+	if _, err := retired.Verify(context.Background(), token); !errors.Is(err, session.ErrSignature) {
+		t.Fatalf("token signed with a retired key: Verify() = %v, want ErrSignature", err)
+	}
+}
+
+func TestRevocation(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemory()
+	m := newManager(t, st, time.Hour, keyA)
+
+	first, firstClaims, _ := m.Issue(player)
+	second, _, _ := m.Issue(player)
+	other := player
+	other.PlayerID = "p2"
+	otherToken, _, _ := m.Issue(other)
+
+	if err := m.Revoke(ctx, firstClaims); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, first); !errors.Is(err, session.ErrRevoked) {
+		t.Fatalf("revoked session: Verify() = %v, want ErrRevoked", err)
+	}
+	if _, err := m.Verify(ctx, second); err != nil {
+		t.Fatalf("the player's other session: %v", err)
+	}
+
+	if err := m.RevokePlayer(ctx, player.ClientID, player.PlayerID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Verify(ctx, second); !errors.Is(err, session.ErrRevoked) {
+		t.Fatalf("session issued before RevokePlayer: Verify() = %v, want ErrRevoked", err)
+	}
+	if _, err := m.Verify(ctx, otherToken); err != nil {
+		t.Fatalf("another player's session: %v", err)
+	}
+
+	// Revocations are kept by the store, so a new manager still honours them,
+	// and sessions issued after RevokePlayer are valid
+	time.Sleep(2 * time.Millisecond)
+	restarted := newManager(t, st, time.Hour, keyA)
+	if _, err := restarted.Verify(ctx, second); !errors.Is(err, session.ErrRevoked) {
+		t.Fatalf("after restart: Verify() = %v, want ErrRevoked", err)
+	}
+	later, _, _ := restarted.Issue(player)
+	if _, err := restarted.Verify(ctx, later); err != nil {
+		t.Fatalf("session issued after RevokePlayer: %v", err)
+	}
+}
+
+func TestNewManagerKeys(t *testing.T) {
+	for name, keys := range map[string][]string{
+		"no keys":   nil,
+		"short key": {keyA, "short"},
+	} {
+		if _, err := session.NewManager(keys, time.Hour, store.SessionRevocations{Store: store.NewMemory()}); err == nil {
+			t.Errorf("%s: NewManager succeeded", name)
+		}
+	}
+}
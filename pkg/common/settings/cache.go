@@ -0,0 +1,279 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+)
+
+// Provider is anything that can look up a player's game settings
+type Provider interface {
+	GetSettings(ctx context.Context, clientID, gameID, playerID string) (Settings, error)
+}
+
+// CacheConfig controls how long settings are reused
+type CacheConfig struct {
+	TTL            time.Duration // how long an entry is served without revalidation
+	StaleTTL       time.Duration // how long past TTL an entry may be served while revalidating
+	RefreshTimeout time.Duration // deadline for a background revalidation
+	MaxFallbackAge time.Duration // how old a last known value may be to serve while the upstream fails; 0 for no fallback
+	MaxEntries     int           // entry count that triggers a sweep of dead entries
+}
+
+// Key identifies one cached settings lookup
+type Key struct {
+	ClientID string
+	GameID   string
+	PlayerID string
+}
+
+// CacheStats is a snapshot of the cache counters
+type CacheStats struct {
+	Hits          uint64 `json:"hits"`
+	StaleHits     uint64 `json:"stale_hits"`
+	Misses        uint64 `json:"misses"`
+	Refreshes     uint64 `json:"refreshes"`
+	RefreshErrors uint64 `json:"refresh_errors"`
+	Fallbacks     uint64 `json:"fallbacks"`
+	Entries       int    `json:"entries"`
+}
+
+type cacheEntry struct {
+	settings  Settings
+	fetchedAt time.Time
+}
+
+type flight struct {
+	done     chan struct{}
+	settings Settings
+	err      error
+}
+
+// Cache is a Provider that reuses upstream answers for a TTL. Concurrent
+// lookups for the same key share a single upstream fetch, and expired entries
+// keep being served for StaleTTL while they are revalidated in the background.
+// Past the stale window a failed fetch falls back to the last known value, up
+// to MaxFallbackAge old, so a short upstream outage only fails players the
+// cache has never seen. Entries too old for either are swept every minute.
+type Cache struct {
+	upstream Provider
+	cfg      CacheConfig
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[Key]cacheEntry
+	flights map[Key]*flight
+	swept   time.Time
+
+	hits          atomic.Uint64
+	staleHits     atomic.Uint64
+	misses        atomic.Uint64
+	refreshes     atomic.Uint64
+	refreshErrors atomic.Uint64
+	fallbacks     atomic.Uint64
+}
+
+// NewCache creates a cache in front of the upstream provider
+func NewCache(upstream Provider, cfg CacheConfig) *Cache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 100000
+	}
+	return &Cache{
+		upstream: upstream,
+		cfg:      cfg,
+		now:      time.Now,
+		entries:  make(map[Key]cacheEntry),
+		flights:  make(map[Key]*flight),
+	}
+}
+
+// GetSettings returns the cached settings for the player, fetching them when missing or too old
+func (c *Cache) GetSettings(ctx context.Context, clientID, gameID, playerID string) (Settings, error) {
+	key := Key{ClientID: clientID, GameID: gameID, PlayerID: playerID}
+
+	c.mu.Lock()
+	if c.now().Sub(c.swept) > cacheSweepPeriod {
+		c.sweep()
+	}
+	entry, ok := c.entries[key]
+	age := c.now().Sub(entry.fetchedAt)
+	if ok {
+		if age < c.cfg.TTL {
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return entry.settings, nil
+		}
+		if age < c.cfg.TTL+c.cfg.StaleTTL {
+			if _, running := c.flights[key]; !running {
+				go c.refresh(context.WithoutCancel(ctx), key, c.startFlight(key))
+			}
+			c.mu.Unlock()
+			c.staleHits.Add(1)
+			return entry.settings, nil
+		}
+	}
+	c.mu.Unlock()
+
+	c.misses.Add(1)
+	fetched, err := c.fetch(ctx, key)
+	if err != nil && ok && age < c.cfg.MaxFallbackAge && !isContextError(err) && !resilience.IsRejected(err) {
+		// The upstream is failing rather than refusing this player; a
+		// recent enough value beats failing the spin
+		c.fallbacks.Add(1)
+		slog.WarnContext(ctx, "Settings lookup failed, serving last known value",
+			slog.String("client_id", key.ClientID),
+			slog.String("game_id", key.GameID),
+			slog.String("player_id", key.PlayerID),
+			slog.Duration("age", age),
+			slog.Any("error", err),
+		)
+		return entry.settings, nil
+	}
+	return fetched, err
+}
+
+// fetch waits for the key's in-flight fetch, or starts one with the caller's context
+func (c *Cache) fetch(ctx context.Context, key Key) (Settings, error) {
+	for {
+		c.mu.Lock()
+		f, running := c.flights[key]
+		if !running {
+			f = c.startFlight(key)
+			c.mu.Unlock()
+			fetched, err := c.upstream.GetSettings(ctx, key.ClientID, key.GameID, key.PlayerID)
+			c.finishFlight(key, f, fetched, err)
+			return fetched, err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return Settings{}, ctx.Err()
+		}
+
+		// A leader that gave up says nothing about the upstream; try again
+		// under our own context while it is still live
+		if isContextError(f.err) && ctx.Err() == nil {
+			continue
+		}
+		return f.settings, f.err
+	}
+}
+
+// refresh revalidates a stale entry; failures leave the stale entry in place
+func (c *Cache) refresh(ctx context.Context, key Key, f *flight) {
+	if c.cfg.RefreshTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.RefreshTimeout)
+		defer cancel()
+	}
+
+	c.refreshes.Add(1)
+	fetched, err := c.upstream.GetSettings(ctx, key.ClientID, key.GameID, key.PlayerID)
+	if err != nil {
+		c.refreshErrors.Add(1)
+		slog.WarnContext(ctx, "Settings cache revalidation failed, serving stale value",
+			slog.String("client_id", key.ClientID),
+			slog.String("game_id", key.GameID),
+			slog.String("player_id", key.PlayerID),
+			slog.Any("error", err),
+		)
+	}
+	c.finishFlight(key, f, fetched, err)
+}
+
+// startFlight registers an in-flight fetch; callers must hold mu
+func (c *Cache) startFlight(key Key) *flight {
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	return f
+}
+
+// finishFlight publishes a fetch result and stores it on success
+func (c *Cache) finishFlight(key Key, f *flight, fetched Settings, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A flight orphaned by Invalidate may carry a value fetched before the
+	// invalidation, so only the current flight may store its result
+	if c.flights[key] == f {
+		delete(c.flights, key)
+		if err == nil {
+			if len(c.entries) >= c.cfg.MaxEntries {
+				c.sweep()
+			}
+			c.entries[key] = cacheEntry{settings: fetched, fetchedAt: c.now()}
+		}
+	}
+	f.settings, f.err = fetched, err
+	close(f.done)
+}
+
+// cacheSweepPeriod is how often entries too old to be served are dropped
+const cacheSweepPeriod = time.Minute
+
+// sweep drops entries that are too old to be served at all, even as a
+// fallback; callers must hold mu
+func (c *Cache) sweep() {
+	now := c.now()
+	c.swept = now
+	maxAge := max(c.cfg.TTL+c.cfg.StaleTTL, c.cfg.MaxFallbackAge)
+	for key, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= maxAge {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Invalidate drops every entry matching the given fields; empty fields match anything
+func (c *Cache) Invalidate(clientID, gameID, playerID string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matches := func(key Key) bool {
+		return (clientID == "" || key.ClientID == clientID) &&
+			(gameID == "" || key.GameID == gameID) &&
+			(playerID == "" || key.PlayerID == playerID)
+	}
+
+	removed := 0
+	for key := range c.entries {
+		if matches(key) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	for key := range c.flights {
+		if matches(key) {
+			delete(c.flights, key)
+		}
+	}
+	return removed
+}
+
+// Stats returns the current cache counters
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:          c.hits.Load(),
+		StaleHits:     c.staleHits.Load(),
+		Misses:        c.misses.Load(),
+		Refreshes:     c.refreshes.Load(),
+		RefreshErrors: c.refreshErrors.Load(),
+		Fallbacks:     c.fallbacks.Load(),
+		Entries:       entries,
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
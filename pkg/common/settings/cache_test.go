@@ -0,0 +1,247 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+)
+
+// fakeUpstream answers with RTP set to its current value, optionally blocking
+// every call until gate is closed
+type fakeUpstream struct {
+	calls atomic.Int32
+	gate  chan struct{}
+
+	mu  sync.Mutex
+	rtp float64
+	err error
+}
+
+func (u *fakeUpstream) GetSettings(ctx context.Context, clientID, gameID, playerID string) (Settings, error) {
+	u.calls.Add(1)
+	if u.gate != nil {
+		<-u.gate
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return Settings{RTP: u.rtp}, u.err
+}
+
+func (u *fakeUpstream) set(rtp float64, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.rtp, u.err = rtp, err
+}
+
+func newTestCache(u *fakeUpstream) (*Cache, *time.Time) {
+	now := time.Unix(0, 0)
+	c := NewCache(u, CacheConfig{TTL: time.Minute, StaleTTL: time.Minute, MaxFallbackAge: 10 * time.Minute})
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+// waitIdle waits for background revalidations to finish
+func waitIdle(t *testing.T, c *Cache) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		c.mu.Lock()
+		n := len(c.flights)
+		c.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("revalidation did not finish")
+}
+
+func TestCacheSingleFlight(t *testing.T) {
+	u := &fakeUpstream{rtp: 96, gate: make(chan struct{})}
+	c, _ := newTestCache(u)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make(chan float64, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := c.GetSettings(context.Background(), "c", "g", "p")
+			if err != nil {
+				t.Error(err)
+			}
+			results <- s.RTP
+		}()
+	}
+	for c.Stats().Misses < callers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(u.gate)
+	wg.Wait()
+	close(results)
+
+	if n := u.calls.Load(); n != 1 {
+		t.Errorf("upstream called %d times, want 1", n)
+	}
+	for rtp := range results {
+		if rtp != 96 {
+			t.Errorf("RTP = %v, want 96", rtp)
+		}
+	}
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	u := &fakeUpstream{rtp: 96}
+	c, now := newTestCache(u)
+	ctx := context.Background()
+
+	if _, err := c.GetSettings(ctx, "c", "g", "p"); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(30 * time.Second)
+	if s, _ := c.GetSettings(ctx, "c", "g", "p"); s.RTP != 96 || u.calls.Load() != 1 {
+		t.Fatalf("fresh lookup: RTP %v after %d calls, want 96 after 1", s.RTP, u.calls.Load())
+	}
+
+	// Past the TTL the old value is served while a refresh runs
+	u.set(94, nil)
+	*now = now.Add(time.Minute)
+	if s, _ := c.GetSettings(ctx, "c", "g", "p"); s.RTP != 96 {
+		t.Fatalf("stale lookup: RTP %v, want the stale 96", s.RTP)
+	}
+	waitIdle(t, c)
+	if s, _ := c.GetSettings(ctx, "c", "g", "p"); s.RTP != 94 {
+		t.Fatalf("after revalidation: RTP %v, want 94", s.RTP)
+	}
+
+	// A failed refresh keeps the stale value in place
+	u.set(0, errors.New("settings API call failed with status 502"))
+	*now = now.Add(90 * time.Second)
+	if s, err := c.GetSettings(ctx, "c", "g", "p"); err != nil || s.RTP != 94 {
+		t.Fatalf("stale lookup during outage = %v, %v; want 94", s.RTP, err)
+	}
+	waitIdle(t, c)
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.StaleHits != 2 || stats.Misses != 1 || stats.Refreshes != 2 || stats.RefreshErrors != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestCacheFallsBackPastStaleWindow(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		err      error
+		fallback bool
+	}{
+		{"upstream failure", errors.New("settings API call failed with status 503"), true},
+		{"breaker open", resilience.ErrCircuitOpen, true},
+		{"refused", resilience.Rejected(errors.New("settings API call failed with status 404")), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &fakeUpstream{rtp: 96}
+			c, now := newTestCache(u)
+			ctx := context.Background()
+			if _, err := c.GetSettings(ctx, "c", "g", "p"); err != nil {
+				t.Fatal(err)
+			}
+
+			u.set(0, tt.err)
+			*now = now.Add(3 * time.Minute)
+			s, err := c.GetSettings(ctx, "c", "g", "p")
+			if tt.fallback {
+				if err != nil || s.RTP != 96 {
+					t.Fatalf("GetSettings() = %v, %v; want the last known 96", s.RTP, err)
+				}
+				if n := c.Stats().Fallbacks; n != 1 {
+					t.Errorf("Fallbacks = %d, want 1", n)
+				}
+			} else if !errors.Is(err, tt.err) {
+				t.Fatalf("GetSettings() error = %v, want %v", err, tt.err)
+			}
+
+			// A player the cache has never seen still fails
+			if _, err := c.GetSettings(ctx, "c", "g", "other"); err == nil {
+				t.Error("GetSettings for an unseen player succeeded")
+			}
+		})
+	}
+}
+
+func TestCacheFallbackAge(t *testing.T) {
+	u := &fakeUpstream{rtp: 96}
+	c, now := newTestCache(u)
+	ctx := context.Background()
+	if _, err := c.GetSettings(ctx, "c", "g", "p"); err != nil {
+		t.Fatal(err)
+	}
+
+	outage := errors.New("settings API call failed with status 503")
+	u.set(0, outage)
+	*now = now.Add(10*time.Minute - time.Second)
+	if s, err := c.GetSettings(ctx, "c", "g", "p"); err != nil || s.RTP != 96 {
+		t.Fatalf("GetSettings() within the fallback age = %v, %v; want the last known 96", s.RTP, err)
+	}
+	*now = now.Add(time.Second)
+	if _, err := c.GetSettings(ctx, "c", "g", "p"); !errors.Is(err, outage) {
+		t.Errorf("GetSettings() past the fallback age error = %v, want %v", err, outage)
+	}
+}
+
+func TestCacheSweep(t *testing.T) {
+	u := &fakeUpstream{rtp: 96}
+	c, now := newTestCache(u)
+	ctx := context.Background()
+	if _, err := c.GetSettings(ctx, "c", "g", "old"); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(9 * time.Minute)
+	if _, err := c.GetSettings(ctx, "c", "g", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Well below MaxEntries, the entry past the fallback age still goes
+	*now = now.Add(time.Minute + time.Second)
+	if _, err := c.GetSettings(ctx, "c", "g", "new"); err != nil {
+		t.Fatal(err)
+	}
+	waitIdle(t, c)
+	if n := c.Stats().Entries; n != 1 {
+		t.Errorf("Entries = %d after the sweep, want 1", n)
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	u := &fakeUpstream{rtp: 96}
+	c, _ := newTestCache(u)
+	ctx := context.Background()
+	for _, player := range []string{"p1", "p2"} {
+		if _, err := c.GetSettings(ctx, "c", "g", player); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := c.Invalidate("", "", "p1"); n != 1 {
+		t.Fatalf("Invalidate(p1) removed %d entries, want 1", n)
+	}
+	u.set(94, nil)
+	if s, _ := c.GetSettings(ctx, "c", "g", "p1"); s.RTP != 94 {
+		t.Errorf("p1 RTP = %v after Invalidate, want the refetched 94", s.RTP)
+	}
+	if s, _ := c.GetSettings(ctx, "c", "g", "p2"); s.RTP != 96 {
+		t.Errorf("p2 RTP = %v, want the cached 96", s.RTP)
+	}
+
+	if n := c.Invalidate("c", "", ""); n != 2 {
+		t.Errorf("Invalidate(c) removed %d entries, want 2", n)
+	}
+	if n := c.Stats().Entries; n != 0 {
+		t.Errorf("Entries = %d after invalidating everything, want 0", n)
+	}
+}
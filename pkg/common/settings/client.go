@@ -1,88 +1,133 @@
 package settings
 
 import (
-    "bytes"
-    "encoding/json"
-    "errors"
-    "log"
-    "net/http"
-    "strconv"
-
-    "github.com/cenkalti/backoff/v4"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/cenkalti/backoff/v4"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/tracing"
 )
 
 // Client for game settings service
 type Client struct {
-    ServiceURL string
+	ServiceURL string
+	HTTPClient *http.Client
+	Policy     *resilience.Policy
 }
 
-// NewClient creates a new settings client
-func NewClient(serviceURL string) *Client {
-    return &Client{
-        ServiceURL: serviceURL,
-    }
+// NewClient creates a new settings client guarded by the given resilience policy
+func NewClient(serviceURL string, policy *resilience.Policy) *Client {
+	return &Client{
+		ServiceURL: serviceURL,
+		HTTPClient: &http.Client{},
+		Policy:     policy,
+	}
 }
 
 type Request struct {
-    ClientID string `json:"client_id"`
-    GameID   string `json:"game_id"`
-    PlayerID string `json:"player_id"`
+	ClientID string `json:"client_id"`
+	GameID   string `json:"game_id"`
+	PlayerID string `json:"player_id"`
 }
 
 type Response struct {
-    Data struct {
-        GameBets string `json:"game_bets"`
-        GameRTP  string `json:"game_rtp"`
-        GameWins string `json:"game_wins"`
-    } `json:"data"`
+	Data struct {
+		GameBets string `json:"game_bets"`
+		GameRTP  string `json:"game_rtp"`
+		GameWins string `json:"game_wins"`
+	} `json:"data"`
 }
 
-// GetRTP retrieves the RTP settings for a player with retry logic (Improvement #4)
-func (c *Client) GetRTP(clientID, gameID, playerID string) (float64, error) {
-    reqBody, err := json.Marshal(Request{
-        ClientID: clientID,
-        GameID:   gameID,
-        PlayerID: playerID,
-    })
-    if err != nil {
-        log.Printf("Error marshaling settings request: %v", err)
-        return 0, err
-    }
-
-    log.Printf("Settings request: %s", string(reqBody))
-
-    var settingsResp Response
-    operation := func() error {
-        resp, err := http.Post(c.ServiceURL, "application/json", bytes.NewBuffer(reqBody))
-        if err != nil {
-            log.Printf("Error calling settings API: %v", err)
-            return err
-        }
-        defer resp.Body.Close()
-
-        if resp.StatusCode != http.StatusOK {
-            log.Printf("Settings API returned non-200 status: %d", resp.StatusCode)
-            return errors.New("Settings API call failed")
-        }
-
-        if err := json.NewDecoder(resp.Body).Decode(&settingsResp); err != nil {
-            log.Printf("Error decoding settings response: %v", err)
-            return err
-        }
-
-        return nil
-    }
-
-    // Retry with exponential backoff
-    err = backoff.Retry(operation, backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 3))
-    if err != nil {
-        return 0, err
-    }
-
-    rtp, err := strconv.ParseFloat(settingsResp.Data.GameRTP, 64)
-    if err != nil {
-        log.Printf("Error parsing RTP value: %v", err)
-        return 0, err
-    }
-    return rtp, nil
-}
\ No newline at end of file
+// GetSettings retrieves the RTP, bet limits and maximum win for a player with
+// retry logic (Improvement #4). Cancelling ctx aborts the in-flight attempt and
+// stops any further retries.
+func (c *Client) GetSettings(ctx context.Context, clientID, gameID, playerID string) (_ Settings, err error) {
+	ctx, span := tracing.Start(ctx, "settings.GetSettings", attribute.String("game.id", gameID))
+	defer func() { tracing.End(span, err) }()
+
+	reqBody, err := json.Marshal(Request{
+		ClientID: clientID,
+		GameID:   gameID,
+		PlayerID: playerID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error marshaling settings request", slog.Any("error", err))
+		return Settings{}, err
+	}
+
+	slog.DebugContext(ctx, "Settings request")
+
+	var settingsResp Response
+	attempt := 0
+	call := func(ctx context.Context) (err error) {
+		attempt++
+		ctx, span := tracing.Start(ctx, "settings.attempt", attribute.Int("attempt", attempt))
+		defer func() { tracing.End(span, err) }()
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.ServiceURL, bytes.NewReader(reqBody))
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		tracing.InjectHTTP(ctx, httpReq)
+
+		resp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error calling settings API", slog.Int("attempt", attempt), slog.Any("error", err))
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			slog.ErrorContext(ctx, "Settings API returned non-200 status", slog.Int("attempt", attempt), slog.Int("status", resp.StatusCode))
+			err := fmt.Errorf("settings API call failed with status %d", resp.StatusCode)
+			if resilience.RefusedStatus(resp.StatusCode) {
+				return resilience.Rejected(err)
+			}
+			return err
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&settingsResp); err != nil {
+			slog.ErrorContext(ctx, "Error decoding settings response", slog.Int("attempt", attempt), slog.Any("error", err))
+			return err
+		}
+
+		return nil
+	}
+	operation := func() error {
+		err := c.Policy.Execute(ctx, call)
+		if resilience.IsUnavailable(err) {
+			// Retrying against an open breaker only delays the failure
+			slog.WarnContext(ctx, "Settings API call rejected", slog.Any("error", err))
+			return backoff.Permanent(err)
+		}
+		if ctx.Err() != nil {
+			return backoff.Permanent(ctx.Err())
+		}
+		if resilience.IsRejected(err) {
+			// The same request will be refused again
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+
+	// Retry with exponential backoff
+	err = backoff.Retry(operation, backoff.WithContext(backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 3), ctx))
+	if err != nil {
+		return Settings{}, err
+	}
+
+	parsed, err := settingsResp.parse()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error parsing settings response", slog.Any("error", err))
+		return Settings{}, err
+	}
+	return parsed, nil
+}
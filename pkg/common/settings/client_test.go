@@ -0,0 +1,74 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+)
+
+func TestGetSettingsStatusHandling(t *testing.T) {
+	for _, tt := range []struct {
+		status    int
+		wantCalls int32
+		wantState resilience.State
+	}{
+		{http.StatusBadRequest, 1, resilience.StateClosed},
+		{http.StatusNotFound, 1, resilience.StateClosed},
+		{http.StatusRequestTimeout, 4, resilience.StateOpen},
+		{http.StatusTooManyRequests, 4, resilience.StateOpen},
+		{http.StatusInternalServerError, 4, resilience.StateOpen},
+	} {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			policy := resilience.NewPolicy("settings", resilience.Config{FailureThreshold: 4, OpenTimeout: time.Minute})
+			c := NewClient(srv.URL, policy)
+			if _, err := c.GetSettings(context.Background(), "client", "game", "player"); err == nil {
+				t.Fatal("GetSettings succeeded")
+			}
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Errorf("settings API called %d times, want %d", n, tt.wantCalls)
+			}
+			if s := policy.State(); s != tt.wantState {
+				t.Errorf("breaker State() = %s, want %s", s, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestGetSettingsCancelStopsRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	policy := resilience.NewPolicy("settings", resilience.Config{FailureThreshold: 10, OpenTimeout: time.Minute})
+	c := NewClient(srv.URL, policy)
+	_, err := c.GetSettings(ctx, "client", "game", "player")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetSettings() = %v, want context.Canceled", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("settings API called %d times after cancellation, want 1", n)
+	}
+	if s := policy.State(); s != resilience.StateClosed {
+		t.Errorf("breaker State() = %s, want closed", s)
+	}
+}
@@ -0,0 +1,158 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Settings is the typed form of a settings service response
+type Settings struct {
+	RTP    float64   `json:"rtp"`
+	Bets   BetLimits `json:"bets"`
+	MaxWin float64   `json:"max_win"` // 0 means uncapped
+}
+
+// BetLimits restricts the bet amounts an operator accepts for a player.
+// Allowed takes precedence over the Min/Max range; an empty value allows every bet.
+type BetLimits struct {
+	Allowed []float64 `json:"allowed,omitempty"`
+	Min     float64   `json:"min,omitempty"`
+	Max     float64   `json:"max,omitempty"` // 0 means no upper bound
+}
+
+// betTolerance absorbs float noise when matching bets against the allowed list
+const betTolerance = 1e-9
+
+// Allows reports whether the bet amount is within the limits
+func (b BetLimits) Allows(amount float64) bool {
+	if len(b.Allowed) > 0 {
+		for _, allowed := range b.Allowed {
+			if math.Abs(allowed-amount) < betTolerance {
+				return true
+			}
+		}
+		return false
+	}
+	if amount < b.Min-betTolerance {
+		return false
+	}
+	if b.Max > 0 && amount > b.Max+betTolerance {
+		return false
+	}
+	return true
+}
+
+// CapWin limits a win to the configured maximum, reporting whether it was capped
+func (s Settings) CapWin(win float64) (float64, bool) {
+	if s.MaxWin > 0 && win > s.MaxWin {
+		return s.MaxWin, true
+	}
+	return win, false
+}
+
+// parse converts the raw string fields of a settings response
+func (r Response) parse() (Settings, error) {
+	rtp, err := strconv.ParseFloat(strings.TrimSpace(r.Data.GameRTP), 64)
+	if err != nil {
+		return Settings{}, fmt.Errorf("invalid game_rtp %q: %w", r.Data.GameRTP, err)
+	}
+
+	bets, err := parseBetLimits(r.Data.GameBets)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	maxWin, err := parseMaxWin(r.Data.GameWins)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	return Settings{RTP: rtp, Bets: bets, MaxWin: maxWin}, nil
+}
+
+// parseBetLimits accepts an empty string (no limits), a list such as
+// "0.1,0.2,0.5" or "[0.1,0.2,0.5]", or a range such as "0.01-10". Amounts
+// may use exponents, as in "1e-3", and must not be negative. Anything else is
+// an error rather than no limits, so a spin never runs without the operator's
+// limits.
+func parseBetLimits(raw string) (BetLimits, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return BetLimits{}, nil
+	}
+
+	var allowed []float64
+	switch {
+	case strings.HasPrefix(raw, "["):
+		if err := json.Unmarshal([]byte(raw), &allowed); err != nil {
+			return BetLimits{}, fmt.Errorf("invalid game_bets %q: %w", raw, err)
+		}
+
+	case !strings.Contains(raw, ","):
+		// A single amount, or a range
+		amount, err := strconv.ParseFloat(raw, 64)
+		if err == nil {
+			allowed = []float64{amount}
+			break
+		}
+		min, max, ok := splitRange(raw)
+		if !ok {
+			return BetLimits{}, fmt.Errorf("invalid game_bets %q: %w", raw, err)
+		}
+		if min < 0 {
+			return BetLimits{}, fmt.Errorf("invalid game_bets range %q: negative amount", raw)
+		}
+		if max < min {
+			return BetLimits{}, fmt.Errorf("invalid game_bets range %q: max below min", raw)
+		}
+		return BetLimits{Min: min, Max: max}, nil
+
+	default:
+		for _, field := range strings.Split(raw, ",") {
+			amount, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return BetLimits{}, fmt.Errorf("invalid game_bets %q: %w", raw, err)
+			}
+			allowed = append(allowed, amount)
+		}
+	}
+	for _, amount := range allowed {
+		if amount < 0 {
+			return BetLimits{}, fmt.Errorf("invalid game_bets %q: negative amount", raw)
+		}
+	}
+	return BetLimits{Allowed: allowed}, nil
+}
+
+// splitRange parses "<number>-<number>". The separator is the first dash
+// that leaves a number on both sides, so signs and exponents such as
+// "1e-3-2" are not taken for it.
+func splitRange(raw string) (low, high float64, ok bool) {
+	for i := 1; i < len(raw)-1; i++ {
+		if raw[i] != '-' {
+			continue
+		}
+		low, errLow := strconv.ParseFloat(strings.TrimSpace(raw[:i]), 64)
+		high, errHigh := strconv.ParseFloat(strings.TrimSpace(raw[i+1:]), 64)
+		if errLow == nil && errHigh == nil {
+			return low, high, true
+		}
+	}
+	return 0, 0, false
+}
+
+// parseMaxWin accepts an empty string or "0" (uncapped) or a positive amount
+func parseMaxWin(raw string) (float64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	maxWin, err := strconv.ParseFloat(raw, 64)
+	if err != nil || maxWin < 0 {
+		return 0, fmt.Errorf("invalid game_wins %q", raw)
+	}
+	return maxWin, nil
+}
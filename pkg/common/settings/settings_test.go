@@ -0,0 +1,96 @@
+package settings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBetLimits(t *testing.T) {
+	for _, tt := range []struct {
+		raw     string
+		want    BetLimits
+		wantErr bool
+	}{
+		{raw: "", want: BetLimits{}},
+		{raw: "0.1,0.2, 0.5", want: BetLimits{Allowed: []float64{0.1, 0.2, 0.5}}},
+		{raw: "[0.1,0.2,0.5]", want: BetLimits{Allowed: []float64{0.1, 0.2, 0.5}}},
+		{raw: "0.01-10", want: BetLimits{Min: 0.01, Max: 10}},
+		{raw: "0.01 - 10", want: BetLimits{Min: 0.01, Max: 10}},
+		{raw: "1e-3", want: BetLimits{Allowed: []float64{0.001}}},
+		{raw: "1e-3-2", want: BetLimits{Min: 0.001, Max: 2}},
+		{raw: "1e-3,0.5", want: BetLimits{Allowed: []float64{0.001, 0.5}}},
+		{raw: "5", want: BetLimits{Allowed: []float64{5}}},
+		{raw: "10-0.01", wantErr: true},
+		{raw: "-1", wantErr: true},
+		{raw: "-1-5", wantErr: true},
+		{raw: "[0.1,-0.2]", wantErr: true},
+		{raw: "1-", wantErr: true},
+		{raw: "0.1,abc", wantErr: true},
+		{raw: "[0.1,", wantErr: true},
+	} {
+		got, err := parseBetLimits(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBetLimits(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseBetLimits(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestBetLimitsAllows(t *testing.T) {
+	list := BetLimits{Allowed: []float64{0.1, 0.2}}
+	rng := BetLimits{Min: 0.5, Max: 10}
+	for _, tt := range []struct {
+		limits BetLimits
+		amount float64
+		want   bool
+	}{
+		{BetLimits{}, 1000, true},
+		{list, 0.1 + 0.2 - 0.1, true},
+		{list, 0.3, false},
+		{rng, 0.5, true},
+		{rng, 10, true},
+		{rng, 0.4, false},
+		{rng, 10.01, false},
+		{BetLimits{Min: 1}, 1e6, true},
+	} {
+		if got := tt.limits.Allows(tt.amount); got != tt.want {
+			t.Errorf("%+v.Allows(%v) = %v, want %v", tt.limits, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestResponseParse(t *testing.T) {
+	response := func(rtp, bets, wins string) Response {
+		var r Response
+		r.Data.GameRTP, r.Data.GameBets, r.Data.GameWins = rtp, bets, wins
+		return r
+	}
+
+	s, err := response(" 96.5 ", "1-5", "100").parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Settings{RTP: 96.5, Bets: BetLimits{Min: 1, Max: 5}, MaxWin: 100}
+	if !reflect.DeepEqual(s, want) {
+		t.Fatalf("parse() = %+v, want %+v", s, want)
+	}
+	if win, capped := s.CapWin(250); win != 100 || !capped {
+		t.Errorf("CapWin(250) = %v, %v; want 100, true", win, capped)
+	}
+	if win, capped := (Settings{}).CapWin(250); win != 250 || capped {
+		t.Errorf("uncapped CapWin(250) = %v, %v; want 250, false", win, capped)
+	}
+
+	for _, bad := range []Response{
+		response("", "", ""),
+		response("96", "x", ""),
+		response("96", "", "-1"),
+	} {
+		if _, err := bad.parse(); err == nil {
+			t.Errorf("parse() accepted %+v", bad.Data)
+		}
+	}
+}
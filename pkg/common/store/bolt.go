@@ -0,0 +1,446 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket            = []byte("meta")             // schema_version -> uint64
+	roundsBucket          = []byte("rounds")           // client \0 bet -> round JSON
+	playerBucket          = []byte("player_rounds")    // client \0 player \0 completed_at \0 bet -> nothing
+	ledgerBucket          = []byte("ledger")           // client \0 bet \0 sequence -> entry JSON
+	idempotencyBucket     = []byte("idempotency")      // key -> expiry, value
+	revokedSessionsBucket = []byte("revoked_sessions") // session ID -> expiry
+	revokedPlayersBucket  = []byte("revoked_players")  // client \0 player -> revocation time
+	roundStatesBucket     = []byte("round_states")     // client \0 bet -> round state JSON
+	openRoundsBucket      = []byte("open_rounds")      // client \0 player -> client \0 bet
+	settingsBucket        = []byte("settings")         // key -> value
+	playerStateBucket     = []byte("player_state")     // kind \0 player key -> expiry, value
+	auditBucket           = []byte("admin_audit")      // sequence -> audit entry JSON
+
+	schemaVersionKey = []byte("schema_version")
+)
+
+// boltMigrations bring a file up to the current schema; entry i moves it from
+// version i to i+1. Files written before versioning have the rounds buckets
+// and count as version 0.
+var boltMigrations = []func(tx *bolt.Tx) error{
+	func(tx *bolt.Tx) error {
+		return createBuckets(tx, roundsBucket, playerBucket)
+	},
+	func(tx *bolt.Tx) error {
+		return createBuckets(tx, ledgerBucket, idempotencyBucket, revokedSessionsBucket, revokedPlayersBucket)
+	},
+	func(tx *bolt.Tx) error {
+		return createBuckets(tx, roundStatesBucket, openRoundsBucket)
+	},
+	func(tx *bolt.Tx) error {
+		return createBuckets(tx, settingsBucket, auditBucket)
+	},
+	func(tx *bolt.Tx) error {
+		return createBuckets(tx, playerStateBucket)
+	},
+}
+
+// Bolt is a Store in a single embedded bbolt file, for single-node deployments
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens or creates the database file at path and migrates it
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(migrateBolt); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+	return &Bolt{db: db}, nil
+}
+
+func migrateBolt(tx *bolt.Tx) error {
+	meta, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+	var version uint64
+	if v := meta.Get(schemaVersionKey); v != nil {
+		version = binary.BigEndian.Uint64(v)
+	}
+	if version > uint64(len(boltMigrations)) {
+		return fmt.Errorf("schema version %d is newer than this build supports", version)
+	}
+	for ; version < uint64(len(boltMigrations)); version++ {
+		if err := boltMigrations[version](tx); err != nil {
+			return err
+		}
+	}
+	return meta.Put(schemaVersionKey, binary.BigEndian.AppendUint64(nil, version))
+}
+
+func createBuckets(tx *bolt.Tx, names ...[]byte) error {
+	for _, name := range names {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update runs fn in a bbolt read-write transaction
+func (s *Bolt) Update(ctx context.Context, fn func(Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// View runs fn in a bbolt read-only transaction
+func (s *Bolt) View(ctx context.Context, fn func(Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// Close closes the database file
+func (s *Bolt) Close() error {
+	return s.db.Close()
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t boltTx) write() error {
+	if !t.tx.Writable() {
+		return errReadOnly
+	}
+	return nil
+}
+
+func (t boltTx) InsertRound(round Round) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(round)
+	if err != nil {
+		return err
+	}
+	rounds := t.tx.Bucket(roundsBucket)
+	key := []byte(roundID(round.ClientID, round.BetID))
+	if rounds.Get(key) != nil {
+		return ErrDuplicate
+	}
+	if err := rounds.Put(key, data); err != nil {
+		return err
+	}
+	index := indexKey(round.ClientID, round.PlayerID, cursor{completed: round.CompletedAt.UnixNano(), betID: round.BetID})
+	return t.tx.Bucket(playerBucket).Put(index, nil)
+}
+
+func (t boltTx) GetRound(clientID, betID string) (Round, error) {
+	data := t.tx.Bucket(roundsBucket).Get([]byte(roundID(clientID, betID)))
+	if data == nil {
+		return Round{}, ErrNotFound
+	}
+	var round Round
+	err := json.Unmarshal(data, &round)
+	return round, err
+}
+
+// ListRounds walks the player's index from newest to oldest
+func (t boltTx) ListRounds(q RoundQuery) (RoundPage, error) {
+	limit := normalizedLimit(q.Limit)
+	prefix := playerPrefix(q.ClientID, q.PlayerID)
+
+	// Start just below the cursor, the upper date bound, or the end of the prefix
+	start := append(bytes.Clone(prefix), 0xff)
+	if !q.To.IsZero() {
+		start = binary.BigEndian.AppendUint64(bytes.Clone(prefix), uint64(q.To.UnixNano()))
+	}
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return RoundPage{}, err
+		}
+		if key := indexKey(q.ClientID, q.PlayerID, after); bytes.Compare(key, start) < 0 {
+			start = key
+		}
+	}
+
+	rounds := t.tx.Bucket(roundsBucket)
+	c := t.tx.Bucket(playerBucket).Cursor()
+	k, _ := c.Seek(start)
+	if k == nil {
+		k, _ = c.Last()
+	}
+	// Seek lands on the first key >= start; step back to the first key below it
+	for k != nil && bytes.Compare(k, start) >= 0 {
+		k, _ = c.Prev()
+	}
+
+	var matched []Round
+	for ; k != nil && bytes.HasPrefix(k, prefix) && len(matched) <= limit; k, _ = c.Prev() {
+		rest := k[len(prefix):]
+		completed := time.Unix(0, int64(binary.BigEndian.Uint64(rest[:8])))
+		if !q.From.IsZero() && completed.Before(q.From) {
+			break
+		}
+		var round Round
+		if err := json.Unmarshal(rounds.Get([]byte(roundID(q.ClientID, string(rest[9:])))), &round); err != nil {
+			return RoundPage{}, err
+		}
+		if q.matches(round) {
+			matched = append(matched, round)
+		}
+	}
+	return newPage(matched, limit), nil
+}
+
+func (t boltTx) AppendLedger(entry LedgerEntry) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	ledger := t.tx.Bucket(ledgerBucket)
+	seq, err := ledger.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := binary.BigEndian.AppendUint64([]byte(roundID(entry.ClientID, entry.BetID)+"\x00"), seq)
+	return ledger.Put(key, data)
+}
+
+func (t boltTx) Ledger(clientID, betID string) ([]LedgerEntry, error) {
+	prefix := []byte(roundID(clientID, betID) + "\x00")
+	var entries []LedgerEntry
+	c := t.tx.Bucket(ledgerBucket).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		var entry LedgerEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (t boltTx) PutIdempotent(key string, value []byte, expires time.Time) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	record := binary.BigEndian.AppendUint64(nil, uint64(expires.UnixNano()))
+	return t.tx.Bucket(idempotencyBucket).Put([]byte(key), append(record, value...))
+}
+
+func (t boltTx) GetIdempotent(key string, now time.Time) ([]byte, error) {
+	record := t.tx.Bucket(idempotencyBucket).Get([]byte(key))
+	if record == nil || now.UnixNano() >= int64(binary.BigEndian.Uint64(record[:8])) {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(record[8:]), nil
+}
+
+func (t boltTx) RevokeSession(sessionID string, expires time.Time) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	return t.tx.Bucket(revokedSessionsBucket).Put([]byte(sessionID), binary.BigEndian.AppendUint64(nil, uint64(expires.UnixNano())))
+}
+
+func (t boltTx) RevokePlayerSessions(clientID, playerID string, at time.Time) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	return t.tx.Bucket(revokedPlayersBucket).Put([]byte(roundID(clientID, playerID)), binary.BigEndian.AppendUint64(nil, uint64(at.UnixNano())))
+}
+
+func (t boltTx) SessionRevoked(sessionID, clientID, playerID string, issuedAt time.Time) (bool, error) {
+	if t.tx.Bucket(revokedSessionsBucket).Get([]byte(sessionID)) != nil {
+		return true, nil
+	}
+	at := t.tx.Bucket(revokedPlayersBucket).Get([]byte(roundID(clientID, playerID)))
+	return at != nil && issuedAt.UnixNano() <= int64(binary.BigEndian.Uint64(at)), nil
+}
+
+func (t boltTx) PutRoundState(state RoundState) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	key := []byte(roundID(state.Round.ClientID, state.Round.BetID))
+	if err := t.tx.Bucket(roundStatesBucket).Put(key, data); err != nil {
+		return err
+	}
+	open := t.tx.Bucket(openRoundsBucket)
+	player := []byte(roundID(state.Round.ClientID, state.Round.PlayerID))
+	if state.Open() {
+		return open.Put(player, key)
+	}
+	if bytes.Equal(open.Get(player), key) {
+		return open.Delete(player)
+	}
+	return nil
+}
+
+func (t boltTx) GetRoundState(clientID, betID string) (RoundState, error) {
+	return t.roundState([]byte(roundID(clientID, betID)))
+}
+
+func (t boltTx) OpenRound(clientID, playerID string) (RoundState, error) {
+	key := t.tx.Bucket(openRoundsBucket).Get([]byte(roundID(clientID, playerID)))
+	if key == nil {
+		return RoundState{}, ErrNotFound
+	}
+	return t.roundState(key)
+}
+
+func (t boltTx) OpenRounds() ([]RoundState, error) {
+	var open []RoundState
+	err := t.tx.Bucket(openRoundsBucket).ForEach(func(_, key []byte) error {
+		state, err := t.roundState(key)
+		open = append(open, state)
+		return err
+	})
+	sortOldestFirst(open)
+	return open, err
+}
+
+func (t boltTx) roundState(key []byte) (RoundState, error) {
+	data := t.tx.Bucket(roundStatesBucket).Get(key)
+	if data == nil {
+		return RoundState{}, ErrNotFound
+	}
+	var state RoundState
+	err := json.Unmarshal(data, &state)
+	return state, err
+}
+
+func (t boltTx) PutPlayerState(kind, playerKey string, value []byte, expires time.Time) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	record := binary.BigEndian.AppendUint64(nil, uint64(expiryNanos(expires)))
+	return t.tx.Bucket(playerStateBucket).Put([]byte(kind+"\x00"+playerKey), append(record, value...))
+}
+
+func (t boltTx) GetPlayerState(kind, playerKey string, now time.Time) ([]byte, error) {
+	record := t.tx.Bucket(playerStateBucket).Get([]byte(kind + "\x00" + playerKey))
+	if record == nil || now.UnixNano() >= int64(binary.BigEndian.Uint64(record[:8])) {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(record[8:]), nil
+}
+
+func (t boltTx) PutSetting(key string, value []byte) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	return t.tx.Bucket(settingsBucket).Put([]byte(key), value)
+}
+
+func (t boltTx) GetSetting(key string) ([]byte, error) {
+	value := t.tx.Bucket(settingsBucket).Get([]byte(key))
+	if value == nil {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(value), nil
+}
+
+func (t boltTx) AppendAudit(entry AuditEntry) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	audit := t.tx.Bucket(auditBucket)
+	seq, err := audit.NextSequence()
+	if err != nil {
+		return err
+	}
+	entry.ID = int64(seq)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return audit.Put(binary.BigEndian.AppendUint64(nil, seq), data)
+}
+
+// ListAudit walks the audit bucket back from before
+func (t boltTx) ListAudit(before int64, limit int) ([]AuditEntry, error) {
+	limit = normalizedLimit(limit)
+	c := t.tx.Bucket(auditBucket).Cursor()
+	k, v := c.Last()
+	if before > 0 {
+		if k, v = c.Seek(binary.BigEndian.AppendUint64(nil, uint64(before))); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+	entries := []AuditEntry{}
+	for ; k != nil && len(entries) < limit; k, v = c.Prev() {
+		var entry AuditEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (t boltTx) PurgeExpired(now time.Time) error {
+	if err := t.write(); err != nil {
+		return err
+	}
+	for _, name := range [][]byte{idempotencyBucket, revokedSessionsBucket, playerStateBucket} {
+		c := t.tx.Bucket(name).Cursor()
+		for k, v := c.First(); k != nil; {
+			if now.UnixNano() >= int64(binary.BigEndian.Uint64(v[:8])) {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				k, v = c.Seek(k)
+				continue
+			}
+			k, v = c.Next()
+		}
+	}
+	return nil
+}
+
+// expiryNanos stores a zero expiry as the largest time, which never passes
+func expiryNanos(expires time.Time) int64 {
+	if expires.IsZero() {
+		return math.MaxInt64
+	}
+	return expires.UnixNano()
+}
+
+func playerPrefix(clientID, playerID string) []byte {
+	return []byte(clientID + "\x00" + playerID + "\x00")
+}
+
+func indexKey(clientID, playerID string, at cursor) []byte {
+	key := binary.BigEndian.AppendUint64(playerPrefix(clientID, playerID), uint64(at.completed))
+	return append(append(key, 0), at.betID...)
+}
@@ -0,0 +1,367 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is a Store held in process memory, for tests and throwaway runs.
+// Transactions are serialised; a failed Update is rolled back with an undo log.
+type Memory struct {
+	mu              sync.RWMutex
+	rounds          map[string]Round
+	roundStates     map[string]RoundState
+	openRounds      map[string]string // client \0 player -> client \0 bet
+	ledger          map[string][]LedgerEntry
+	idempotent      map[string]idempotent
+	revokedSessions map[string]time.Time
+	revokedPlayers  map[string]time.Time
+	playerStates    map[string]idempotent // kind \0 player key; a zero expiry never expires
+	settings        map[string][]byte
+	audit           []AuditEntry
+}
+
+type idempotent struct {
+	value   []byte
+	expires time.Time
+}
+
+// expired reports whether the record has expired; player state with a zero
+// expiry never does
+func (rec idempotent) expired(now time.Time) bool {
+	return !rec.expires.IsZero() && !now.Before(rec.expires)
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{
+		rounds:          make(map[string]Round),
+		roundStates:     make(map[string]RoundState),
+		openRounds:      make(map[string]string),
+		ledger:          make(map[string][]LedgerEntry),
+		idempotent:      make(map[string]idempotent),
+		revokedSessions: make(map[string]time.Time),
+		revokedPlayers:  make(map[string]time.Time),
+		playerStates:    make(map[string]idempotent),
+		settings:        make(map[string][]byte),
+	}
+}
+
+// Update runs fn under the write lock, undoing its writes if it fails
+func (m *Memory) Update(ctx context.Context, fn func(Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &memoryTx{m: m, writable: true}
+	if err := fn(tx); err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+		return err
+	}
+	return nil
+}
+
+// View runs fn under the read lock
+func (m *Memory) View(ctx context.Context, fn func(Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fn(&memoryTx{m: m})
+}
+
+// Close does nothing; the data goes away with the process
+func (m *Memory) Close() error {
+	return nil
+}
+
+type memoryTx struct {
+	m        *Memory
+	writable bool
+	undo     []func()
+}
+
+// restore returns a function putting back the current value of key in a map
+func restore[V any](data map[string]V, key string) func() {
+	old, existed := data[key]
+	return func() {
+		if existed {
+			data[key] = old
+		} else {
+			delete(data, key)
+		}
+	}
+}
+
+func (tx *memoryTx) write() error {
+	if !tx.writable {
+		return errReadOnly
+	}
+	return nil
+}
+
+func (tx *memoryTx) InsertRound(round Round) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	key := roundID(round.ClientID, round.BetID)
+	if _, ok := tx.m.rounds[key]; ok {
+		return ErrDuplicate
+	}
+	tx.undo = append(tx.undo, restore(tx.m.rounds, key))
+	round.Reels = slices.Clone(round.Reels)
+	tx.m.rounds[key] = round
+	return nil
+}
+
+func (tx *memoryTx) GetRound(clientID, betID string) (Round, error) {
+	round, ok := tx.m.rounds[roundID(clientID, betID)]
+	if !ok {
+		return Round{}, ErrNotFound
+	}
+	return round, nil
+}
+
+func (tx *memoryTx) ListRounds(q RoundQuery) (RoundPage, error) {
+	var after cursor
+	if q.Cursor != "" {
+		var err error
+		if after, err = decodeCursor(q.Cursor); err != nil {
+			return RoundPage{}, err
+		}
+	}
+
+	var matched []Round
+	for _, r := range tx.m.rounds {
+		if r.ClientID != q.ClientID || r.PlayerID != q.PlayerID || !q.matches(r) {
+			continue
+		}
+		if q.Cursor != "" && !after.before(r.CompletedAt.UnixNano(), r.BetID) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	slices.SortFunc(matched, func(a, b Round) int {
+		if c := b.CompletedAt.Compare(a.CompletedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.BetID, a.BetID)
+	})
+	return newPage(matched, normalizedLimit(q.Limit)), nil
+}
+
+func (tx *memoryTx) AppendLedger(entry LedgerEntry) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	key := roundID(entry.ClientID, entry.BetID)
+	tx.undo = append(tx.undo, restore(tx.m.ledger, key))
+	tx.m.ledger[key] = append(slices.Clone(tx.m.ledger[key]), entry)
+	return nil
+}
+
+func (tx *memoryTx) Ledger(clientID, betID string) ([]LedgerEntry, error) {
+	return slices.Clone(tx.m.ledger[roundID(clientID, betID)]), nil
+}
+
+func (tx *memoryTx) PutIdempotent(key string, value []byte, expires time.Time) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, restore(tx.m.idempotent, key))
+	tx.m.idempotent[key] = idempotent{value: slices.Clone(value), expires: expires}
+	return nil
+}
+
+func (tx *memoryTx) GetIdempotent(key string, now time.Time) ([]byte, error) {
+	rec, ok := tx.m.idempotent[key]
+	if !ok || !now.Before(rec.expires) {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(rec.value), nil
+}
+
+func (tx *memoryTx) RevokeSession(sessionID string, expires time.Time) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, restore(tx.m.revokedSessions, sessionID))
+	tx.m.revokedSessions[sessionID] = expires
+	return nil
+}
+
+func (tx *memoryTx) RevokePlayerSessions(clientID, playerID string, at time.Time) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	key := roundID(clientID, playerID)
+	tx.undo = append(tx.undo, restore(tx.m.revokedPlayers, key))
+	tx.m.revokedPlayers[key] = at
+	return nil
+}
+
+func (tx *memoryTx) SessionRevoked(sessionID, clientID, playerID string, issuedAt time.Time) (bool, error) {
+	if _, ok := tx.m.revokedSessions[sessionID]; ok {
+		return true, nil
+	}
+	at, ok := tx.m.revokedPlayers[roundID(clientID, playerID)]
+	return ok && !issuedAt.After(at), nil
+}
+
+func (tx *memoryTx) PutRoundState(state RoundState) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	key := roundID(state.Round.ClientID, state.Round.BetID)
+	player := roundID(state.Round.ClientID, state.Round.PlayerID)
+	tx.undo = append(tx.undo, restore(tx.m.roundStates, key), restore(tx.m.openRounds, player))
+	tx.m.roundStates[key] = cloneState(state)
+	if state.Open() {
+		tx.m.openRounds[player] = key
+	} else if tx.m.openRounds[player] == key {
+		delete(tx.m.openRounds, player)
+	}
+	return nil
+}
+
+func (tx *memoryTx) GetRoundState(clientID, betID string) (RoundState, error) {
+	state, ok := tx.m.roundStates[roundID(clientID, betID)]
+	if !ok {
+		return RoundState{}, ErrNotFound
+	}
+	return cloneState(state), nil
+}
+
+func (tx *memoryTx) OpenRound(clientID, playerID string) (RoundState, error) {
+	key, ok := tx.m.openRounds[roundID(clientID, playerID)]
+	if !ok {
+		return RoundState{}, ErrNotFound
+	}
+	return cloneState(tx.m.roundStates[key]), nil
+}
+
+func (tx *memoryTx) OpenRounds() ([]RoundState, error) {
+	var open []RoundState
+	for _, key := range tx.m.openRounds {
+		open = append(open, cloneState(tx.m.roundStates[key]))
+	}
+	sortOldestFirst(open)
+	return open, nil
+}
+
+func (tx *memoryTx) PutPlayerState(kind, playerKey string, value []byte, expires time.Time) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	key := kind + "\x00" + playerKey
+	tx.undo = append(tx.undo, restore(tx.m.playerStates, key))
+	tx.m.playerStates[key] = idempotent{value: slices.Clone(value), expires: expires}
+	return nil
+}
+
+func (tx *memoryTx) GetPlayerState(kind, playerKey string, now time.Time) ([]byte, error) {
+	rec, ok := tx.m.playerStates[kind+"\x00"+playerKey]
+	if !ok || rec.expired(now) {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(rec.value), nil
+}
+
+func (tx *memoryTx) PutSetting(key string, value []byte) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, restore(tx.m.settings, key))
+	tx.m.settings[key] = slices.Clone(value)
+	return nil
+}
+
+func (tx *memoryTx) GetSetting(key string) ([]byte, error) {
+	value, ok := tx.m.settings[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(value), nil
+}
+
+func (tx *memoryTx) AppendAudit(entry AuditEntry) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	n := len(tx.m.audit)
+	tx.undo = append(tx.undo, func() { tx.m.audit = tx.m.audit[:n] })
+	entry.ID = int64(n + 1)
+	tx.m.audit = append(tx.m.audit, entry)
+	return nil
+}
+
+func (tx *memoryTx) ListAudit(before int64, limit int) ([]AuditEntry, error) {
+	limit = normalizedLimit(limit)
+	end := int64(len(tx.m.audit))
+	if before > 0 && before-1 < end {
+		end = before - 1
+	}
+	entries := []AuditEntry{}
+	for i := end - 1; i >= 0 && len(entries) < limit; i-- {
+		entries = append(entries, tx.m.audit[i])
+	}
+	return entries, nil
+}
+
+func (tx *memoryTx) PurgeExpired(now time.Time) error {
+	if err := tx.write(); err != nil {
+		return err
+	}
+	for key, rec := range tx.m.idempotent {
+		if !now.Before(rec.expires) {
+			tx.undo = append(tx.undo, restore(tx.m.idempotent, key))
+			delete(tx.m.idempotent, key)
+		}
+	}
+	for key, rec := range tx.m.playerStates {
+		if rec.expired(now) {
+			tx.undo = append(tx.undo, restore(tx.m.playerStates, key))
+			delete(tx.m.playerStates, key)
+		}
+	}
+	for id, expires := range tx.m.revokedSessions {
+		if now.After(expires) {
+			tx.undo = append(tx.undo, restore(tx.m.revokedSessions, id))
+			delete(tx.m.revokedSessions, id)
+		}
+	}
+	return nil
+}
+
+// roundID joins a client ID with a bet or player ID
+func roundID(clientID, id string) string {
+	return clientID + "\x00" + id
+}
+
+// cloneState copies the slices of a round state so callers cannot alias the store
+func cloneState(state RoundState) RoundState {
+	state.Round.Reels = slices.Clone(state.Round.Reels)
+	state.Transitions = slices.Clone(state.Transitions)
+	state.RNGResponse = slices.Clone(state.RNGResponse)
+	return state
+}
+
+// newPage cuts a newest-first list of matching rounds into a page
+func newPage(matched []Round, limit int) RoundPage {
+	page := RoundPage{Rounds: []Round{}}
+	if len(matched) > limit {
+		last := matched[limit-1]
+		page.NextCursor = cursor{completed: last.CompletedAt.UnixNano(), betID: last.BetID}.encode()
+		matched = matched[:limit]
+	}
+	page.Rounds = append(page.Rounds, matched...)
+	return page
+}
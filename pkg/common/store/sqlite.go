@@ -0,0 +1,455 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// sqlMigrations are applied in order and recorded in schema_migrations;
+// entry i creates version i+1. Never edit an entry once released, append a new one.
+var sqlMigrations = []string{
+	`CREATE TABLE rounds (
+		client_id    TEXT    NOT NULL,
+		bet_id       TEXT    NOT NULL,
+		player_id    TEXT    NOT NULL,
+		bet_level    INTEGER NOT NULL,
+		completed_at INTEGER NOT NULL,
+		data         TEXT    NOT NULL,
+		PRIMARY KEY (client_id, bet_id)
+	);
+	CREATE INDEX rounds_by_player ON rounds (client_id, player_id, completed_at DESC, bet_id DESC);`,
+
+	`CREATE TABLE ledger (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		client_id  TEXT    NOT NULL,
+		bet_id     TEXT    NOT NULL,
+		data       TEXT    NOT NULL
+	);
+	CREATE INDEX ledger_by_bet ON ledger (client_id, bet_id, id);
+	CREATE TABLE idempotency (
+		key        TEXT    PRIMARY KEY,
+		value      BLOB    NOT NULL,
+		expires_at INTEGER NOT NULL
+	);
+	CREATE TABLE revoked_sessions (
+		session_id TEXT    PRIMARY KEY,
+		expires_at INTEGER NOT NULL
+	);
+	CREATE TABLE revoked_players (
+		client_id  TEXT    NOT NULL,
+		player_id  TEXT    NOT NULL,
+		revoked_at INTEGER NOT NULL,
+		PRIMARY KEY (client_id, player_id)
+	);`,
+
+	`CREATE TABLE round_states (
+		client_id  TEXT    NOT NULL,
+		bet_id     TEXT    NOT NULL,
+		player_id  TEXT    NOT NULL,
+		state      TEXT    NOT NULL,
+		started_at INTEGER NOT NULL,
+		data       TEXT    NOT NULL,
+		PRIMARY KEY (client_id, bet_id)
+	);
+	CREATE INDEX open_rounds_by_player ON round_states (client_id, player_id) WHERE state NOT IN ('settled', 'voided');`,
+
+	`CREATE TABLE settings (
+		key   TEXT PRIMARY KEY,
+		value BLOB NOT NULL
+	);
+	CREATE TABLE admin_audit (
+		id   INTEGER PRIMARY KEY AUTOINCREMENT,
+		data TEXT    NOT NULL
+	);`,
+
+	`CREATE TABLE player_state (
+		kind       TEXT    NOT NULL,
+		player_key TEXT    NOT NULL,
+		value      BLOB    NOT NULL,
+		expires_at INTEGER NOT NULL,
+		PRIMARY KEY (kind, player_key)
+	);`,
+}
+
+// SQL is a Store on a SQL database. It is opened on SQLite, which suits a
+// single node and tests against a local file.
+type SQL struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates the SQLite database at path and migrates it
+func OpenSQLite(path string) (*SQL, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer; a single connection keeps transactions serialised
+	db.SetMaxOpenConns(1)
+
+	s := &SQL{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *SQL) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return err
+	}
+	var version int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(sqlMigrations) {
+		return fmt.Errorf("schema version %d is newer than this build supports", version)
+	}
+	for ; version < len(sqlMigrations); version++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, sqlMigrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version+1, time.Now().Unix()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update runs fn in a database transaction
+func (s *SQL) Update(ctx context.Context, fn func(Tx) error) error {
+	return s.run(ctx, fn, false)
+}
+
+// View runs fn in a read-only database transaction
+func (s *SQL) View(ctx context.Context, fn func(Tx) error) error {
+	return s.run(ctx, fn, true)
+}
+
+func (s *SQL) run(ctx context.Context, fn func(Tx) error, readOnly bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(sqlTx{ctx: ctx, tx: tx, readOnly: readOnly}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if readOnly {
+		return tx.Rollback()
+	}
+	return tx.Commit()
+}
+
+// Close closes the database
+func (s *SQL) Close() error {
+	return s.db.Close()
+}
+
+type sqlTx struct {
+	ctx      context.Context
+	tx       *sql.Tx
+	readOnly bool
+}
+
+func (t sqlTx) exec(query string, args ...any) error {
+	if t.readOnly {
+		return errReadOnly
+	}
+	_, err := t.tx.ExecContext(t.ctx, query, args...)
+	return err
+}
+
+func (t sqlTx) InsertRound(round Round) error {
+	data, err := json.Marshal(round)
+	if err != nil {
+		return err
+	}
+	err = t.exec(`INSERT INTO rounds (client_id, bet_id, player_id, bet_level, completed_at, data) VALUES (?, ?, ?, ?, ?, ?)`,
+		round.ClientID, round.BetID, round.PlayerID, round.BetLevel, round.CompletedAt.UnixNano(), data)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (t sqlTx) GetRound(clientID, betID string) (Round, error) {
+	var data []byte
+	err := t.tx.QueryRowContext(t.ctx, `SELECT data FROM rounds WHERE client_id = ? AND bet_id = ?`, clientID, betID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Round{}, ErrNotFound
+	}
+	if err != nil {
+		return Round{}, err
+	}
+	var round Round
+	err = json.Unmarshal(data, &round)
+	return round, err
+}
+
+func (t sqlTx) ListRounds(q RoundQuery) (RoundPage, error) {
+	limit := normalizedLimit(q.Limit)
+	query := `SELECT data FROM rounds WHERE client_id = ? AND player_id = ?`
+	args := []any{q.ClientID, q.PlayerID}
+	if !q.From.IsZero() {
+		query += ` AND completed_at >= ?`
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		query += ` AND completed_at < ?`
+		args = append(args, q.To.UnixNano())
+	}
+	if q.Level != 0 {
+		query += ` AND bet_level = ?`
+		args = append(args, q.Level)
+	}
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return RoundPage{}, err
+		}
+		query += ` AND (completed_at < ? OR (completed_at = ? AND bet_id < ?))`
+		args = append(args, after.completed, after.completed, after.betID)
+	}
+	query += ` ORDER BY completed_at DESC, bet_id DESC LIMIT ?`
+	args = append(args, limit+1)
+
+	rows, err := t.tx.QueryContext(t.ctx, query, args...)
+	if err != nil {
+		return RoundPage{}, err
+	}
+	defer rows.Close()
+
+	var matched []Round
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return RoundPage{}, err
+		}
+		var round Round
+		if err := json.Unmarshal(data, &round); err != nil {
+			return RoundPage{}, err
+		}
+		matched = append(matched, round)
+	}
+	if err := rows.Err(); err != nil {
+		return RoundPage{}, err
+	}
+	return newPage(matched, limit), nil
+}
+
+func (t sqlTx) AppendLedger(entry LedgerEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return t.exec(`INSERT INTO ledger (client_id, bet_id, data) VALUES (?, ?, ?)`, entry.ClientID, entry.BetID, data)
+}
+
+func (t sqlTx) Ledger(clientID, betID string) ([]LedgerEntry, error) {
+	rows, err := t.tx.QueryContext(t.ctx, `SELECT data FROM ledger WHERE client_id = ? AND bet_id = ? ORDER BY id`, clientID, betID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []LedgerEntry
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var entry LedgerEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (t sqlTx) PutIdempotent(key string, value []byte, expires time.Time) error {
+	return t.exec(`INSERT INTO idempotency (key, value, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+		key, value, expires.UnixNano())
+}
+
+func (t sqlTx) GetIdempotent(key string, now time.Time) ([]byte, error) {
+	var value []byte
+	err := t.tx.QueryRowContext(t.ctx, `SELECT value FROM idempotency WHERE key = ? AND expires_at > ?`, key, now.UnixNano()).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (t sqlTx) RevokeSession(sessionID string, expires time.Time) error {
+	return t.exec(`INSERT INTO revoked_sessions (session_id, expires_at) VALUES (?, ?)
+		ON CONFLICT (session_id) DO UPDATE SET expires_at = excluded.expires_at`,
+		sessionID, expires.UnixNano())
+}
+
+func (t sqlTx) RevokePlayerSessions(clientID, playerID string, at time.Time) error {
+	return t.exec(`INSERT INTO revoked_players (client_id, player_id, revoked_at) VALUES (?, ?, ?)
+		ON CONFLICT (client_id, player_id) DO UPDATE SET revoked_at = excluded.revoked_at`,
+		clientID, playerID, at.UnixNano())
+}
+
+func (t sqlTx) SessionRevoked(sessionID, clientID, playerID string, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := t.tx.QueryRowContext(t.ctx, `SELECT
+		EXISTS (SELECT 1 FROM revoked_sessions WHERE session_id = ?) OR
+		EXISTS (SELECT 1 FROM revoked_players WHERE client_id = ? AND player_id = ? AND revoked_at >= ?)`,
+		sessionID, clientID, playerID, issuedAt.UnixNano()).Scan(&revoked)
+	return revoked, err
+}
+
+func (t sqlTx) PutRoundState(state RoundState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return t.exec(`INSERT INTO round_states (client_id, bet_id, player_id, state, started_at, data) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (client_id, bet_id) DO UPDATE SET state = excluded.state, data = excluded.data`,
+		state.Round.ClientID, state.Round.BetID, state.Round.PlayerID, state.State, state.Round.StartedAt.UnixNano(), data)
+}
+
+func (t sqlTx) GetRoundState(clientID, betID string) (RoundState, error) {
+	return t.roundState(`SELECT data FROM round_states WHERE client_id = ? AND bet_id = ?`, clientID, betID)
+}
+
+func (t sqlTx) OpenRound(clientID, playerID string) (RoundState, error) {
+	return t.roundState(`SELECT data FROM round_states WHERE client_id = ? AND player_id = ? AND state NOT IN ('settled', 'voided')
+		ORDER BY started_at DESC LIMIT 1`, clientID, playerID)
+}
+
+func (t sqlTx) OpenRounds() ([]RoundState, error) {
+	rows, err := t.tx.QueryContext(t.ctx, `SELECT data FROM round_states WHERE state NOT IN ('settled', 'voided') ORDER BY started_at, bet_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var open []RoundState
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var state RoundState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, err
+		}
+		open = append(open, state)
+	}
+	return open, rows.Err()
+}
+
+func (t sqlTx) roundState(query string, args ...any) (RoundState, error) {
+	var data []byte
+	err := t.tx.QueryRowContext(t.ctx, query, args...).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RoundState{}, ErrNotFound
+	}
+	if err != nil {
+		return RoundState{}, err
+	}
+	var state RoundState
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+func (t sqlTx) PutPlayerState(kind, playerKey string, value []byte, expires time.Time) error {
+	return t.exec(`INSERT INTO player_state (kind, player_key, value, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (kind, player_key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+		kind, playerKey, value, expiryNanos(expires))
+}
+
+func (t sqlTx) GetPlayerState(kind, playerKey string, now time.Time) ([]byte, error) {
+	var value []byte
+	err := t.tx.QueryRowContext(t.ctx, `SELECT value FROM player_state WHERE kind = ? AND player_key = ? AND expires_at > ?`,
+		kind, playerKey, now.UnixNano()).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (t sqlTx) PutSetting(key string, value []byte) error {
+	return t.exec(`INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`, key, value)
+}
+
+func (t sqlTx) GetSetting(key string) ([]byte, error) {
+	var value []byte
+	err := t.tx.QueryRowContext(t.ctx, `SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (t sqlTx) AppendAudit(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return t.exec(`INSERT INTO admin_audit (data) VALUES (?)`, data)
+}
+
+func (t sqlTx) ListAudit(before int64, limit int) ([]AuditEntry, error) {
+	if before <= 0 {
+		before = math.MaxInt64
+	}
+	rows, err := t.tx.QueryContext(t.ctx, `SELECT id, data FROM admin_audit WHERE id < ? ORDER BY id DESC LIMIT ?`, before, normalizedLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var id int64
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+		entry.ID = id
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (t sqlTx) PurgeExpired(now time.Time) error {
+	if err := t.exec(`DELETE FROM idempotency WHERE expires_at <= ?`, now.UnixNano()); err != nil {
+		return err
+	}
+	if err := t.exec(`DELETE FROM player_state WHERE expires_at <= ?`, now.UnixNano()); err != nil {
+		return err
+	}
+	return t.exec(`DELETE FROM revoked_sessions WHERE expires_at < ?`, now.UnixNano())
+}
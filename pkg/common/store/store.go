@@ -0,0 +1,302 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors shared by every backend
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrDuplicate     = errors.New("already exists")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// errReadOnly is returned by writes attempted inside View
+var errReadOnly = errors.New("write in a read-only transaction")
+
+// Store is a transactional store for rounds and their lifecycle, ledger
+// entries, idempotent responses, session revocations, per-player state,
+// runtime settings and the admin audit trail. Every backend runs its schema migrations when it is opened.
+type Store interface {
+	// Update runs fn in a read-write transaction, committed only when fn returns nil
+	Update(ctx context.Context, fn func(Tx) error) error
+	// View runs fn in a read-only transaction
+	View(ctx context.Context, fn func(Tx) error) error
+	Close() error
+}
+
+// Tx is the set of operations available inside a transaction
+type Tx interface {
+	// InsertRound records a round; a second round for the same client and bet fails with ErrDuplicate
+	InsertRound(round Round) error
+	GetRound(clientID, betID string) (Round, error)
+	ListRounds(q RoundQuery) (RoundPage, error)
+
+	// AppendLedger records a debit or credit
+	AppendLedger(entry LedgerEntry) error
+	// Ledger returns the entries of a bet in the order they were written
+	Ledger(clientID, betID string) ([]LedgerEntry, error)
+
+	// PutIdempotent stores the response to replay for key until expires
+	PutIdempotent(key string, value []byte, expires time.Time) error
+	// GetIdempotent returns the stored response, or ErrNotFound once it has expired
+	GetIdempotent(key string, now time.Time) ([]byte, error)
+
+	// RevokeSession revokes one session until it would have expired anyway
+	RevokeSession(sessionID string, expires time.Time) error
+	// RevokePlayerSessions revokes every session of a player issued up to at
+	RevokePlayerSessions(clientID, playerID string, at time.Time) error
+	// SessionRevoked reports whether a session was revoked, alone or with its player's
+	SessionRevoked(sessionID, clientID, playerID string, issuedAt time.Time) (bool, error)
+
+	// PutRoundState writes the lifecycle state of a round, replacing the previous one
+	PutRoundState(state RoundState) error
+	GetRoundState(clientID, betID string) (RoundState, error)
+	// OpenRound returns the player's round that is neither settled nor voided, or ErrNotFound
+	OpenRound(clientID, playerID string) (RoundState, error)
+	// OpenRounds returns every round that is neither settled nor voided, oldest first
+	OpenRounds() ([]RoundState, error)
+
+	// PutPlayerState stores state of the given kind for a player, replacing the
+	// previous value, until expires; a zero expires keeps it until replaced
+	PutPlayerState(kind, playerKey string, value []byte, expires time.Time) error
+	// GetPlayerState returns stored player state, or ErrNotFound once it has expired
+	GetPlayerState(kind, playerKey string, now time.Time) ([]byte, error)
+
+	// PutSetting stores a named runtime setting, replacing the previous value
+	PutSetting(key string, value []byte) error
+	// GetSetting returns a stored setting, or ErrNotFound
+	GetSetting(key string) ([]byte, error)
+
+	// AppendAudit records an administrative change; the store assigns its ID
+	AppendAudit(entry AuditEntry) error
+	// ListAudit returns up to limit audit entries with an ID below before (0
+	// for no bound), newest first
+	ListAudit(before int64, limit int) ([]AuditEntry, error)
+
+	// PurgeExpired deletes idempotent responses, revocations and player state that no longer matter
+	PurgeExpired(now time.Time) error
+}
+
+// Open opens a store. driver is "memory", "bolt" (an embedded file) or
+// "sqlite"; dsn is the file path for the last two.
+func Open(driver, dsn string) (Store, error) {
+	switch driver {
+	case "memory":
+		return NewMemory(), nil
+	case "bolt":
+		return OpenBolt(dsn)
+	case "sqlite":
+		return OpenSQLite(dsn)
+	default:
+		return nil, fmt.Errorf("unknown store driver %q", driver)
+	}
+}
+
+// Round is one completed spin as recorded for history and support
+type Round struct {
+	BetID             string    `json:"bet_id"`
+	ClientID          string    `json:"client_id"`
+	PlayerID          string    `json:"player_id"`
+	OperatorID        string    `json:"operator_id,omitempty"`
+	GameID            string    `json:"game_id"`
+	Environment       string    `json:"environment"`
+	SessionID         string    `json:"session_id,omitempty"`
+	RequestID         string    `json:"request_id,omitempty"`
+	DefinitionVersion string    `json:"definition_version"`
+	BetLevel          int       `json:"bet_level"`
+	BetAmount         float64   `json:"bet_amount"`
+	Currency          string    `json:"currency,omitempty"`
+	Outcome           string    `json:"outcome"` // win or loss, as decided by the RNG
+	Reels             []string  `json:"reels"`
+	Combination       string    `json:"winning_combination"`
+	WinAmount         float64   `json:"win_amount"`
+	WinCapped         bool      `json:"win_capped,omitempty"`
+	BalanceAfter      *float64  `json:"balance_after,omitempty"` // only on rounds reconciliation credited, when the wallet reports it
+	StartedAt         time.Time `json:"started_at"`
+	CompletedAt       time.Time `json:"completed_at"`
+}
+
+// RoundQuery selects a player's rounds, newest first
+type RoundQuery struct {
+	ClientID string
+	PlayerID string
+	From     time.Time // inclusive, zero for no lower bound
+	To       time.Time // exclusive, zero for no upper bound
+	Level    int       // 0 for every bet level
+	Limit    int
+	Cursor   string // NextCursor of the previous page
+}
+
+// RoundPage is one page of a round listing
+type RoundPage struct {
+	Rounds     []Round `json:"rounds"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Round lifecycle states. A round goes created → wagered → resolved →
+// settled, or ends voided before it is resolved.
+const (
+	StateCreated  = "created"
+	StateWagered  = "wagered"
+	StateResolved = "resolved"
+	StateSettled  = "settled"
+	StateVoided   = "voided"
+)
+
+// RoundState is where a round is in its lifecycle. Round holds what is known
+// so far: the bet from creation, the outcome once it is resolved.
+type RoundState struct {
+	Round       Round           `json:"round"`
+	State       string          `json:"state"`
+	VoidReason  string          `json:"void_reason,omitempty"`
+	RNGResponse json.RawMessage `json:"rng_response,omitempty"` // as returned by the RNG, once resolved
+	Delivered   bool            `json:"delivered,omitempty"`    // the result was returned, so the operator pays its win
+	Transitions []Transition    `json:"transitions"`
+}
+
+// Transition records when a round entered a state
+type Transition struct {
+	State string    `json:"state"`
+	At    time.Time `json:"at"`
+}
+
+// Open reports whether the round is still neither settled nor voided
+func (s RoundState) Open() bool {
+	return s.State != StateSettled && s.State != StateVoided
+}
+
+// Ledger entry kinds. A rollback returns the debit of a voided round.
+const (
+	Debit    = "debit"
+	Credit   = "credit"
+	Rollback = "rollback"
+)
+
+// LedgerEntry is one movement of a player's money for a bet
+type LedgerEntry struct {
+	ClientID  string    `json:"client_id"`
+	PlayerID  string    `json:"player_id"`
+	BetID     string    `json:"bet_id"`
+	Kind      string    `json:"kind"` // Debit, Credit or Rollback
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditEntry records one change made through the admin API
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	At        time.Time       `json:"at"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RemoteIP  string          `json:"remote_ip,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// Pagination bounds for ListRounds and ListAudit
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// normalizedLimit applies the pagination bounds to a requested page size
+func normalizedLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return DefaultLimit
+	case limit > MaxLimit:
+		return MaxLimit
+	default:
+		return limit
+	}
+}
+
+// cursor marks the position after the last round of a page. Rounds are
+// ordered by completion time, then bet ID, in every backend.
+type cursor struct {
+	completed int64 // unix nanoseconds
+	betID     string
+}
+
+func (c cursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.completed, 10) + ":" + c.betID))
+}
+
+func decodeCursor(s string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	nanos, betID, ok := strings.Cut(string(raw), ":")
+	completed, err := strconv.ParseInt(nanos, 10, 64)
+	if !ok || err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	return cursor{completed: completed, betID: betID}, nil
+}
+
+// before reports whether a round sorts after the cursor in newest-first order
+func (c cursor) before(completed int64, betID string) bool {
+	return completed < c.completed || (completed == c.completed && betID < c.betID)
+}
+
+// sortOldestFirst orders round states by start time, then bet ID
+func sortOldestFirst(states []RoundState) {
+	slices.SortFunc(states, func(a, b RoundState) int {
+		if c := a.Round.StartedAt.Compare(b.Round.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Round.BetID, b.Round.BetID)
+	})
+}
+
+// matches applies the date and level filters of q to a round
+func (q RoundQuery) matches(r Round) bool {
+	if !q.From.IsZero() && r.CompletedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !r.CompletedAt.Before(q.To) {
+		return false
+	}
+	return q.Level == 0 || r.BetLevel == q.Level
+}
+
+// SessionRevocations keeps player session revocations in a Store
+type SessionRevocations struct {
+	Store Store
+}
+
+// RevokeSession revokes one session until it expires
+func (r SessionRevocations) RevokeSession(ctx context.Context, sessionID string, expires time.Time) error {
+	return r.Store.Update(ctx, func(tx Tx) error {
+		return tx.RevokeSession(sessionID, expires)
+	})
+}
+
+// RevokePlayerSessions revokes every session of a player issued up to at
+func (r SessionRevocations) RevokePlayerSessions(ctx context.Context, clientID, playerID string, at time.Time) error {
+	return r.Store.Update(ctx, func(tx Tx) error {
+		return tx.RevokePlayerSessions(clientID, playerID, at)
+	})
+}
+
+// SessionRevoked reports whether a session was revoked
+func (r SessionRevocations) SessionRevoked(ctx context.Context, sessionID, clientID, playerID string, issuedAt time.Time) (revoked bool, err error) {
+	err = r.Store.View(ctx, func(tx Tx) error {
+		revoked, err = tx.SessionRevoked(sessionID, clientID, playerID, issuedAt)
+		return err
+	})
+	return revoked, err
+}
@@ -0,0 +1,398 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// backends opens a fresh store of every kind
+func backends(t *testing.T) map[string]func() Store {
+	dir := t.TempDir()
+	open := func(driver, file string) func() Store {
+		return func() Store {
+			s, err := Open(driver, filepath.Join(dir, file))
+			if err != nil {
+				t.Fatalf("opening %s: %v", driver, err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		}
+	}
+	return map[string]func() Store{
+		"memory": open("memory", ""),
+		"bolt":   open("bolt", "test.bolt"),
+		"sqlite": open("sqlite", "test.sqlite"),
+	}
+}
+
+var base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testRound(bet string, minute, level int) Round {
+	return Round{
+		BetID:       bet,
+		ClientID:    "c1",
+		PlayerID:    "p1",
+		GameID:      "funkykingkong",
+		BetLevel:    level,
+		BetAmount:   0.1,
+		Reels:       []string{"Kong", "Kong", "Kong"},
+		WinAmount:   0.8,
+		StartedAt:   base.Add(time.Duration(minute) * time.Minute),
+		CompletedAt: base.Add(time.Duration(minute) * time.Minute),
+	}
+}
+
+func TestRounds(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			err := s.Update(ctx, func(tx Tx) error {
+				for i := 1; i <= 5; i++ {
+					if err := tx.InsertRound(testRound(fmt.Sprintf("b%d", i), i, 1+i%2)); err != nil {
+						return err
+					}
+				}
+				other := testRound("x1", 3, 1)
+				other.PlayerID = "p2"
+				return tx.InsertRound(other)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.Update(ctx, func(tx Tx) error { return tx.InsertRound(testRound("b1", 9, 1)) })
+			if !errors.Is(err, ErrDuplicate) {
+				t.Fatalf("duplicate insert: got %v, want ErrDuplicate", err)
+			}
+
+			s.View(ctx, func(tx Tx) error {
+				round, err := tx.GetRound("c1", "b3")
+				if err != nil || round.BetLevel != 2 || len(round.Reels) != 3 || !round.CompletedAt.Equal(base.Add(3*time.Minute)) {
+					t.Errorf("GetRound = %+v, %v", round, err)
+				}
+				if _, err := tx.GetRound("c1", "missing"); !errors.Is(err, ErrNotFound) {
+					t.Errorf("GetRound(missing) = %v, want ErrNotFound", err)
+				}
+
+				// Pages of two, newest first, only p1's rounds
+				var got []string
+				q := RoundQuery{ClientID: "c1", PlayerID: "p1", Limit: 2}
+				for {
+					page, err := tx.ListRounds(q)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, r := range page.Rounds {
+						got = append(got, r.BetID)
+					}
+					if page.NextCursor == "" {
+						break
+					}
+					q.Cursor = page.NextCursor
+				}
+				if want := "[b5 b4 b3 b2 b1]"; fmt.Sprint(got) != want {
+					t.Errorf("paged listing = %v, want %s", got, want)
+				}
+
+				page, _ := tx.ListRounds(RoundQuery{ClientID: "c1", PlayerID: "p1", Level: 2})
+				if got := betIDs(page); got != "[b5 b3 b1]" {
+					t.Errorf("level filter = %s, want [b5 b3 b1]", got)
+				}
+				page, _ = tx.ListRounds(RoundQuery{ClientID: "c1", PlayerID: "p1", From: base.Add(2 * time.Minute), To: base.Add(4 * time.Minute)})
+				if got := betIDs(page); got != "[b3 b2]" {
+					t.Errorf("date filter = %s, want [b3 b2]", got)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func betIDs(page RoundPage) string {
+	var ids []string
+	for _, r := range page.Rounds {
+		ids = append(ids, r.BetID)
+	}
+	return fmt.Sprint(ids)
+}
+
+func TestUpdateRollsBack(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			failure := errors.New("credit failed")
+			err := s.Update(ctx, func(tx Tx) error {
+				if err := tx.AppendLedger(LedgerEntry{ClientID: "c1", BetID: "b1", Kind: Debit, Amount: 0.1}); err != nil {
+					return err
+				}
+				if err := tx.InsertRound(testRound("b1", 1, 1)); err != nil {
+					return err
+				}
+				if err := tx.PutIdempotent("spin/c1/b1", []byte("{}"), base.Add(time.Hour)); err != nil {
+					return err
+				}
+				return failure
+			})
+			if !errors.Is(err, failure) {
+				t.Fatalf("Update = %v, want %v", err, failure)
+			}
+
+			s.View(ctx, func(tx Tx) error {
+				if _, err := tx.GetRound("c1", "b1"); !errors.Is(err, ErrNotFound) {
+					t.Errorf("round survived rollback: %v", err)
+				}
+				if entries, _ := tx.Ledger("c1", "b1"); len(entries) != 0 {
+					t.Errorf("ledger survived rollback: %v", entries)
+				}
+				if _, err := tx.GetIdempotent("spin/c1/b1", base); !errors.Is(err, ErrNotFound) {
+					t.Errorf("idempotent response survived rollback: %v", err)
+				}
+				if err := tx.InsertRound(testRound("b2", 1, 1)); err == nil {
+					t.Error("write succeeded inside View")
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestLedgerIdempotencyAndRevocations(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			err := s.Update(ctx, func(tx Tx) error {
+				tx.AppendLedger(LedgerEntry{ClientID: "c1", BetID: "b1", Kind: Debit, Amount: 0.1})
+				tx.AppendLedger(LedgerEntry{ClientID: "c1", BetID: "b1", Kind: Credit, Amount: 0.8})
+				tx.PutIdempotent("k", []byte("response"), base.Add(time.Minute))
+				tx.RevokeSession("s1", base.Add(time.Hour))
+				return tx.RevokePlayerSessions("c1", "p1", base)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s.View(ctx, func(tx Tx) error {
+				entries, err := tx.Ledger("c1", "b1")
+				if err != nil || len(entries) != 2 || entries[0].Kind != Debit || entries[1].Kind != Credit {
+					t.Errorf("Ledger = %+v, %v", entries, err)
+				}
+				if v, err := tx.GetIdempotent("k", base); err != nil || string(v) != "response" {
+					t.Errorf("GetIdempotent = %q, %v", v, err)
+				}
+				if _, err := tx.GetIdempotent("k", base.Add(time.Minute)); !errors.Is(err, ErrNotFound) {
+					t.Errorf("expired GetIdempotent = %v, want ErrNotFound", err)
+				}
+				for _, c := range []struct {
+					session, player string
+					issued          time.Time
+					want            bool
+				}{
+					{"s1", "p9", base.Add(time.Hour), true},
+					{"s2", "p1", base.Add(-time.Second), true},
+					{"s2", "p1", base, true},
+					{"s2", "p1", base.Add(time.Millisecond), false},
+					{"s2", "p2", base, false},
+				} {
+					if got, err := tx.SessionRevoked(c.session, "c1", c.player, c.issued); err != nil || got != c.want {
+						t.Errorf("SessionRevoked(%s, %s, %v) = %v, %v; want %v", c.session, c.player, c.issued, got, err, c.want)
+					}
+				}
+				return nil
+			})
+
+			if err := s.Update(ctx, func(tx Tx) error { return tx.PurgeExpired(base.Add(2 * time.Hour)) }); err != nil {
+				t.Fatal(err)
+			}
+			s.View(ctx, func(tx Tx) error {
+				if revoked, _ := tx.SessionRevoked("s1", "c1", "p9", base.Add(3*time.Hour)); revoked {
+					t.Error("expired session revocation was not purged")
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestRoundStates(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			state := func(bet string, minute int, st string) RoundState {
+				r := testRound(bet, minute, 1)
+				return RoundState{Round: r, State: st, Transitions: []Transition{{State: st, At: r.StartedAt}}}
+			}
+			err := s.Update(ctx, func(tx Tx) error {
+				tx.PutRoundState(state("b2", 2, StateWagered))
+				tx.PutRoundState(state("b1", 1, StateSettled))
+				other := state("b3", 1, StateCreated)
+				other.Round.PlayerID = "p2"
+				return tx.PutRoundState(other)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s.View(ctx, func(tx Tx) error {
+				if got, err := tx.OpenRound("c1", "p1"); err != nil || got.Round.BetID != "b2" || got.State != StateWagered {
+					t.Errorf("OpenRound = %+v, %v; want b2 wagered", got, err)
+				}
+				if got, err := tx.GetRoundState("c1", "b1"); err != nil || got.State != StateSettled || len(got.Transitions) != 1 {
+					t.Errorf("GetRoundState = %+v, %v", got, err)
+				}
+				open, err := tx.OpenRounds()
+				if err != nil || len(open) != 2 || open[0].Round.BetID != "b3" || open[1].Round.BetID != "b2" {
+					t.Errorf("OpenRounds = %+v, %v; want b3, b2", open, err)
+				}
+				return nil
+			})
+
+			s.Update(ctx, func(tx Tx) error { return tx.PutRoundState(state("b2", 2, StateVoided)) })
+			s.View(ctx, func(tx Tx) error {
+				if _, err := tx.OpenRound("c1", "p1"); !errors.Is(err, ErrNotFound) {
+					t.Errorf("OpenRound after voiding = %v, want ErrNotFound", err)
+				}
+				if open, _ := tx.OpenRounds(); len(open) != 1 {
+					t.Errorf("OpenRounds after voiding = %d rounds, want 1", len(open))
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestSettingsAndAudit(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			err := s.Update(ctx, func(tx Tx) error {
+				tx.PutSetting("admin", []byte(`{"a":1}`))
+				tx.PutSetting("admin", []byte(`{"a":2}`))
+				for i := 1; i <= 3; i++ {
+					if err := tx.AppendAudit(AuditEntry{At: base, Actor: "ops", Action: fmt.Sprintf("change%d", i)}); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// A failed update writes no audit entry
+			s.Update(ctx, func(tx Tx) error {
+				tx.AppendAudit(AuditEntry{At: base, Action: "lost"})
+				return errors.New("rejected")
+			})
+
+			s.View(ctx, func(tx Tx) error {
+				if got, err := tx.GetSetting("admin"); err != nil || string(got) != `{"a":2}` {
+					t.Errorf("GetSetting = %s, %v", got, err)
+				}
+				if _, err := tx.GetSetting("missing"); !errors.Is(err, ErrNotFound) {
+					t.Errorf("GetSetting of a missing key = %v, want ErrNotFound", err)
+				}
+				all, err := tx.ListAudit(0, 0)
+				if err != nil || len(all) != 3 || all[0].Action != "change3" || all[2].Action != "change1" {
+					t.Fatalf("ListAudit = %+v, %v; want the three changes newest first", all, err)
+				}
+				if older, _ := tx.ListAudit(all[0].ID, 1); len(older) != 1 || older[0].Action != "change2" {
+					t.Errorf("ListAudit before %d = %+v, want change2", all[0].ID, older)
+				}
+				if none, _ := tx.ListAudit(all[2].ID, 0); len(none) != 0 {
+					t.Errorf("ListAudit before the first entry = %+v, want none", none)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestPlayerState(t *testing.T) {
+	ctx := context.Background()
+	for name, open := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			err := s.Update(ctx, func(tx Tx) error {
+				tx.PutPlayerState("rg", "c1\x00p1", []byte("old"), base.Add(time.Hour))
+				tx.PutPlayerState("rg", "c1\x00p1", []byte("new"), base.Add(time.Hour))
+				tx.PutPlayerState("bet", "c1\x00p1", []byte("kept"), time.Time{})
+				return tx.PutPlayerState("rg", "c1\x00p2", []byte("short"), base.Add(time.Minute))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			get := func(tx Tx, kind, key string, now time.Time) string {
+				v, err := tx.GetPlayerState(kind, key, now)
+				if errors.Is(err, ErrNotFound) {
+					return "<none>"
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				return string(v)
+			}
+			s.View(ctx, func(tx Tx) error {
+				for _, tt := range []struct {
+					kind, key string
+					now       time.Time
+					want      string
+				}{
+					{"rg", "c1\x00p1", base, "new"},
+					{"bet", "c1\x00p1", base.Add(24 * 365 * time.Hour), "kept"},
+					{"rg", "c1\x00p2", base, "short"},
+					{"rg", "c1\x00p2", base.Add(time.Minute), "<none>"},
+					{"rg", "c1\x00p3", base, "<none>"},
+				} {
+					if got := get(tx, tt.kind, tt.key, tt.now); got != tt.want {
+						t.Errorf("GetPlayerState(%s, %q) = %s, want %s", tt.kind, tt.key, got, tt.want)
+					}
+				}
+				return nil
+			})
+
+			if err := s.Update(ctx, func(tx Tx) error { return tx.PurgeExpired(base.Add(2 * time.Hour)) }); err != nil {
+				t.Fatal(err)
+			}
+			s.View(ctx, func(tx Tx) error {
+				if got := get(tx, "rg", "c1\x00p1", base); got != "<none>" {
+					t.Errorf("expired state survived PurgeExpired: %s", got)
+				}
+				if got := get(tx, "bet", "c1\x00p1", base); got != "kept" {
+					t.Errorf("state without expiry = %s after PurgeExpired, want kept", got)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestMigrationsAreRepeatable(t *testing.T) {
+	dir := t.TempDir()
+	for _, driver := range []string{"bolt", "sqlite"} {
+		path := filepath.Join(dir, driver)
+		for i := 0; i < 2; i++ {
+			s, err := Open(driver, path)
+			if err != nil {
+				t.Fatalf("%s open %d: %v", driver, i, err)
+			}
+			if i == 0 {
+				s.Update(context.Background(), func(tx Tx) error { return tx.InsertRound(testRound("b1", 1, 1)) })
+			} else if err := s.View(context.Background(), func(tx Tx) error {
+				_, err := tx.GetRound("c1", "b1")
+				return err
+			}); err != nil {
+				t.Errorf("%s: round lost after reopening: %v", driver, err)
+			}
+			s.Close()
+		}
+	}
+}
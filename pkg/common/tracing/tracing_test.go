@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent   = "00-" + parentTraceID + "-00f067aa0ba902b7-01"
+)
+
+// record installs a tracer provider that keeps every ended span
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return recorder
+}
+
+func TestMiddlewareContinuesTrace(t *testing.T) {
+	recorder := record(t)
+
+	var outbound string
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/", func(c *fiber.Ctx) error {
+		ctx, span := Start(c.UserContext(), "handler")
+		defer span.End()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://upstream", nil)
+		InjectHTTP(ctx, req)
+		outbound = req.Header.Get("traceparent")
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", traceparent)
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != parentTraceID {
+		t.Errorf("span trace ID = %s, want the caller's %s", got, parentTraceID)
+	}
+	if !spans[0].Parent().IsRemote() {
+		t.Error("span parent is not the remote caller")
+	}
+	if !strings.Contains(outbound, parentTraceID) || !strings.Contains(outbound, spans[0].SpanContext().SpanID().String()) {
+		t.Errorf("outbound traceparent = %q, want the handler span under trace %s", outbound, parentTraceID)
+	}
+}
+
+func TestEndRecordsError(t *testing.T) {
+	recorder := record(t)
+
+	_, span := Start(context.Background(), "ok")
+	End(span, nil)
+	_, span = Start(context.Background(), "failed")
+	End(span, errors.New("RNG API call failed with status 502"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	if s := spans[0].Status(); s.Code != codes.Unset {
+		t.Errorf("ok span status = %v, want unset", s.Code)
+	}
+	if s := spans[1].Status(); s.Code != codes.Error || len(spans[1].Events()) != 1 {
+		t.Errorf("failed span status = %v with %d events, want error with the recorded error", s.Code, len(spans[1].Events()))
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Fatal("Setup accepted an unknown exporter")
+	}
+}
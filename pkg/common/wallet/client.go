@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/tracing"
+)
+
+// Transaction types
+const (
+	Credit   = "credit"   // pays a win
+	Rollback = "rollback" // returns the bet of a voided round
+)
+
+// Client notifies an operator wallet of money moved by the game server
+type Client struct {
+	ServiceURL string
+	HTTPClient *http.Client
+	Policy     *resilience.Policy
+}
+
+// NewClient creates a new wallet client guarded by the given resilience policy
+func NewClient(serviceURL string, policy *resilience.Policy) *Client {
+	return &Client{
+		ServiceURL: serviceURL,
+		HTTPClient: &http.Client{},
+		Policy:     policy,
+	}
+}
+
+// Transaction is one credit or rollback for a bet
+type Transaction struct {
+	TransactionID string  `json:"transaction_id"` // the same for every attempt, so the wallet applies it once
+	Type          string  `json:"type"`
+	ClientID      string  `json:"client_id"`
+	PlayerID      string  `json:"player_id"`
+	GameID        string  `json:"game_id"`
+	BetID         string  `json:"bet_id"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency,omitempty"`
+	Reason        string  `json:"reason,omitempty"`
+}
+
+// Receipt is the wallet's answer to a transaction
+type Receipt struct {
+	Balance *float64 `json:"balance"` // the player's balance afterwards, when the wallet reports it
+}
+
+// TransactionID identifies the transaction of a given type for a bet
+func TransactionID(clientID, betID, kind string) string {
+	return clientID + ":" + betID + ":" + kind
+}
+
+// Notify sends a transaction to the wallet. A 409 means the wallet already
+// applied it and counts as success. A 2xx body of {"balance": n} reports the
+// player's balance after the transaction; any other body leaves it unknown.
+func (c *Client) Notify(ctx context.Context, t Transaction) (receipt Receipt, err error) {
+	ctx, span := tracing.Start(ctx, "wallet.Notify",
+		attribute.String("game.id", t.GameID),
+		attribute.String("bet.id", t.BetID),
+		attribute.String("wallet.type", t.Type),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	reqBody, err := json.Marshal(t)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	err = c.Policy.Execute(ctx, func(ctx context.Context) error {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.ServiceURL, bytes.NewReader(reqBody))
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		tracing.InjectHTTP(ctx, httpReq)
+
+		resp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error calling wallet API", slog.Any("error", err))
+			return err
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusConflict:
+			slog.InfoContext(ctx, "Wallet transaction already applied", slog.String("transaction_id", t.TransactionID))
+			return nil
+		case resp.StatusCode < 200 || resp.StatusCode > 299:
+			slog.ErrorContext(ctx, "Wallet API returned non-2xx status", slog.Int("status", resp.StatusCode))
+			err := fmt.Errorf("wallet API call failed with status %d", resp.StatusCode)
+			if resilience.RefusedStatus(resp.StatusCode) {
+				return resilience.Rejected(err)
+			}
+			return err
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&receipt); err != nil {
+			slog.DebugContext(ctx, "Wallet response carries no balance", slog.Any("error", err))
+			receipt = Receipt{}
+		}
+		return nil
+	})
+	return receipt, err
+}
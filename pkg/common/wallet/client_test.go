@@ -0,0 +1,74 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+)
+
+func TestNotify(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		status      int
+		body        string
+		wantBalance float64 // 0 for no balance
+		wantErr     bool
+		wantRefused bool // the error marks a refused request, which leaves the breaker closed
+		wantBreaker resilience.State
+	}{
+		{"balance reported", http.StatusOK, `{"balance": 12.5}`, 12.5, false, false, resilience.StateClosed},
+		{"no balance", http.StatusOK, `ok`, 0, false, false, resilience.StateClosed},
+		{"already applied", http.StatusConflict, `{"balance": 12.5}`, 0, false, false, resilience.StateClosed},
+		{"refused", http.StatusBadRequest, ``, 0, true, true, resilience.StateClosed},
+		{"server error", http.StatusInternalServerError, ``, 0, true, false, resilience.StateOpen},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Transaction
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decoding request: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			policy := resilience.NewPolicy("wallet", resilience.Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+			c := NewClient(srv.URL, policy)
+			sent := Transaction{
+				TransactionID: TransactionID("1001", "bet-1", Credit),
+				Type:          Credit,
+				ClientID:      "1001",
+				PlayerID:      "p1",
+				GameID:        "slot",
+				BetID:         "bet-1",
+				Amount:        2.5,
+			}
+			receipt, err := c.Notify(context.Background(), sent)
+
+			if got != sent {
+				t.Errorf("wallet received %+v, want %+v", got, sent)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, want error %v", err, tt.wantErr)
+			}
+			if resilience.IsRejected(err) != tt.wantRefused {
+				t.Errorf("Notify() error = %v, want refused %v", err, tt.wantRefused)
+			}
+			switch {
+			case tt.wantBalance == 0 && receipt.Balance != nil:
+				t.Errorf("balance = %v, want none", *receipt.Balance)
+			case tt.wantBalance != 0 && (receipt.Balance == nil || *receipt.Balance != tt.wantBalance):
+				t.Errorf("balance = %v, want %v", receipt.Balance, tt.wantBalance)
+			}
+			if s := policy.State(); s != tt.wantBreaker {
+				t.Errorf("breaker State() = %s, want %s", s, tt.wantBreaker)
+			}
+		})
+	}
+}
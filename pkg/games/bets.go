@@ -0,0 +1,261 @@
+package games
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/logging"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/metrics"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+// Selection is a player's current bet: the level picks the paytable column
+// and the multiplier the coin denomination
+type Selection struct {
+	Level      int `json:"level"`
+	Multiplier int `json:"multiplier"`
+}
+
+// Bet returns the level and amount the selection plays. A multiplier the
+// level does not offer falls back to the level's smallest amount.
+func (s Selection) Bet(game Game, info Info) (int, float64) {
+	amounts := info.BetAmounts[s.Level]
+	for _, amount := range amounts {
+		if m, _ := game.Multiplier(amount, s.Level); m == s.Multiplier {
+			return s.Level, amount
+		}
+	}
+	if len(amounts) == 0 {
+		return s.Level, 0
+	}
+	return s.Level, amounts[0]
+}
+
+// Denomination returns the coin value of the selection: the amount with its
+// multiplier at the first bet level
+func (s Selection) Denomination(game Game, info Info) float64 {
+	_, amount := Selection{Level: info.BetLevels[0], Multiplier: s.Multiplier}.Bet(game, info)
+	return amount
+}
+
+// selectionKind is the kind of player state holding bet selections
+const selectionKind = "bet"
+
+// SelectionIdle is how long an unused bet selection is kept
+const SelectionIdle = 24 * time.Hour
+
+// Selections keeps each player's current bet selection per game in the
+// store, forgetting selections unused for longer than the idle timeout
+type Selections struct {
+	store store.Store
+	idle  time.Duration
+}
+
+// NewSelections creates a selection store keeping selections in st
+func NewSelections(st store.Store, idle time.Duration) *Selections {
+	return &Selections{store: st, idle: idle}
+}
+
+// selectionKey identifies a player's selection for a game
+func selectionKey(gameID, clientID, playerID string) string {
+	return gameID + "\x00" + clientID + "\x00" + playerID
+}
+
+// Current returns the player's selection, or the game's smallest bet at its
+// first level when the player has none
+func (s *Selections) Current(ctx context.Context, key string, game Game, info Info) (Selection, error) {
+	var data []byte
+	err := s.store.View(ctx, func(tx store.Tx) (err error) {
+		data, err = tx.GetPlayerState(selectionKind, key, time.Now())
+		return err
+	})
+	if err == nil {
+		var sel Selection
+		err = json.Unmarshal(data, &sel)
+		return sel, err
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return Selection{}, err
+	}
+	level := info.BetLevels[0]
+	multiplier := 0
+	if amounts := info.BetAmounts[level]; len(amounts) > 0 {
+		multiplier, _ = game.Multiplier(amounts[0], level)
+	}
+	return Selection{Level: level, Multiplier: multiplier}, nil
+}
+
+// Set makes sel the player's current selection
+func (s *Selections) Set(ctx context.Context, key string, sel Selection) error {
+	data, err := json.Marshal(sel)
+	if err != nil {
+		return err
+	}
+	return s.store.Update(ctx, func(tx store.Tx) error {
+		return tx.PutPlayerState(selectionKind, key, data, time.Now().Add(s.idle))
+	})
+}
+
+// BetOne moves to the next bet level, wrapping from the highest to the first
+func BetOne(sel Selection, info Info) Selection {
+	i := slices.Index(info.BetLevels, sel.Level)
+	sel.Level = info.BetLevels[(i+1)%len(info.BetLevels)]
+	return sel
+}
+
+// BetMax moves to the highest bet level
+func BetMax(sel Selection, info Info) Selection {
+	sel.Level = slices.Max(info.BetLevels)
+	return sel
+}
+
+// betAction changes a selection; a returned *BetError refuses the change
+type betAction func(sel Selection, game Game, info Info, req BetRequest) (Selection, error)
+
+// keepSelection reports the selection unchanged
+func keepSelection(sel Selection, _ Game, _ Info, _ BetRequest) (Selection, error) {
+	return sel, nil
+}
+
+// betOne is the "Bet One" button
+func betOne(sel Selection, _ Game, info Info, _ BetRequest) (Selection, error) {
+	return BetOne(sel, info), nil
+}
+
+// betMax is the "Bet Max" button
+func betMax(sel Selection, _ Game, info Info, _ BetRequest) (Selection, error) {
+	return BetMax(sel, info), nil
+}
+
+// setDenomination changes the coin value, keeping the bet level. The coin
+// value must be offered at the first bet level and at the current one.
+func setDenomination(sel Selection, game Game, info Info, req BetRequest) (Selection, error) {
+	first := info.BetLevels[0]
+	multiplier, ok := game.Multiplier(req.Denomination, first)
+	if ok {
+		level, amount := Selection{Level: sel.Level, Multiplier: multiplier}.Bet(game, info)
+		m, _ := game.Multiplier(amount, level)
+		ok = m == multiplier
+	}
+	if !ok {
+		return sel, &BetError{
+			Code:    "invalid_denomination",
+			Message: fmt.Sprintf("Invalid denomination, valid denominations: %v", info.BetAmounts[first]),
+		}
+	}
+	sel.Multiplier = multiplier
+	return sel, nil
+}
+
+// BetHandler returns the handler applying action to the player's selection
+// for game id and answering the resulting selection
+func (rg *RouteGroup) BetHandler(id string, action betAction) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		game, info, ok := rg.Games.Active(id)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "game not found")
+		}
+		gameID := info.ID
+		if limited, err := rg.limitIP(c, gameID); limited {
+			return err
+		}
+
+		var req BetRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				metrics.RecordRejection(gameID, "invalid_body")
+				return c.Status(fiber.StatusBadRequest).JSON(BetResponse{
+					Status:  "error",
+					Message: "Invalid request body",
+				})
+			}
+		}
+		if req.ClientID == "" && req.PlayerID == "" {
+			req.ClientID, req.PlayerID = c.Query("client_id"), c.Query("player_id")
+		}
+		player := SpinRequest{ClientID: req.ClientID, PlayerID: req.PlayerID, GameID: gameID}
+		if _, done, err := rg.authenticatePlayer(c, gameID, &player); done {
+			return err
+		}
+		if player.ClientID == "" || player.PlayerID == "" {
+			metrics.RecordRejection(gameID, "missing_fields")
+			return c.Status(fiber.StatusBadRequest).JSON(BetResponse{
+				Status:  "error",
+				Message: "ClientID, PlayerID must not be empty",
+			})
+		}
+		if done, err := clientNotAllowed(c, gameID, player.ClientID); done {
+			return err
+		}
+		c.SetUserContext(logging.With(c.UserContext(),
+			slog.String(logging.KeyPlayerID, player.PlayerID),
+			slog.String(logging.KeyClientID, player.ClientID),
+			slog.String(logging.KeyGameID, gameID),
+		))
+
+		key := selectionKey(gameID, player.ClientID, player.PlayerID)
+		current, err := rg.Selections.Current(c.UserContext(), key, game, info)
+		if err != nil {
+			return selectionFailed(c, err)
+		}
+		sel, err := action(current, game, info, req)
+		var betErr *BetError
+		if errors.As(err, &betErr) {
+			slog.WarnContext(c.UserContext(), "Validation error: "+betErr.Message)
+			metrics.RecordRejection(gameID, betErr.Code)
+			return c.Status(fiber.StatusBadRequest).JSON(BetResponse{
+				Status:  "error",
+				Code:    betErr.Code,
+				Message: betErr.Message,
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		// Settle on a bet the level offers, so the selection is always playable
+		level, amount := sel.Bet(game, info)
+		sel.Multiplier, _ = game.Multiplier(amount, level)
+		if err := rg.Selections.Set(c.UserContext(), key, sel); err != nil {
+			return selectionFailed(c, err)
+		}
+		slog.DebugContext(c.UserContext(), "Bet selection changed",
+			slog.Int("bet_level", level), slog.Float64("bet_amount", amount))
+
+		return c.JSON(BetResponse{
+			Status:       "success",
+			BetLevel:     level,
+			BetAmount:    amount,
+			Denomination: sel.Denomination(game, info),
+			Paytable:     paytableColumn(info, level),
+		})
+	}
+}
+
+// selectionFailed answers a bet request whose selection could not be read or saved
+func selectionFailed(c *fiber.Ctx, err error) error {
+	slog.ErrorContext(c.UserContext(), "Error accessing bet selection", slog.Any("error", err))
+	return c.Status(fiber.StatusInternalServerError).JSON(BetResponse{
+		Status:  "error",
+		Message: "Internal server error",
+	})
+}
+
+// paytableColumn returns the credits of each combination at level
+func paytableColumn(info Info, level int) map[string]int {
+	i := slices.Index(info.BetLevels, level)
+	column := make(map[string]int, len(info.Paytable))
+	for combination, credits := range info.Paytable {
+		if i >= 0 && i < len(credits) {
+			column[combination] = credits[i]
+		}
+	}
+	return column
+}
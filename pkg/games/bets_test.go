@@ -0,0 +1,54 @@
+package games_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games/funkykingkong"
+)
+
+func TestBetSelection(t *testing.T) {
+	game := funkykingkong.New()
+	info := game.Info()
+	ctx := context.Background()
+	st := store.NewMemory()
+	selections := games.NewSelections(st, time.Hour)
+
+	sel, err := selections.Current(ctx, "p1", game, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level, amount := sel.Bet(game, info); level != 1 || amount != 0.01 {
+		t.Fatalf("default bet = x%d %v, want x1 0.01", level, amount)
+	}
+
+	// Bet One cycles x1 -> x2 -> x3 -> x1 at the same denomination
+	sel.Multiplier = 10
+	for _, want := range []struct {
+		level  int
+		amount float64
+	}{{2, 0.2}, {3, 0.3}, {1, 0.1}} {
+		sel = games.BetOne(sel, info)
+		if level, amount := sel.Bet(game, info); level != want.level || amount != want.amount {
+			t.Fatalf("after Bet One: x%d %v, want x%d %v", level, amount, want.level, want.amount)
+		}
+		if d := sel.Denomination(game, info); d != 0.1 {
+			t.Fatalf("denomination changed to %v", d)
+		}
+	}
+
+	if sel = games.BetMax(sel, info); sel.Level != 3 {
+		t.Fatalf("Bet Max selected x%d", sel.Level)
+	}
+	if err := selections.Set(ctx, "p1", sel); err != nil {
+		t.Fatal(err)
+	}
+
+	// Selections live in the store, so they outlive the process that set them
+	if got, err := games.NewSelections(st, time.Hour).Current(ctx, "p1", game, info); err != nil || got != sel {
+		t.Fatalf("Current = %+v, %v; want %+v", got, err, sel)
+	}
+}
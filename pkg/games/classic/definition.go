@@ -0,0 +1,275 @@
+package classic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Pay match kinds
+const (
+	// MatchExact pays when each reel shows the symbol listed for it
+	MatchExact = "exact"
+	// MatchAny pays when every reel shows one of the listed symbols, such as any bar
+	MatchAny = "any"
+	// MatchLeading pays when the first Count reels show the listed symbol, such as cherries
+	MatchLeading = "leading"
+)
+
+// Definition describes a classic slot: its reels, symbols, bets and pays
+type Definition struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Version     string     `json:"version"`
+	RTP         float64    `json:"rtp"` // certified theoretical return to player, percent
+	Reels       int        `json:"reels"`
+	Blank       string     `json:"blank,omitempty"` // the empty reel position, which never pays
+	CreditValue float64    `json:"credit_value"`    // money per paytable credit at multiplier 1
+	Symbols     []string   `json:"symbols"`
+	BetLevels   []BetLevel `json:"bet_levels"`
+	Pays        []Pay      `json:"pays"`
+
+	// WinningReels, when listed, are the outcomes drawn for a win; otherwise
+	// wins are generated from the pays
+	WinningReels [][]string `json:"winning_reels,omitempty"`
+	// LosingReels are the outcomes a curated loss policy draws from
+	LosingReels [][]string `json:"losing_reels,omitempty"`
+	// ReelStrips are the stops of each reel, blanks included, that a
+	// reel-strip loss policy spins; without them each reel holds every
+	// symbol and the blank once
+	ReelStrips [][]string `json:"reel_strips,omitempty"`
+	// LossPolicy is how losses are presented unless the server overrides it
+	LossPolicy LossPolicy `json:"loss_policy,omitempty"`
+}
+
+// BetLevel is one paytable and the bet amounts played on it
+type BetLevel struct {
+	Level   int         `json:"level"`
+	Amounts []BetAmount `json:"amounts"`
+}
+
+// BetAmount maps a bet amount to the multiplier applied to paytable credits
+type BetAmount struct {
+	Amount     float64 `json:"amount"`
+	Multiplier int     `json:"multiplier"`
+}
+
+// Pay is one paying combination and its credits at each bet level
+type Pay struct {
+	Name    string   `json:"name"`            // paytable key
+	Label   string   `json:"label,omitempty"` // reported combination; Name when empty
+	Match   string   `json:"match"`           // MatchExact, MatchAny or MatchLeading
+	Symbols []string `json:"symbols"`
+	Count   int      `json:"count,omitempty"` // reels covered by a MatchLeading pay
+	Credits []int    `json:"credits"`         // one entry per bet level, in BetLevels order
+}
+
+// combination is the name reported when the pay wins
+func (p Pay) combination() string {
+	if p.Label != "" {
+		return p.Label
+	}
+	return p.Name
+}
+
+// Parse decodes and validates a definition
+func Parse(data []byte) (*Definition, error) {
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("decoding definition: %w", err)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// Validate reports the first problem that would make the definition unplayable
+func (d *Definition) Validate() error {
+	if d.ID == "" {
+		return errors.New("definition has no id")
+	}
+	if d.RTP <= 0 || d.RTP > 100 {
+		return fmt.Errorf("definition %s: rtp %v is outside 0..100", d.ID, d.RTP)
+	}
+	if d.Reels < 1 {
+		return fmt.Errorf("definition %s: reels must be at least 1", d.ID)
+	}
+	if d.CreditValue <= 0 {
+		return fmt.Errorf("definition %s: credit_value must be positive", d.ID)
+	}
+	if len(d.Symbols) == 0 {
+		return fmt.Errorf("definition %s: no symbols", d.ID)
+	}
+	seen := make(map[string]bool, len(d.Symbols))
+	for _, symbol := range d.Symbols {
+		if symbol == "" || symbol == d.Blank || seen[symbol] {
+			return fmt.Errorf("definition %s: symbol %q is empty, blank or repeated", d.ID, symbol)
+		}
+		seen[symbol] = true
+	}
+
+	if len(d.BetLevels) == 0 {
+		return fmt.Errorf("definition %s: no bet levels", d.ID)
+	}
+	levels := make(map[int]bool, len(d.BetLevels))
+	for _, level := range d.BetLevels {
+		if levels[level.Level] {
+			return fmt.Errorf("definition %s: bet level %d is repeated", d.ID, level.Level)
+		}
+		levels[level.Level] = true
+		if len(level.Amounts) == 0 {
+			return fmt.Errorf("definition %s: no bet amounts for level %d", d.ID, level.Level)
+		}
+		for _, amount := range level.Amounts {
+			if amount.Amount <= 0 || amount.Multiplier <= 0 {
+				return fmt.Errorf("definition %s: level %d: amount %v and multiplier %d must be positive",
+					d.ID, level.Level, amount.Amount, amount.Multiplier)
+			}
+		}
+	}
+
+	if len(d.Pays) == 0 {
+		return fmt.Errorf("definition %s: paytable is empty", d.ID)
+	}
+	names := make(map[string]bool, len(d.Pays))
+	for _, pay := range d.Pays {
+		if err := d.validatePay(pay, seen); err != nil {
+			return fmt.Errorf("definition %s: pay %q: %w", d.ID, pay.Name, err)
+		}
+		if names[pay.Name] {
+			return fmt.Errorf("definition %s: pay %q is repeated", d.ID, pay.Name)
+		}
+		names[pay.Name] = true
+	}
+
+	if d.Blank == "" && len(d.LosingReels) == 0 {
+		return fmt.Errorf("definition %s: needs a blank or losing_reels to generate losses", d.ID)
+	}
+	if len(d.ReelStrips) > 0 && len(d.ReelStrips) != d.Reels {
+		return fmt.Errorf("definition %s: has %d reel strips for %d reels", d.ID, len(d.ReelStrips), d.Reels)
+	}
+	for i, strip := range d.ReelStrips {
+		if len(strip) == 0 {
+			return fmt.Errorf("definition %s: reel strip %d is empty", d.ID, i+1)
+		}
+		for _, symbol := range strip {
+			if !seen[symbol] && symbol != d.Blank {
+				return fmt.Errorf("definition %s: reel strip %d: unknown symbol %q", d.ID, i+1, symbol)
+			}
+		}
+	}
+	for _, reels := range d.WinningReels {
+		if err := d.validateOutcome(reels, seen, true); err != nil {
+			return fmt.Errorf("definition %s: winning reels %v: %w", d.ID, reels, err)
+		}
+	}
+	for _, reels := range d.LosingReels {
+		if err := d.validateOutcome(reels, seen, false); err != nil {
+			return fmt.Errorf("definition %s: losing reels %v: %w", d.ID, reels, err)
+		}
+	}
+	if err := d.resolveLossPolicy(d.LossPolicy).validate(d); err != nil {
+		return fmt.Errorf("definition %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+// validatePay checks one pay against the reels, symbols and bet levels
+func (d *Definition) validatePay(pay Pay, symbols map[string]bool) error {
+	if pay.Name == "" {
+		return errors.New("no name")
+	}
+	if len(pay.Credits) != len(d.BetLevels) {
+		return fmt.Errorf("has %d credits, want one per bet level (%d)", len(pay.Credits), len(d.BetLevels))
+	}
+	for _, credits := range pay.Credits {
+		if credits <= 0 {
+			return errors.New("credits must be positive")
+		}
+	}
+	for _, symbol := range pay.Symbols {
+		if !symbols[symbol] {
+			return fmt.Errorf("unknown symbol %q", symbol)
+		}
+	}
+	switch pay.Match {
+	case MatchExact:
+		if len(pay.Symbols) != d.Reels {
+			return fmt.Errorf("lists %d symbols for %d reels", len(pay.Symbols), d.Reels)
+		}
+	case MatchAny:
+		if len(pay.Symbols) < 2 {
+			return errors.New("needs at least two symbols")
+		}
+	case MatchLeading:
+		if len(pay.Symbols) != 1 {
+			return errors.New("needs exactly one symbol")
+		}
+		if pay.Count < 1 || pay.Count > d.Reels {
+			return fmt.Errorf("count %d is outside 1..%d", pay.Count, d.Reels)
+		}
+	default:
+		return fmt.Errorf("unknown match %q", pay.Match)
+	}
+	return nil
+}
+
+// validateOutcome checks that listed reels are well formed and pay, or don't,
+// at every bet level
+func (d *Definition) validateOutcome(reels []string, symbols map[string]bool, win bool) error {
+	if len(reels) != d.Reels {
+		return fmt.Errorf("has %d reels, want %d", len(reels), d.Reels)
+	}
+	for _, symbol := range reels {
+		if !symbols[symbol] && symbol != d.Blank {
+			return fmt.Errorf("unknown symbol %q", symbol)
+		}
+	}
+	for i := range d.BetLevels {
+		if _, ok := d.bestPay(reels, i); ok != win {
+			return fmt.Errorf("pays %v at level %d", ok, d.BetLevels[i].Level)
+		}
+	}
+	return nil
+}
+
+// bestPay returns the highest-paying pay matched by reels at the bet level
+// with index level
+func (d *Definition) bestPay(reels []string, level int) (Pay, bool) {
+	var best Pay
+	found := false
+	for _, pay := range d.Pays {
+		if pay.matches(reels) && (!found || pay.Credits[level] > best.Credits[level]) {
+			best, found = pay, true
+		}
+	}
+	return best, found
+}
+
+// matches reports whether reels show the pay's combination
+func (p Pay) matches(reels []string) bool {
+	switch p.Match {
+	case MatchExact:
+		return slices.Equal(reels, p.Symbols)
+	case MatchAny:
+		for _, symbol := range reels {
+			if !slices.Contains(p.Symbols, symbol) {
+				return false
+			}
+		}
+		return len(reels) > 0
+	case MatchLeading:
+		if len(reels) < p.Count {
+			return false
+		}
+		for _, symbol := range reels[:p.Count] {
+			if symbol != p.Symbols[0] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+package classic
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games"
+)
+
+// generateAttempts bounds the draws spent looking for a losing outcome
+// before falling back to a fixed one
+const generateAttempts = 1000
+
+// Game plays a classic slot described by a Definition
+type Game struct {
+	def    *Definition
+	losses *lossGenerator
+}
+
+// New creates a game from a definition, validating it first. Losses are
+// presented under the definition's loss policy.
+func New(def *Definition) (*Game, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	losses, err := newLossGenerator(def, LossPolicy{})
+	if err != nil {
+		return nil, err
+	}
+	return &Game{def: def, losses: losses}, nil
+}
+
+// Load parses a definition and creates its game
+func Load(data []byte) (*Game, error) {
+	def, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return New(def)
+}
+
+// WithLossPolicy returns a copy of the game presenting losses under policy.
+// An empty Mode keeps the definition's.
+func (g *Game) WithLossPolicy(policy LossPolicy) (*Game, error) {
+	losses, err := newLossGenerator(g.def, policy)
+	if err != nil {
+		return nil, fmt.Errorf("game %s: %w", g.def.ID, err)
+	}
+	return &Game{def: g.def, losses: losses}, nil
+}
+
+// LossPolicy returns the policy losses are presented under
+func (g *Game) LossPolicy() LossPolicy {
+	return g.losses.policy
+}
+
+// NearMissRates returns the share of losses that are near misses by chance
+// on the reel strips, and the share the loss policy is expected to show
+func (g *Game) NearMissRates() (chance, policy float64) {
+	return g.losses.chanceRate, g.losses.rate
+}
+
+// NearMiss reports whether losing reels fall one reel short of a pay
+func (g *Game) NearMiss(reels []string) bool {
+	return !g.def.pays(reels) && g.def.nearMiss(reels)
+}
+
+// Definition returns the definition the game plays
+func (g *Game) Definition() *Definition {
+	return g.def
+}
+
+// Info describes the game
+func (g *Game) Info() games.Info {
+	levels := g.Levels()
+	amounts := make(map[int][]float64, len(levels))
+	for _, level := range levels {
+		amounts[level] = g.BetAmounts(level)
+	}
+	paytable := make(map[string][]int, len(g.def.Pays))
+	for _, pay := range g.def.Pays {
+		paytable[pay.Name] = pay.Credits
+	}
+	return games.Info{
+		ID:                g.def.ID,
+		Name:              g.def.Name,
+		DefinitionVersion: g.def.Version,
+		RTP:               g.def.RTP,
+		BetLevels:         levels,
+		BetAmounts:        amounts,
+		Paytable:          paytable,
+	}
+}
+
+// Levels returns the bet levels in definition order
+func (g *Game) Levels() []int {
+	levels := make([]int, len(g.def.BetLevels))
+	for i, level := range g.def.BetLevels {
+		levels[i] = level.Level
+	}
+	return levels
+}
+
+// BetAmounts returns the amounts offered at level in ascending order
+func (g *Game) BetAmounts(level int) []float64 {
+	i := g.levelIndex(level)
+	if i < 0 {
+		return nil
+	}
+	amounts := make([]float64, 0, len(g.def.BetLevels[i].Amounts))
+	for _, amount := range g.def.BetLevels[i].Amounts {
+		amounts = append(amounts, amount.Amount)
+	}
+	slices.Sort(amounts)
+	return amounts
+}
+
+// Multiplier returns the credit multiplier for amount at level
+func (g *Game) Multiplier(amount float64, level int) (int, bool) {
+	i := g.levelIndex(level)
+	if i < 0 {
+		return 0, false
+	}
+	for _, a := range g.def.BetLevels[i].Amounts {
+		if a.Amount == amount {
+			return a.Multiplier, true
+		}
+	}
+	return 0, false
+}
+
+// ValidateBet checks the bet level and the amount offered at that level
+func (g *Game) ValidateBet(level int, amount float64) error {
+	if g.levelIndex(level) < 0 {
+		return games.InvalidBetLevel(g.Levels())
+	}
+	if _, ok := g.Multiplier(amount, level); !ok {
+		return games.InvalidBetAmount(level, g.BetAmounts(level))
+	}
+	return nil
+}
+
+// Evaluate prices reels at the paytable of level for amount
+func (g *Game) Evaluate(reels []string, level int, amount float64) (float64, string) {
+	multiplier, ok := g.Multiplier(amount, level)
+	if !ok {
+		return 0, ""
+	}
+	return g.EvaluateCredits(reels, level, multiplier)
+}
+
+// EvaluateCredits prices reels at the paytable of level with a credit
+// multiplier. Reels of the wrong length, unknown levels and non-positive
+// multipliers never pay.
+func (g *Game) EvaluateCredits(reels []string, level int, multiplier int) (float64, string) {
+	i := g.levelIndex(level)
+	if i < 0 || multiplier <= 0 || len(reels) != g.def.Reels {
+		return 0, ""
+	}
+	pay, ok := g.def.bestPay(reels, i)
+	if !ok {
+		return 0, ""
+	}
+	return float64(pay.Credits[i]*multiplier) * g.def.CreditValue, pay.combination()
+}
+
+// WinningReels draws reels that pay at every bet level
+func (g *Game) WinningReels() []string {
+	if len(g.def.WinningReels) > 0 {
+		return slices.Clone(g.def.WinningReels[rand.Intn(len(g.def.WinningReels))])
+	}
+	pay := g.def.Pays[rand.Intn(len(g.def.Pays))]
+	reels := make([]string, g.def.Reels)
+	for i := range reels {
+		switch {
+		case pay.Match == MatchExact:
+			reels[i] = pay.Symbols[i]
+		case pay.Match == MatchAny:
+			reels[i] = pay.Symbols[rand.Intn(len(pay.Symbols))]
+		case i < pay.Count:
+			reels[i] = pay.Symbols[0]
+		default:
+			reels[i] = g.randomPosition()
+		}
+	}
+	return reels
+}
+
+// LosingReels draws reels that pay nothing, under the loss policy
+func (g *Game) LosingReels() []string {
+	return g.losses.draw(g.def)
+}
+
+// CheckDefinition reports whether the definition is usable
+func (g *Game) CheckDefinition() error {
+	return g.def.Validate()
+}
+
+// randomPosition draws a symbol or, when the game has one, a blank
+func (g *Game) randomPosition() string {
+	n := len(g.def.Symbols)
+	if g.def.Blank != "" {
+		n++
+	}
+	if i := rand.Intn(n); i < len(g.def.Symbols) {
+		return g.def.Symbols[i]
+	}
+	return g.def.Blank
+}
+
+// levelIndex returns the position of level in the definition, or -1
+func (g *Game) levelIndex(level int) int {
+	for i, l := range g.def.BetLevels {
+		if l.Level == level {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,96 @@
+package classic
+
+import (
+	"strings"
+	"testing"
+)
+
+// fruitMachine is a five-reel definition with an any-7 group and cherry pays
+const fruitMachine = `{
+  "id": "fruits",
+  "name": "Fruits",
+  "version": "1",
+  "rtp": 95,
+  "reels": 5,
+  "blank": "BLANK",
+  "credit_value": 0.01,
+  "symbols": ["Red7", "Blue7", "Bell", "Cherry", "Lemon"],
+  "bet_levels": [
+    {"level": 1, "amounts": [{"amount": 0.05, "multiplier": 5}]},
+    {"level": 2, "amounts": [{"amount": 0.1, "multiplier": 5}]}
+  ],
+  "pays": [
+    {"name": "Red7 x5", "match": "exact", "symbols": ["Red7", "Red7", "Red7", "Red7", "Red7"], "credits": [500, 1000]},
+    {"name": "ANY_7", "label": "ANY 7", "match": "any", "symbols": ["Red7", "Blue7"], "credits": [100, 200]},
+    {"name": "Cherry", "match": "leading", "symbols": ["Cherry"], "count": 1, "credits": [2, 4]},
+    {"name": "Cherry Cherry", "match": "leading", "symbols": ["Cherry"], "count": 2, "credits": [5, 10]}
+  ]
+}`
+
+func loadFruitMachine(t *testing.T) *Game {
+	t.Helper()
+	game, err := Load([]byte(fruitMachine))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func TestEvaluate(t *testing.T) {
+	game := loadFruitMachine(t)
+	for _, tc := range []struct {
+		reels       []string
+		level       int
+		win         float64
+		combination string
+	}{
+		{[]string{"Red7", "Red7", "Red7", "Red7", "Red7"}, 1, 25, "Red7 x5"},
+		{[]string{"Red7", "Blue7", "Red7", "Blue7", "Blue7"}, 2, 10, "ANY 7"},
+		{[]string{"Blue7", "Blue7", "Blue7", "Blue7", "Blue7"}, 1, 5, "ANY 7"},
+		{[]string{"Cherry", "Lemon", "Bell", "BLANK", "Bell"}, 1, 0.1, "Cherry"},
+		{[]string{"Cherry", "Cherry", "Bell", "Cherry", "Bell"}, 2, 0.5, "Cherry Cherry"},
+		{[]string{"Lemon", "Cherry", "Cherry", "Cherry", "Cherry"}, 1, 0, ""},
+		{[]string{"Red7", "BLANK", "Red7", "Red7", "Red7"}, 1, 0, ""},
+		{[]string{"Red7", "Red7", "Red7"}, 1, 0, ""},
+		{[]string{"Red7", "Red7", "Red7", "Red7", "Red7"}, 3, 0, ""},
+	} {
+		amount := map[int]float64{1: 0.05, 2: 0.1, 3: 0.15}[tc.level]
+		win, combination := game.Evaluate(tc.reels, tc.level, amount)
+		if win != tc.win || combination != tc.combination {
+			t.Errorf("%v at level %d: got %v %q, want %v %q", tc.reels, tc.level, win, combination, tc.win, tc.combination)
+		}
+	}
+}
+
+func TestGeneratedOutcomes(t *testing.T) {
+	game := loadFruitMachine(t)
+	for i := 0; i < 2000; i++ {
+		if reels := game.WinningReels(); len(reels) != 5 || !game.def.pays(reels) {
+			t.Fatalf("winning reels %v do not pay", reels)
+		}
+		if reels := game.LosingReels(); len(reels) != 5 || game.def.pays(reels) {
+			t.Fatalf("losing reels %v pay", reels)
+		}
+	}
+}
+
+func TestValidateRejectsBrokenDefinitions(t *testing.T) {
+	for name, tc := range map[string]struct{ from, to string }{
+		"unknown symbol":  {`["Cherry"], "count": 1`, `["Grape"], "count": 1`},
+		"short credits":   {`"credits": [500, 1000]`, `"credits": [500]`},
+		"exact length":    {`["Red7", "Red7", "Red7", "Red7", "Red7"]`, `["Red7", "Red7"]`},
+		"leading count":   {`"count": 2`, `"count": 6`},
+		"unknown match":   {`"match": "any"`, `"match": "scatter"`},
+		"repeated level":  {`{"level": 2,`, `{"level": 1,`},
+		"no blank":        {`"blank": "BLANK",`, ``},
+		"blank as symbol": {`"Lemon"]`, `"Lemon", "BLANK"]`},
+	} {
+		broken := strings.Replace(fruitMachine, tc.from, tc.to, 1)
+		if broken == fruitMachine {
+			t.Fatalf("%s: replacement did not apply", name)
+		}
+		if _, err := Load([]byte(broken)); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package classic
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// Loss policy modes
+const (
+	// PolicyCurated draws losses from the definition's losing_reels list
+	PolicyCurated = "curated"
+	// PolicyReelStrip spins the reel strips until they show a loss, so every
+	// losing outcome appears as often as chance makes it
+	PolicyReelStrip = "reel_strip"
+)
+
+// chanceEnumerationLimit is the largest number of reel-strip stops combined
+// exhaustively when measuring the chance near-miss rate; larger games are sampled
+const chanceEnumerationLimit = 2_000_000
+
+// chanceSamples is the number of spins sampled for larger games
+const chanceSamples = 200_000
+
+// LossPolicy controls how a loss decided by the RNG is presented
+type LossPolicy struct {
+	Mode string `json:"mode"` // PolicyCurated or PolicyReelStrip; empty for the definition's
+
+	// MaxNearMissRate caps the share of losses shown as near misses; 0 leaves it alone
+	MaxNearMissRate float64 `json:"max_near_miss_rate,omitempty"`
+	// CapAtChance caps the near-miss share at what the reel strips give by chance
+	CapAtChance bool `json:"cap_at_chance,omitempty"`
+}
+
+func (p LossPolicy) String() string {
+	s := p.Mode
+	if p.MaxNearMissRate > 0 {
+		s += fmt.Sprintf(", near misses <= %g%%", p.MaxNearMissRate*100)
+	}
+	if p.CapAtChance {
+		s += ", near misses <= chance"
+	}
+	return s
+}
+
+// validate checks the policy against the definition it applies to
+func (p LossPolicy) validate(d *Definition) error {
+	switch p.Mode {
+	case PolicyCurated:
+		if len(d.LosingReels) == 0 {
+			return fmt.Errorf("policy %s needs losing_reels", p.Mode)
+		}
+	case PolicyReelStrip:
+	default:
+		return fmt.Errorf("unknown loss policy %q", p.Mode)
+	}
+	if p.MaxNearMissRate < 0 || p.MaxNearMissRate > 1 {
+		return fmt.Errorf("max_near_miss_rate %v is outside 0..1", p.MaxNearMissRate)
+	}
+	return nil
+}
+
+// lossGenerator draws losing outcomes under a loss policy
+type lossGenerator struct {
+	policy LossPolicy
+	strips [][]string
+
+	// ChanceRate is the share of reel-strip losses that are near misses
+	chanceRate float64
+	// rate is the share of losses shown as near misses under the policy
+	rate float64
+
+	// curated losses split by whether they are near misses
+	nearMisses [][]string
+	others     [][]string
+	// acceptNearMiss is the probability a near miss drawn from the strips is kept
+	acceptNearMiss float64
+}
+
+// resolveLossPolicy fills in an empty mode: the definition's, or curated
+// when losses are listed and reel strips otherwise
+func (d *Definition) resolveLossPolicy(policy LossPolicy) LossPolicy {
+	if policy.Mode == "" {
+		policy.Mode = d.LossPolicy.Mode
+	}
+	if policy.Mode == "" {
+		policy.Mode = PolicyReelStrip
+		if len(d.LosingReels) > 0 {
+			policy.Mode = PolicyCurated
+		}
+	}
+	return policy
+}
+
+// newLossGenerator prepares the generator for policy
+func newLossGenerator(d *Definition, policy LossPolicy) (*lossGenerator, error) {
+	policy = d.resolveLossPolicy(policy)
+	if err := policy.validate(d); err != nil {
+		return nil, err
+	}
+
+	g := &lossGenerator{policy: policy, strips: d.strips()}
+	g.chanceRate = d.chanceNearMissRate(g.strips)
+	limit := 1.0
+	if policy.MaxNearMissRate > 0 {
+		limit = policy.MaxNearMissRate
+	}
+	if policy.CapAtChance {
+		limit = min(limit, g.chanceRate)
+	}
+
+	switch policy.Mode {
+	case PolicyCurated:
+		for _, reels := range d.LosingReels {
+			if d.nearMiss(reels) {
+				g.nearMisses = append(g.nearMisses, reels)
+			} else {
+				g.others = append(g.others, reels)
+			}
+		}
+		g.rate = min(float64(len(g.nearMisses))/float64(len(d.LosingReels)), limit)
+		if g.rate < 1 && len(g.others) == 0 {
+			return nil, fmt.Errorf("policy %s: every listed loss is a near miss", policy)
+		}
+	case PolicyReelStrip:
+		g.rate = min(g.chanceRate, limit)
+		if g.rate < 1 && g.chanceRate == 1 {
+			return nil, fmt.Errorf("policy %s: every reel-strip loss is a near miss", policy)
+		}
+		g.acceptNearMiss = 1
+		if g.chanceRate > g.rate {
+			g.acceptNearMiss = g.rate * (1 - g.chanceRate) / (g.chanceRate * (1 - g.rate))
+		}
+	}
+	return g, nil
+}
+
+// draw returns a losing outcome
+func (g *lossGenerator) draw(d *Definition) []string {
+	if g.policy.Mode == PolicyCurated {
+		pool := g.others
+		if len(g.nearMisses) > 0 && rand.Float64() < g.rate {
+			pool = g.nearMisses
+		}
+		return slices.Clone(pool[rand.Intn(len(pool))])
+	}
+
+	reels := make([]string, d.Reels)
+	for range generateAttempts {
+		for i, strip := range g.strips {
+			reels[i] = strip[rand.Intn(len(strip))]
+		}
+		if d.pays(reels) {
+			continue
+		}
+		if d.nearMiss(reels) && rand.Float64() >= g.acceptNearMiss {
+			continue
+		}
+		return reels
+	}
+	return d.fallbackLoss()
+}
+
+// strips returns the reel strips, or when the definition lists none, one
+// stop for every symbol and the blank on each reel
+func (d *Definition) strips() [][]string {
+	if len(d.ReelStrips) > 0 {
+		return d.ReelStrips
+	}
+	stops := slices.Clone(d.Symbols)
+	if d.Blank != "" {
+		stops = append(stops, d.Blank)
+	}
+	strips := make([][]string, d.Reels)
+	for i := range strips {
+		strips[i] = stops
+	}
+	return strips
+}
+
+// chanceNearMissRate measures the share of reel-strip losses that are near
+// misses, exactly for small games and by sampling for large ones
+func (d *Definition) chanceNearMissRate(strips [][]string) float64 {
+	combinations := 1
+	for _, strip := range strips {
+		combinations *= len(strip)
+		if combinations > chanceEnumerationLimit {
+			break
+		}
+	}
+
+	var losses, nearMisses int
+	observe := func(reels []string) {
+		if d.pays(reels) {
+			return
+		}
+		losses++
+		if d.nearMiss(reels) {
+			nearMisses++
+		}
+	}
+	reels := make([]string, len(strips))
+	if combinations <= chanceEnumerationLimit {
+		stops := make([]int, len(strips))
+		for {
+			for i, stop := range stops {
+				reels[i] = strips[i][stop]
+			}
+			observe(reels)
+			i := 0
+			for ; i < len(stops); i++ {
+				if stops[i]++; stops[i] < len(strips[i]) {
+					break
+				}
+				stops[i] = 0
+			}
+			if i == len(stops) {
+				break
+			}
+		}
+	} else {
+		r := rand.New(rand.NewSource(1))
+		for range chanceSamples {
+			for i, strip := range strips {
+				reels[i] = strip[r.Intn(len(strip))]
+			}
+			observe(reels)
+		}
+	}
+	if losses == 0 {
+		return 0
+	}
+	return float64(nearMisses) / float64(losses)
+}
+
+// nearMiss reports whether reels fall one reel short of an exact or any pay
+func (d *Definition) nearMiss(reels []string) bool {
+	if len(reels) < 2 {
+		return false
+	}
+	for _, pay := range d.Pays {
+		matched := 0
+		switch pay.Match {
+		case MatchExact:
+			for i, symbol := range reels {
+				if i < len(pay.Symbols) && symbol == pay.Symbols[i] {
+					matched++
+				}
+			}
+		case MatchAny:
+			for _, symbol := range reels {
+				if slices.Contains(pay.Symbols, symbol) {
+					matched++
+				}
+			}
+		default:
+			continue
+		}
+		if matched == len(reels)-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// pays reports whether reels pay at any bet level
+func (d *Definition) pays(reels []string) bool {
+	for i := range d.BetLevels {
+		if _, ok := d.bestPay(reels, i); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackLoss is shown when no loss could be drawn: all blanks, or the
+// first listed loss
+func (d *Definition) fallbackLoss() []string {
+	if d.Blank == "" {
+		return slices.Clone(d.LosingReels[0])
+	}
+	reels := make([]string, d.Reels)
+	for i := range reels {
+		reels[i] = d.Blank
+	}
+	return reels
+}
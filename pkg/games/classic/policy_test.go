@@ -0,0 +1,84 @@
+package classic
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNearMiss(t *testing.T) {
+	game := loadFruitMachine(t)
+	for _, tc := range []struct {
+		reels    []string
+		nearMiss bool
+	}{
+		{[]string{"Red7", "Red7", "Red7", "Red7", "Lemon"}, true},   // one short of Red7 x5 and ANY 7
+		{[]string{"Red7", "Blue7", "BLANK", "Blue7", "Red7"}, true}, // one short of ANY 7
+		{[]string{"Red7", "Red7", "Lemon", "Bell", "Red7"}, false},
+		{[]string{"Red7", "Red7", "Red7", "Red7", "Red7"}, false}, // a win, not a miss
+		{[]string{"Lemon", "Bell", "Lemon", "Bell", "Lemon"}, false},
+	} {
+		if got := game.NearMiss(tc.reels); got != tc.nearMiss {
+			t.Errorf("NearMiss(%v) = %v, want %v", tc.reels, got, tc.nearMiss)
+		}
+	}
+}
+
+func TestLossPolicies(t *testing.T) {
+	base := loadFruitMachine(t)
+	if _, err := base.WithLossPolicy(LossPolicy{Mode: PolicyCurated}); err == nil {
+		t.Error("curated policy accepted without losing_reels")
+	}
+	if _, err := base.WithLossPolicy(LossPolicy{Mode: "rigged"}); err == nil {
+		t.Error("unknown policy accepted")
+	}
+
+	// Every loss these strips show is four 7s and a Lemon, one short of ANY 7
+	nearMissStrips, err := Load([]byte(strings.Replace(fruitMachine, `"reels": 5,`,
+		`"reels": 5, "reel_strips": [["Red7"], ["Red7"], ["Red7"], ["Red7"], ["Red7", "Lemon"]],`, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nearMissStrips.WithLossPolicy(LossPolicy{Mode: PolicyReelStrip}); err != nil {
+		t.Errorf("uncapped policy on near-miss strips: %v", err)
+	}
+	for _, policy := range []LossPolicy{
+		{Mode: PolicyReelStrip, MaxNearMissRate: 0.5},
+		{Mode: PolicyReelStrip, MaxNearMissRate: 0.5, CapAtChance: true},
+	} {
+		if _, err := nearMissStrips.WithLossPolicy(policy); err == nil {
+			t.Errorf("%s accepted on strips whose every loss is a near miss", policy)
+		}
+	}
+
+	const draws = 50_000
+	for _, policy := range []LossPolicy{
+		{Mode: PolicyReelStrip},
+		{Mode: PolicyReelStrip, MaxNearMissRate: 0.01},
+		{Mode: PolicyReelStrip, CapAtChance: true},
+	} {
+		game, err := base.WithLossPolicy(policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chance, expected := game.NearMissRates()
+		if expected > chance || (policy.MaxNearMissRate > 0 && expected > policy.MaxNearMissRate) {
+			t.Fatalf("%s: expected rate %v above its limits (chance %v)", policy, expected, chance)
+		}
+
+		nearMisses := 0
+		for range draws {
+			reels := game.LosingReels()
+			if game.def.pays(reels) {
+				t.Fatalf("%s: losing reels %v pay", policy, reels)
+			}
+			if game.NearMiss(reels) {
+				nearMisses++
+			}
+		}
+		measured := float64(nearMisses) / draws
+		if tolerance := 4 * math.Sqrt(expected*(1-expected)/draws); math.Abs(measured-expected) > tolerance+1e-3 {
+			t.Errorf("%s: measured near-miss rate %v, expected %v", policy, measured, expected)
+		}
+	}
+}
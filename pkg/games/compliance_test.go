@@ -0,0 +1,71 @@
+package games
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/compliance"
+)
+
+func TestEnforceProfile(t *testing.T) {
+	strict := &compliance.Profile{
+		Name:            "strict",
+		MaxBet:          5,
+		MinSpinInterval: compliance.Duration(time.Hour),
+	}
+	open := &compliance.Profile{Name: "open", AutoplayAllowed: true}
+
+	for _, tt := range []struct {
+		name       string
+		profile    *compliance.Profile
+		req        SpinRequest
+		spins      int  // spins sent; the last one is checked
+		begun      bool // whether an allowed spin began its round, recording it
+		wantStatus int
+		wantCode   string
+	}{
+		{"no profile", nil, SpinRequest{BetAmount: 1000, Autoplay: true}, 2, true, http.StatusOK, ""},
+		{"allowed", open, SpinRequest{BetAmount: 1000, Autoplay: true}, 1, true, http.StatusOK, ""},
+		{"autoplay disabled", strict, SpinRequest{BetAmount: 1, Autoplay: true}, 1, true, http.StatusForbidden, "autoplay_disabled"},
+		{"bet at the maximum", strict, SpinRequest{BetAmount: 5}, 1, true, http.StatusOK, ""},
+		{"bet above the maximum", strict, SpinRequest{BetAmount: 5.01}, 1, true, http.StatusForbidden, "compliance_max_bet"},
+		{"spin too soon", strict, SpinRequest{BetAmount: 1}, 2, true, http.StatusTooManyRequests, "rate_limited"},
+		{"spin after one that began no round", strict, SpinRequest{BetAmount: 1}, 2, false, http.StatusOK, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := &RouteGroup{pacer: compliance.NewPacer()}
+			app := fiber.New()
+			app.Post("/spin", func(c *fiber.Ctx) error {
+				if done, err := rg.enforceProfile(c, "slot", tt.profile, "1001\x00p1", tt.req); done {
+					return err
+				}
+				if tt.begun && tt.profile != nil {
+					rg.pacer.Record("1001\x00p1", time.Duration(tt.profile.MinSpinInterval), time.Now())
+				}
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			var status int
+			var body SpinResponse
+			for range tt.spins {
+				resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/spin", nil))
+				if err != nil {
+					t.Fatal(err)
+				}
+				status, body = resp.StatusCode, SpinResponse{}
+				if status != http.StatusOK {
+					json.NewDecoder(resp.Body).Decode(&body)
+				}
+				resp.Body.Close()
+			}
+			if status != tt.wantStatus || body.Code != tt.wantCode {
+				t.Errorf("spin = %d %q, want %d %q", status, body.Code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package games
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"syscall"
+	"time"
+)
+
+// disconnectPollInterval is how often a spin checks whether its client is still connected
+const disconnectPollInterval = 100 * time.Millisecond
+
+// watchDisconnect calls cancel once the client closes conn, checking every
+// disconnectPollInterval until the returned stop is called. fasthttp does not
+// read a connection while its handler runs, so the check only peeks at the
+// socket: a pipelined request stays queued for the server. Connections that
+// cannot be peeked at, such as in tests, are not watched.
+func watchDisconnect(conn net.Conn, cancel context.CancelFunc) (stop func()) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return func() {}
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if peerClosed(raw) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
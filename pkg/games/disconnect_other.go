@@ -0,0 +1,10 @@
+//go:build !unix
+
+package games
+
+import "syscall"
+
+// peerClosed cannot peek at sockets on this platform, so disconnects go unnoticed
+func peerClosed(syscall.RawConn) bool {
+	return false
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package games
+
+import (
+	"errors"
+	"syscall"
+)
+
+// peerClosed reports whether the other end has closed the connection, without
+// consuming any data waiting on it
+func peerClosed(raw syscall.RawConn) bool {
+	var buf [1]byte
+	var n int
+	var err error
+	if rerr := raw.Read(func(fd uintptr) bool {
+		n, _, err = syscall.Recvfrom(int(fd), buf[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		return true
+	}); rerr != nil {
+		return false
+	}
+	switch {
+	case err == nil:
+		return n == 0
+	case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+		return false
+	default:
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build unix
+
+package games
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// tcpPair returns both ends of a loopback TCP connection
+func tcpPair(t *testing.T) (server, client net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	client, err = net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err = ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	return server, client
+}
+
+func TestWatchDisconnect(t *testing.T) {
+	server, client := tcpPair(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := watchDisconnect(server, cancel)
+	defer stop()
+
+	client.Close()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("spin context not cancelled after the client disconnected")
+	}
+}
+
+func TestWatchDisconnectKeepsPipelinedRequest(t *testing.T) {
+	server, client := tcpPair(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := watchDisconnect(server, cancel)
+
+	if _, err := client.Write([]byte("next")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * disconnectPollInterval)
+	stop()
+	if ctx.Err() != nil {
+		t.Fatal("spin context cancelled while the client was connected")
+	}
+
+	// The next request is still there for the server to read
+	buf := make([]byte, 4)
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(server, buf); err != nil || string(buf) != "next" {
+		t.Errorf("read %q, %v after the watch; want the pipelined bytes", buf, err)
+	}
+}
@@ -0,0 +1,30 @@
+package funkykingkong
+
+import (
+	_ "embed"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/games/classic"
+)
+
+// definitionJSON is the game's reels, bets and paytables
+//
+//go:embed definition.json
+var definitionJSON []byte
+
+// engine plays the embedded definition
+var engine = mustLoad()
+
+// mustLoad loads the embedded definition; it only fails when the file
+// shipped with the binary is broken
+func mustLoad() *classic.Game {
+	game, err := classic.Load(definitionJSON)
+	if err != nil {
+		panic("funkykingkong: " + err.Error())
+	}
+	return game
+}
+
+// New returns Funky King Kong as played by the classic-slot engine
+func New() *classic.Game {
+	return engine
+}
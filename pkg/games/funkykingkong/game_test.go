@@ -0,0 +1,198 @@
+package funkykingkong
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+// reelSymbols is every value a reel position can hold, including blanks
+var reelSymbols = []string{"Kong", "Sun", "Palm", "Coconut", "Banana", "3BAR", "2BAR", "1BAR", "EMPTY"}
+
+// allTriples enumerates every possible three-reel result
+func allTriples() [][]string {
+	var triples [][]string
+	for _, a := range reelSymbols {
+		for _, b := range reelSymbols {
+			for _, c := range reelSymbols {
+				triples = append(triples, []string{a, b, c})
+			}
+		}
+	}
+	return triples
+}
+
+func TestCalculateWinNeverPanics(t *testing.T) {
+	property := func(reels []string, betLevel int, internalMultiplier int) bool {
+		CalculateWin(reels, betLevel, internalMultiplier)
+		return true
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+
+	// Bet levels just outside the valid range must not index past the paytable
+	for _, level := range []int{-1, 0, 4, 100} {
+		if win, combination := CalculateWin([]string{"Kong", "Kong", "Kong"}, level, 10); win != 0 || combination != "" {
+			t.Errorf("level %d: expected no win, got %f %q", level, win, combination)
+		}
+	}
+}
+
+func TestCalculateWinEmptyPositionsNeverPay(t *testing.T) {
+	for _, reels := range allTriples() {
+		hasEmpty := reels[0] == "EMPTY" || reels[1] == "EMPTY" || reels[2] == "EMPTY"
+		if !hasEmpty {
+			continue
+		}
+		for _, level := range ValidBetLevels {
+			if win, _ := CalculateWin(reels, level, 25); win != 0 {
+				t.Errorf("%v at level %d: expected no win, got %f", reels, level, win)
+			}
+		}
+	}
+}
+
+func TestCalculateWinExactBarTriplePaysOwnLine(t *testing.T) {
+	for _, bar := range []string{"1BAR", "2BAR", "3BAR"} {
+		reels := []string{bar, bar, bar}
+		for _, level := range ValidBetLevels {
+			win, combination := CalculateWin(reels, level, 1)
+			expected := bar + " " + bar + " " + bar
+			if combination != expected {
+				t.Errorf("%v at level %d: expected combination %q, got %q", reels, level, expected, combination)
+			}
+			if anyBar, _ := CalculateWin([]string{"1BAR", "2BAR", "3BAR"}, level, 1); win <= anyBar {
+				t.Errorf("%v at level %d: exact triple %f should pay more than ANY 3X BAR %f", reels, level, win, anyBar)
+			}
+		}
+	}
+}
+
+// Mixed bars pay ANY 3X BAR, so 1BAR 2BAR 1BAR, once listed as a loss, was
+// replaced there by 1BAR Palm 1BAR
+func TestMixedBarsPayAnyBar(t *testing.T) {
+	for _, reels := range [][]string{{"1BAR", "2BAR", "1BAR"}, {"3BAR", "3BAR", "2BAR"}} {
+		for _, level := range ValidBetLevels {
+			if win, combination := CalculateWin(reels, level, 1); win == 0 || combination != "ANY 3X BAR" {
+				t.Errorf("%v at level %d = %f %q, want a win on ANY 3X BAR", reels, level, win, combination)
+			}
+		}
+	}
+	if win, _ := CalculateWin([]string{"1BAR", "Palm", "1BAR"}, 1, 1); win != 0 {
+		t.Errorf("1BAR Palm 1BAR pays %f, want a loss", win)
+	}
+}
+
+func TestLosingReelsNeverPay(t *testing.T) {
+	for i := 0; i < 2000; i++ {
+		reels := GenerateLosingReels()
+		if len(reels) != 3 {
+			t.Fatalf("expected 3 reels, got %v", reels)
+		}
+		for _, level := range ValidBetLevels {
+			if win, combination := CalculateWin(reels, level, 25); win != 0 {
+				t.Fatalf("losing reels %v pay %f (%s) at level %d", reels, win, combination, level)
+			}
+		}
+	}
+}
+
+func TestWinningReelsAlwaysPay(t *testing.T) {
+	for i := 0; i < 2000; i++ {
+		reels := GenerateWinningReels()
+		if len(reels) != 3 {
+			t.Fatalf("expected 3 reels, got %v", reels)
+		}
+		for _, level := range ValidBetLevels {
+			for _, multiplier := range BetAmountToMultiplier[level] {
+				if win, _ := CalculateWin(reels, level, multiplier); win <= 0 {
+					t.Fatalf("winning reels %v pay nothing at level %d, multiplier %d", reels, level, multiplier)
+				}
+			}
+		}
+	}
+}
+
+func TestPayoutsIncreaseWithBetLevel(t *testing.T) {
+	for _, reels := range allTriples() {
+		previous, _ := CalculateWin(reels, ValidBetLevels[0], 1)
+		if previous == 0 {
+			continue
+		}
+		for _, level := range ValidBetLevels[1:] {
+			win, _ := CalculateWin(reels, level, 1)
+			if win <= previous {
+				t.Errorf("%v: level %d pays %f, not more than %f", reels, level, win, previous)
+			}
+			previous = win
+		}
+	}
+}
+
+func TestValidateBetAmountMatchesMultiplierTable(t *testing.T) {
+	for level, amounts := range BetAmountToMultiplier {
+		for amount, multiplier := range amounts {
+			if !ValidateBetAmount(amount, level) {
+				t.Errorf("%f at level %d should be valid", amount, level)
+			}
+			if got := GetInternalMultiplier(amount, level); got != multiplier {
+				t.Errorf("%f at level %d: expected multiplier %d, got %d", amount, level, multiplier, got)
+			}
+		}
+	}
+
+	property := func(amount float64, level int) bool {
+		valid := ValidateBetAmount(amount, level)
+		_, listed := BetAmountToMultiplier[level][amount]
+		return valid == listed && (!valid || ValidateBetLevel(level))
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzCalculateWin(f *testing.F) {
+	f.Add("Kong", "Kong", "Kong", 1, 10)
+	f.Add("1BAR", "2BAR", "3BAR", 3, 25)
+	f.Add("1BAR", "1BAR", "1BAR", 2, 1)
+	f.Add("EMPTY", "Kong", "Kong", 1, 5)
+	f.Add("Sun", "Sun", "Sun", 0, 1)
+	f.Add("Palm", "Palm", "Palm", 4, -1)
+
+	f.Fuzz(func(t *testing.T, a, b, c string, betLevel int, internalMultiplier int) {
+		reels := []string{a, b, c}
+		win, combination := CalculateWin(reels, betLevel, internalMultiplier)
+		if win < 0 {
+			t.Fatalf("%v: negative win %f", reels, win)
+		}
+		if (win == 0) != (combination == "") {
+			t.Fatalf("%v: win %f does not agree with combination %q", reels, win, combination)
+		}
+		if win > 0 && !ValidateBetLevel(betLevel) {
+			t.Fatalf("%v: level %d is invalid but pays %f", reels, betLevel, win)
+		}
+	})
+}
+
+func FuzzValidateBetAmount(f *testing.F) {
+	f.Add(0.1, 1)
+	f.Add(0.75, 3)
+	f.Add(0.0, 0)
+	f.Add(-0.5, 2)
+	f.Add(0.30000000000000004, 3)
+
+	f.Fuzz(func(t *testing.T, amount float64, betLevel int) {
+		if !ValidateBetAmount(amount, betLevel) {
+			return
+		}
+		if !ValidateBetLevel(betLevel) {
+			t.Fatalf("amount %f accepted for invalid level %d", amount, betLevel)
+		}
+		if amount <= 0 {
+			t.Fatalf("non-positive amount %f accepted at level %d", amount, betLevel)
+		}
+		if GetInternalMultiplier(amount, betLevel) <= 0 {
+			t.Fatalf("amount %f at level %d has no multiplier", amount, betLevel)
+		}
+	})
+}
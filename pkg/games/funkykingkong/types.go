@@ -1,5 +1,7 @@
 package funkykingkong
 
+import "github.com/JILI-GAMES/b_backend_games11/pkg/games"
+
 // Symbol represents a symbol on the reels
 type Symbol string
 
@@ -14,23 +16,8 @@ const (
 	Symbol1BAR    Symbol = "1BAR"
 )
 
-// SpinRequest represents the request body for the /spin endpoint
-type SpinRequest struct {
-	ClientID  string  `json:"client_id"`
-	GameID    string  `json:"game_id"`
-	PlayerID  string  `json:"player_id"`
-	BetID     string  `json:"bet_id"`
-	BetAmount float64 `json:"bet_amount"`
-	BetLevel  int     `json:"bet_level"` // 1, 2, or 3 (paytable selection)
-}
-
-// SpinResponse represents the response body for the /spin endpoint
-type SpinResponse struct {
-	Status             string   `json:"status"`
-	Message            string   `json:"message"`
-	Reels              []string `json:"reels"`
-	WinAmount          float64  `json:"win_amount"`
-	WinningCombination string   `json:"winning_combination"`
-	PaytableUsed       int      `json:"paytable_used"`
-	BetLevel           int      `json:"bet_level"`
-}
+// SpinRequest and SpinResponse are the shared spin API types
+type (
+	SpinRequest  = games.SpinRequest
+	SpinResponse = games.SpinResponse
+)
@@ -0,0 +1,42 @@
+package funkykingkong
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func FuzzSpinRequestJSON(f *testing.F) {
+	f.Add([]byte(`{"client_id":"1","game_id":"funkykingkong","player_id":"22","bet_id":"abc","bet_amount":0.1,"bet_level":1}`))
+	f.Add([]byte(`{"bet_amount":"0.1","bet_level":"1"}`))
+	f.Add([]byte(`{"bet_amount":1e309,"bet_level":9223372036854775808}`))
+	f.Add([]byte(`{"bet_level":-1,"bet_amount":-0.0}`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var req SpinRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return
+		}
+
+		// Validation must be total over anything that decodes
+		if ValidateBetLevel(req.BetLevel) && ValidateBetAmount(req.BetAmount, req.BetLevel) {
+			if win, _ := CalculateWin(GenerateWinningReels(), req.BetLevel, GetInternalMultiplier(req.BetAmount, req.BetLevel)); win <= 0 {
+				t.Fatalf("valid request %+v cannot win", req)
+			}
+		}
+
+		// Decoded requests survive a round trip unchanged
+		encoded, err := json.Marshal(req)
+		if err != nil {
+			t.Fatalf("re-encoding %+v: %v", req, err)
+		}
+		var again SpinRequest
+		if err := json.Unmarshal(encoded, &again); err != nil {
+			t.Fatalf("decoding %s: %v", encoded, err)
+		}
+		if again != req {
+			t.Fatalf("round trip changed request: %+v != %+v", again, req)
+		}
+	})
+}
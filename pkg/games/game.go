@@ -0,0 +1,72 @@
+package games
+
+import "fmt"
+
+// Game is one slot game served by the shared spin pipeline. The pipeline
+// handles sessions, limits, settings, the RNG call and bookkeeping; a game
+// only knows its own definition.
+type Game interface {
+	// Info describes the game; it is read once, when the game is registered
+	Info() Info
+	// ValidateBet returns a *BetError when the level and amount cannot be played
+	ValidateBet(level int, amount float64) error
+	// Multiplier returns the credit multiplier a valid amount plays at level
+	Multiplier(amount float64, level int) (int, bool)
+	// WinningReels generates reels that are guaranteed to pay
+	WinningReels() []string
+	// LosingReels generates reels that are guaranteed not to pay
+	LosingReels() []string
+	// Evaluate returns the win and the winning combination for reels
+	Evaluate(reels []string, level int, amount float64) (float64, string)
+	// CheckDefinition reports whether the paytables and bet tables are usable
+	CheckDefinition() error
+}
+
+// Info describes a game for routing, listings and round records
+type Info struct {
+	ID                string            `json:"id"`
+	Name              string            `json:"name"`
+	DefinitionVersion string            `json:"definition_version"`
+	RTP               float64           `json:"rtp"` // theoretical return to player, percent
+	BetLevels         []int             `json:"bet_levels"`
+	BetAmounts        map[int][]float64 `json:"bet_amounts"` // valid amounts per bet level
+	Paytable          map[string][]int  `json:"paytable"`    // payout per bet level for each combination
+}
+
+// BetError is a bet a game refuses to play
+type BetError struct {
+	Code    string // rejection reason recorded in metrics
+	Message string
+}
+
+func (e *BetError) Error() string {
+	return e.Message
+}
+
+// InvalidBetLevel is the BetError for a level outside levels
+func InvalidBetLevel(levels []int) *BetError {
+	return &BetError{
+		Code:    "invalid_bet_level",
+		Message: fmt.Sprintf("Invalid bet level, allowed values are %s", joinInts(levels)),
+	}
+}
+
+// InvalidBetAmount is the BetError for an amount not offered at level
+func InvalidBetAmount(level int, amounts []float64) *BetError {
+	return &BetError{
+		Code:    "invalid_bet_amount",
+		Message: fmt.Sprintf("Invalid bet amount for level x%d, valid amounts: %v", level, amounts),
+	}
+}
+
+// joinInts formats values as "1, 2, 3"
+func joinInts(values []int) string {
+	s := ""
+	for i, v := range values {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprint(v)
+	}
+	return s
+}
@@ -0,0 +1,785 @@
+package games
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/auth"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/compliance"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/logging"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/metrics"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/resilience"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/responsible"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/rounds"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/session"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/tracing"
+)
+
+// SpinHandler returns the handler spinning game id on its active definition
+func (rg *RouteGroup) SpinHandler(id string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		game, info, ok := rg.Games.Active(id)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "game not found")
+		}
+		return rg.spin(c, game, info)
+	}
+}
+
+// spin plays one round of game
+func (rg *RouteGroup) spin(c *fiber.Ctx, game Game, info Info) error {
+	startedAt := time.Now()
+	gameID := info.ID
+	spanCtx, span := tracing.Start(c.UserContext(), "SpinHandler", attribute.String("game.id", gameID))
+	defer func() {
+		status := c.Response().StatusCode()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, fiber.ErrInternalServerError.Message)
+		}
+		span.End()
+	}()
+	c.SetUserContext(spanCtx)
+
+	// Shed abusive callers before doing any work for them
+	if limited, err := rg.limitIP(c, gameID); limited {
+		return err
+	}
+
+	// No new rounds start while the game is under maintenance
+	if message, down := rg.Maintenance.Blocks(gameID); down {
+		metrics.RecordRejection(gameID, "maintenance")
+		return c.Status(fiber.StatusServiceUnavailable).JSON(SpinResponse{
+			Status:  "error",
+			Code:    "maintenance",
+			Message: message,
+		})
+	}
+
+	// Parse the request
+	var req SpinRequest
+	if err := c.BodyParser(&req); err != nil {
+		slog.WarnContext(c.UserContext(), "Invalid request body", slog.Any("error", err))
+		metrics.RecordRejection(gameID, "invalid_body")
+		return c.Status(fiber.StatusBadRequest).JSON(SpinResponse{
+			Status:  "error",
+			Message: "Invalid request body",
+		})
+	}
+	if req.GameID == "" {
+		req.GameID = gameID
+	}
+	if req.GameID != gameID {
+		metrics.RecordRejection(gameID, "game_mismatch")
+		return c.Status(fiber.StatusBadRequest).JSON(SpinResponse{
+			Status:  "error",
+			Code:    "game_mismatch",
+			Message: "game_id does not match the game being spun",
+		})
+	}
+
+	// With player sessions enabled, identity comes from the token, not the body
+	claims, done, err := rg.authenticatePlayer(c, gameID, &req)
+	if done {
+		return err
+	}
+
+	// Every later log line for this spin carries its identifiers
+	c.SetUserContext(logging.With(c.UserContext(),
+		slog.String(logging.KeyBetID, req.BetID),
+		slog.String(logging.KeyPlayerID, req.PlayerID),
+		slog.String(logging.KeyClientID, req.ClientID),
+		slog.String(logging.KeyGameID, req.GameID),
+	))
+
+	// Validate the request
+	if req.ClientID == "" || req.PlayerID == "" || req.BetID == "" {
+		slog.WarnContext(c.UserContext(), "Validation error: ClientID, PlayerID, BetID, GameID must not be empty")
+		metrics.RecordRejection(gameID, "missing_fields")
+		return c.Status(fiber.StatusBadRequest).JSON(SpinResponse{
+			Status:  "error",
+			Message: "ClientID, PlayerID, BetID, GameID must not be empty",
+		})
+	}
+
+	// Per-player and per-operator request rates
+	playerKey := responsible.PlayerKey(req.ClientID, req.PlayerID)
+	opKey := operatorKey(c, req, claims)
+	if ok, retryAfter := rg.Limits.Player.Allow(playerKey); !ok {
+		return rateLimited(c, gameID, "player", retryAfter)
+	}
+	if ok, retryAfter := rg.Limits.Operator.Allow(opKey); !ok {
+		return rateLimited(c, gameID, "operator", retryAfter)
+	}
+
+	// An authenticated operator may only spin for its own clients
+	if done, err := clientNotAllowed(c, gameID, req.ClientID); done {
+		return err
+	}
+
+	// A retried bet gets the response of the spin that already settled it
+	if replayed, err := rg.replay(c, gameID, req); replayed || err != nil {
+		return err
+	}
+
+	// A spin without a bet plays the player's current selection; one with a
+	// bet becomes the current selection once the spin is played
+	selectionKey := selectionKey(gameID, req.ClientID, req.PlayerID)
+	if req.BetLevel == 0 && req.BetAmount == 0 {
+		current, err := rg.Selections.Current(c.UserContext(), selectionKey, game, info)
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Error reading bet selection", slog.Any("error", err))
+			return c.Status(fiber.StatusInternalServerError).JSON(SpinResponse{
+				Status:  "error",
+				Message: "Internal server error",
+			})
+		}
+		req.BetLevel, req.BetAmount = current.Bet(game, info)
+	}
+	if err := game.ValidateBet(req.BetLevel, req.BetAmount); err != nil {
+		return invalidBet(c, gameID, req, err)
+	}
+
+	// Select the environment for this request; every later line records it
+	env, ok := rg.environmentFor(c, claims)
+	if !ok {
+		slog.WarnContext(c.UserContext(), "Session environment no longer configured", slog.String("environment", claims.Environment))
+		metrics.RecordRejection(gameID, "session_invalid")
+		return c.Status(fiber.StatusUnauthorized).JSON(SpinResponse{
+			Status:  "error",
+			Code:    "session_invalid",
+			Message: "Session environment is not available",
+		})
+	}
+	c.SetUserContext(logging.With(c.UserContext(), slog.String(logging.KeyEnvironment, env.Name)))
+	span.SetAttributes(attribute.String("environment", env.Name))
+
+	// A player may only have a limited number of rounds in progress
+	release, ok := rg.Limits.PlayerSpins.Acquire(playerKey)
+	if !ok {
+		return rateLimited(c, gameID, "concurrent_spins", time.Second)
+	}
+	defer release()
+
+	// Responsible-gambling limits are checked before the bet is taken
+	if rg.Responsible != nil {
+		if err := rg.Responsible.Check(c.UserContext(), opKey, playerKey, req.BetAmount, time.Now()); err != nil {
+			return limitedByResponsibleGambling(c, gameID, err)
+		}
+	}
+
+	// The operator's compliance profile bounds the bet, the pace and features
+	profile := rg.Compliance.For(opKey)
+	if done, err := rg.enforceProfile(c, gameID, profile, playerKey, req); done {
+		return err
+	}
+
+	// Outbound calls share one deadline derived from the request
+	ctx, cancel := rg.spinContext(c)
+	defer cancel()
+
+	// The round is created before any outbound call; one that does not reach
+	// the RNG's decision is voided
+	var currency, sessionID string
+	if claims != nil {
+		currency, sessionID = claims.Currency, claims.SessionID
+	}
+	round, err := rg.Rounds.Begin(ctx, store.Round{
+		BetID:             req.BetID,
+		ClientID:          req.ClientID,
+		PlayerID:          req.PlayerID,
+		OperatorID:        opKey,
+		GameID:            req.GameID,
+		Environment:       env.Name,
+		SessionID:         sessionID,
+		RequestID:         logging.RequestID(ctx),
+		DefinitionVersion: info.DefinitionVersion,
+		BetLevel:          req.BetLevel,
+		BetAmount:         req.BetAmount,
+		Currency:          currency,
+		StartedAt:         startedAt,
+	})
+	if err != nil {
+		return roundNotStarted(c, gameID, err)
+	}
+	// Only a spin that began a round counts towards the profile's spin interval
+	if profile != nil {
+		rg.pacer.Record(playerKey, time.Duration(profile.MinSpinInterval), time.Now())
+	}
+	voidReason := "spin_failed"
+	defer func() {
+		if round.Unresolved() {
+			if err := round.Void(ctx, voidReason); err != nil {
+				slog.ErrorContext(ctx, "Error voiding round", slog.String("reason", voidReason), slog.Any("error", err))
+			}
+		}
+	}()
+
+	// Call the Settings API to get RTP, bet limits and max win
+	gameSettings, err := env.Settings.GetSettings(ctx, req.ClientID, req.GameID, req.PlayerID)
+	if err != nil {
+		if ctx.Err() != nil {
+			voidReason = "cancelled"
+			return cancelledSpin(c, "settings", ctx.Err())
+		}
+		voidReason = "settings_unavailable"
+		slog.ErrorContext(ctx, "Error retrieving game settings", slog.Any("error", err))
+		if resilience.IsUnavailable(err) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(SpinResponse{
+				Status:  "error",
+				Message: "Game settings service is temporarily unavailable, please retry shortly",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(SpinResponse{
+			Status:  "error",
+			Message: "Failed to retrieve game settings: " + err.Error(),
+		})
+	}
+	slog.DebugContext(ctx, "Retrieved settings",
+		slog.Float64("rtp", gameSettings.RTP), slog.Any("bets", gameSettings.Bets), slog.Float64("max_win", gameSettings.MaxWin))
+
+	// The market's minimum RTP and maximum win apply whatever the operator configured
+	if profile != nil {
+		if profile.MinRTP > 0 && gameSettings.RTP < profile.MinRTP {
+			slog.ErrorContext(ctx, "Configured RTP is below the compliance minimum",
+				slog.Float64("rtp", gameSettings.RTP), slog.Float64("min_rtp", profile.MinRTP))
+			metrics.RecordRejection(gameID, "compliance_rtp")
+			voidReason = "compliance_rtp"
+			return c.Status(fiber.StatusForbidden).JSON(SpinResponse{
+				Status:  "error",
+				Code:    "compliance_rtp",
+				Message: "Game is not configured to meet this market's minimum RTP",
+			})
+		}
+		gameSettings.MaxWin = profile.CapWin(gameSettings.MaxWin)
+	}
+
+	// Enforce the operator's bet limits for this player
+	if !gameSettings.Bets.Allows(req.BetAmount) {
+		slog.WarnContext(ctx, "Validation error: bet amount not allowed by operator limits",
+			slog.Float64("bet_amount", req.BetAmount), slog.Any("bets", gameSettings.Bets))
+		metrics.RecordRejection(gameID, "operator_bet_limit")
+		voidReason = "operator_bet_limit"
+		return c.Status(fiber.StatusBadRequest).JSON(SpinResponse{
+			Status:  "error",
+			Message: "Bet amount is not allowed for this player",
+		})
+	}
+
+	// Generate guaranteed winning combination first
+	_, reelSpan := tracing.Start(ctx, "GenerateWinningReels")
+	winningReels := game.WinningReels()
+	potentialWin, winCombination := game.Evaluate(winningReels, req.BetLevel, req.BetAmount)
+	reelSpan.End()
+
+	// Cap the win before the RNG prices it, so the multiplier matches what can be paid
+	potentialWin, winCapped := gameSettings.CapWin(potentialWin)
+	if winCapped {
+		slog.InfoContext(ctx, "Win capped at operator maximum", slog.Float64("max_win", potentialWin))
+	}
+
+	// Calculate payout multiplier for RNG
+	payoutMultiplier := 0.0
+	if req.BetAmount > 0 {
+		payoutMultiplier = potentialWin / req.BetAmount
+	}
+
+	slog.DebugContext(ctx, "Generated winning reels",
+		slog.Any("reels", winningReels),
+		slog.Float64("potential_win", potentialWin),
+		slog.String("combination", winCombination),
+		slog.Float64("payout_multiplier", payoutMultiplier),
+	)
+
+	// The bet is taken before the RNG decides the round
+	if err := round.Wager(ctx); err != nil {
+		return roundFailed(c, "wager", err)
+	}
+
+	// Call the RNG API
+	ip := c.IP()
+	userAgent := c.Get("User-Agent")
+
+	rngResp, err := env.RNG.GetOutcome(ctx, req.ClientID, req.GameID, req.PlayerID, req.BetID, gameSettings.RTP, payoutMultiplier, req.BetAmount, ip, userAgent)
+	if err != nil {
+		if ctx.Err() != nil {
+			voidReason = "cancelled"
+			return cancelledSpin(c, "rng", ctx.Err())
+		}
+		voidReason = "rng_unavailable"
+		slog.ErrorContext(ctx, "Error retrieving RNG outcome", slog.Any("error", err))
+		if resilience.IsUnavailable(err) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(SpinResponse{
+				Status:  "error",
+				Message: "RNG service is temporarily unavailable, please retry shortly",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(SpinResponse{
+			Status:  "error",
+			Message: "Failed to retrieve RNG outcome: " + err.Error(),
+		})
+	}
+
+	// Determine final result based on RNG outcome
+	var finalReels []string
+	var finalWinAmount float64
+	var finalWinCombination string
+	var finalWinCapped bool
+
+	if rngResp.PrefOutcome == "win" {
+		// RNG says win - use the winning combination
+		finalReels = winningReels
+		finalWinAmount = potentialWin
+		finalWinCombination = winCombination
+		finalWinCapped = winCapped
+	} else {
+		// RNG says loss - force a losing combination
+		_, reelSpan := tracing.Start(ctx, "GenerateLosingReels")
+		finalReels = game.LosingReels()
+		reelSpan.End()
+		finalWinAmount = 0
+		finalWinCombination = ""
+	}
+
+	// Record the decision before anything is shown or credited; a round left
+	// wagered is voided and its bet rolled back
+	rngJSON, err := json.Marshal(rngResp)
+	if err == nil {
+		err = round.Resolve(ctx, rounds.Outcome{
+			Outcome:     rngResp.PrefOutcome,
+			Reels:       finalReels,
+			Combination: finalWinCombination,
+			WinAmount:   finalWinAmount,
+			WinCapped:   finalWinCapped,
+			RNGResponse: rngJSON,
+		})
+	}
+	if err != nil {
+		return roundFailed(c, "resolve", err)
+	}
+
+	slog.InfoContext(ctx, "Spin completed",
+		slog.String("outcome", rngResp.PrefOutcome),
+		slog.Int("bet_level", req.BetLevel),
+		slog.Float64("bet_amount", req.BetAmount),
+		slog.Any("reels", finalReels),
+		slog.Float64("win_amount", finalWinAmount),
+		slog.String("combination", finalWinCombination),
+		slog.String("ip", ip),
+		slog.String("user_agent", userAgent),
+	)
+
+	var realityCheck *responsible.RealityCheck
+	if rg.Responsible != nil {
+		// The RNG has decided the round by now, so a failure to count it is
+		// logged rather than failing the spin
+		realityCheck, err = rg.Responsible.Record(ctx, opKey, playerKey, req.BetAmount, finalWinAmount, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "Error recording responsible gambling totals", slog.Any("error", err))
+		}
+	}
+
+	// The bet was played, so it becomes the player's selection
+	if multiplier, ok := game.Multiplier(req.BetAmount, req.BetLevel); ok {
+		if err := rg.Selections.Set(ctx, selectionKey, Selection{Level: req.BetLevel, Multiplier: multiplier}); err != nil {
+			slog.ErrorContext(ctx, "Error saving bet selection", slog.Any("error", err))
+		}
+	}
+
+	metrics.RecordSpin(gameID, req.BetLevel, req.BetAmount, finalWinAmount, finalWinCombination)
+
+	span.SetAttributes(
+		attribute.String("bet.id", req.BetID),
+		attribute.Int("bet.level", req.BetLevel),
+		attribute.String("spin.outcome", rngResp.PrefOutcome),
+	)
+
+	// Build the response
+	response := SpinResponse{
+		Status:             "success",
+		Message:            "",
+		Reels:              finalReels,
+		WinAmount:          finalWinAmount,
+		WinningCombination: finalWinCombination,
+		WinCapped:          finalWinCapped,
+		PaytableUsed:       req.BetLevel,
+		BetLevel:           req.BetLevel,
+		RealityCheck:       realityCheck,
+		Currency:           currency,
+	}
+
+	// The result is not shown before the minimum round duration
+	round.Hold(ctx)
+	if err := rg.settle(ctx, round, response); err != nil {
+		return undeliveredSpin(c, err)
+	}
+
+	return c.JSON(response)
+}
+
+// idempotentSpin is what is kept to answer a retried bet_id
+type idempotentSpin struct {
+	PlayerID string       `json:"player_id"`
+	Response SpinResponse `json:"response"`
+}
+
+// idempotencyKey identifies a bet across retries
+func idempotencyKey(clientID, betID string) string {
+	return "spin/" + clientID + "/" + betID
+}
+
+// replay answers a bet_id that was already settled with its original
+// response. It reports whether it wrote a response.
+func (rg *RouteGroup) replay(c *fiber.Ctx, gameID string, req SpinRequest) (bool, error) {
+	if rg.Store == nil {
+		return false, nil
+	}
+	var stored []byte
+	err := rg.Store.View(c.UserContext(), func(tx store.Tx) (err error) {
+		stored, err = tx.GetIdempotent(idempotencyKey(req.ClientID, req.BetID), time.Now())
+		return err
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	var previous idempotentSpin
+	if err == nil {
+		err = json.Unmarshal(stored, &previous)
+	}
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error reading idempotent response", slog.Any("error", err))
+		return true, c.Status(fiber.StatusInternalServerError).JSON(SpinResponse{
+			Status:  "error",
+			Message: "Internal server error",
+		})
+	}
+	if previous.PlayerID != req.PlayerID {
+		slog.WarnContext(c.UserContext(), "Bet ID reused by another player")
+		metrics.RecordRejection(gameID, "duplicate_bet_id")
+		return true, c.Status(fiber.StatusConflict).JSON(SpinResponse{
+			Status:  "error",
+			Code:    "duplicate_bet_id",
+			Message: "bet_id was already used",
+		})
+	}
+	slog.InfoContext(c.UserContext(), "Replaying settled spin")
+	c.Set("Idempotent-Replayed", "true")
+	return true, c.JSON(previous.Response)
+}
+
+// settle settles a resolved round, keeping the response to replay in the same
+// transaction. A failed write is retried a few times within settleTimeout,
+// which runs on past the spin's deadline since the round is already decided.
+// When every attempt fails the round stays resolved for the reconciler, marked
+// delivered so its win is not credited a second time. An error means not even
+// that mark was written: the response must not be returned, and the
+// reconciler credits the win instead.
+func (rg *RouteGroup) settle(ctx context.Context, round *rounds.Round, response SpinResponse) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), settleTimeout)
+	defer cancel()
+
+	attempt := 0
+	operation := func() error {
+		attempt++
+		err := round.Settle(ctx, nil, func(tx store.Tx, r store.Round) error {
+			return rg.keepReplay(tx, r, response)
+		})
+		if errors.Is(err, rounds.ErrInvalidTransition) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+	retry := backoff.NewExponentialBackOff()
+	retry.InitialInterval = settleRetryInterval
+	err := backoff.RetryNotify(operation, backoff.WithContext(backoff.WithMaxRetries(retry, settleRetries), ctx),
+		func(err error, wait time.Duration) {
+			slog.WarnContext(ctx, "Error settling round, retrying",
+				slog.Int("attempt", attempt), slog.Duration("wait", wait), slog.Any("error", err))
+		})
+	if err == nil {
+		return nil
+	}
+	slog.ErrorContext(ctx, "Error settling round", slog.Int("attempts", attempt), slog.Any("error", err))
+	return round.Deliver(ctx)
+}
+
+// Settling a round is retried this many times, starting this far apart, for
+// at most settleTimeout
+const (
+	settleRetries       = 3
+	settleRetryInterval = 50 * time.Millisecond
+	settleTimeout       = time.Second
+)
+
+// KeepReplay stores the response a retried bet_id gets for a round settled
+// outside a spin, such as by reconciliation
+func (rg *RouteGroup) KeepReplay(tx store.Tx, round store.Round) error {
+	return rg.keepReplay(tx, round, SpinResponse{
+		Status:             "success",
+		Reels:              round.Reels,
+		WinAmount:          round.WinAmount,
+		WinningCombination: round.Combination,
+		WinCapped:          round.WinCapped,
+		PaytableUsed:       round.BetLevel,
+		BetLevel:           round.BetLevel,
+		Currency:           round.Currency,
+	})
+}
+
+// keepReplay stores response for a settled round until IdempotencyTTL has passed
+func (rg *RouteGroup) keepReplay(tx store.Tx, round store.Round, response SpinResponse) error {
+	replay, err := json.Marshal(idempotentSpin{PlayerID: round.PlayerID, Response: response})
+	if err != nil {
+		return err
+	}
+	return tx.PutIdempotent(idempotencyKey(round.ClientID, round.BetID), replay, round.CompletedAt.Add(rg.IdempotencyTTL))
+}
+
+// roundNotStarted answers a spin whose round could not be created
+func roundNotStarted(c *fiber.Ctx, gameID string, err error) error {
+	switch {
+	case errors.Is(err, rounds.ErrRoundInProgress):
+		slog.WarnContext(c.UserContext(), "Round already in progress", slog.Any("reason", err))
+		metrics.RecordRejection(gameID, "round_in_progress")
+		return c.Status(fiber.StatusConflict).JSON(SpinResponse{
+			Status:  "error",
+			Code:    "round_in_progress",
+			Message: "Another round is in progress for this player",
+		})
+	case errors.Is(err, rounds.ErrRoundVoided):
+		slog.WarnContext(c.UserContext(), "Bet ID belongs to a voided round", slog.Any("reason", err))
+		metrics.RecordRejection(gameID, "round_voided")
+		return c.Status(fiber.StatusConflict).JSON(SpinResponse{
+			Status:  "error",
+			Code:    "round_voided",
+			Message: "The round for this bet_id was voided and its bet returned; place a new bet",
+		})
+	case errors.Is(err, store.ErrDuplicate):
+		slog.WarnContext(c.UserContext(), "Bet ID already played")
+		metrics.RecordRejection(gameID, "duplicate_bet_id")
+		return c.Status(fiber.StatusConflict).JSON(SpinResponse{
+			Status:  "error",
+			Code:    "duplicate_bet_id",
+			Message: "bet_id was already used",
+		})
+	default:
+		return roundFailed(c, "create", err)
+	}
+}
+
+// roundFailed answers a spin whose round transition could not be recorded
+func roundFailed(c *fiber.Ctx, stage string, err error) error {
+	slog.ErrorContext(c.UserContext(), "Error recording round transition", slog.String("stage", stage), slog.Any("error", err))
+	return c.Status(fiber.StatusInternalServerError).JSON(SpinResponse{
+		Status:  "error",
+		Message: "Internal server error",
+	})
+}
+
+// undeliveredSpin answers a resolved spin whose round could be neither settled
+// nor marked delivered. The operator must not pay it: the reconciler credits
+// the win through the wallet.
+func undeliveredSpin(c *fiber.Ctx, err error) error {
+	slog.ErrorContext(c.UserContext(), "Spin cancelled",
+		slog.String("status", "cancelled"),
+		slog.String("stage", "settle"),
+		slog.Any("reason", err),
+	)
+	return c.Status(fiber.StatusInternalServerError).JSON(SpinResponse{
+		Status:  "cancelled",
+		Message: "Spin result could not be recorded; it will be settled through the wallet",
+	})
+}
+
+// authenticatePlayer verifies the player's session token, when sessions are
+// enabled, and takes the client and player from it. It reports whether it
+// wrote a response.
+func (rg *RouteGroup) authenticatePlayer(c *fiber.Ctx, gameID string, req *SpinRequest) (*session.Claims, bool, error) {
+	if rg.Sessions == nil {
+		return nil, false, nil
+	}
+	verified, err := rg.Sessions.Verify(c.UserContext(), session.TokenFrom(c))
+	if err != nil && !session.IsTokenError(err) {
+		slog.ErrorContext(c.UserContext(), "Error checking session", slog.Any("error", err))
+		return nil, true, c.Status(fiber.StatusInternalServerError).JSON(SpinResponse{
+			Status:  "error",
+			Message: "Internal server error",
+		})
+	}
+	if err != nil {
+		slog.WarnContext(c.UserContext(), "Session rejected", slog.Any("error", err))
+		metrics.RecordRejection(gameID, session.Code(err))
+		return nil, true, c.Status(fiber.StatusUnauthorized).JSON(SpinResponse{
+			Status:  "error",
+			Code:    session.Code(err),
+			Message: err.Error(),
+		})
+	}
+	if verified.GameID != "" && req.GameID != verified.GameID {
+		metrics.RecordRejection(gameID, "session_game_mismatch")
+		return nil, true, c.Status(fiber.StatusForbidden).JSON(SpinResponse{
+			Status:  "error",
+			Code:    "session_game_mismatch",
+			Message: "Session was not issued for this game",
+		})
+	}
+	req.ClientID = verified.ClientID
+	req.PlayerID = verified.PlayerID
+	return &verified, false, nil
+}
+
+// enforceProfile applies a compliance profile's feature switches, maximum
+// bet and spin interval. It reports whether it wrote a response.
+func (rg *RouteGroup) enforceProfile(c *fiber.Ctx, gameID string, profile *compliance.Profile, playerKey string, req SpinRequest) (bool, error) {
+	if profile == nil {
+		return false, nil
+	}
+	refuse := func(code, message string) (bool, error) {
+		slog.WarnContext(c.UserContext(), "Spin refused by compliance profile",
+			slog.String("profile", profile.Name), slog.String("code", code))
+		metrics.RecordRejection(gameID, code)
+		return true, c.Status(fiber.StatusForbidden).JSON(SpinResponse{
+			Status:  "error",
+			Code:    code,
+			Message: message,
+		})
+	}
+	if req.Autoplay && !profile.AutoplayAllowed {
+		return refuse("autoplay_disabled", "Autoplay is not allowed in this market")
+	}
+	if profile.MaxBet > 0 && req.BetAmount > profile.MaxBet {
+		return refuse("compliance_max_bet", fmt.Sprintf("Bet exceeds this market's maximum of %v", profile.MaxBet))
+	}
+	if wait := rg.pacer.Wait(playerKey, time.Duration(profile.MinSpinInterval), time.Now()); wait > 0 {
+		return true, rateLimited(c, gameID, "spin_interval", wait)
+	}
+	return false, nil
+}
+
+// clientNotAllowed refuses an authenticated operator acting for a client that
+// is not its own. It reports whether it wrote a response.
+func clientNotAllowed(c *fiber.Ctx, gameID, clientID string) (bool, error) {
+	op, ok := auth.OperatorFrom(c.UserContext())
+	if !ok || op.Allows(clientID) {
+		return false, nil
+	}
+	slog.WarnContext(c.UserContext(), "Operator not allowed to act for client")
+	metrics.RecordRejection(gameID, "client_not_allowed")
+	return true, c.Status(fiber.StatusForbidden).JSON(SpinResponse{
+		Status:  "error",
+		Message: "Operator is not allowed to spin for this client_id",
+	})
+}
+
+// limitIP applies the per-IP limit, reporting whether it wrote a response.
+// An authenticated operator sends every player's requests from its own
+// servers, so its traffic is left to the operator limit instead.
+func (rg *RouteGroup) limitIP(c *fiber.Ctx, gameID string) (bool, error) {
+	if _, ok := auth.OperatorFrom(c.UserContext()); ok {
+		return false, nil
+	}
+	if ok, retryAfter := rg.Limits.IP.Allow(c.IP()); !ok {
+		return true, rateLimited(c, gameID, "ip", retryAfter)
+	}
+	return false, nil
+}
+
+// operatorKey identifies the operator a spin is charged to for rate limiting
+func operatorKey(c *fiber.Ctx, req SpinRequest, claims *session.Claims) string {
+	if claims != nil {
+		return claims.OperatorID
+	}
+	if op, ok := auth.OperatorFrom(c.UserContext()); ok {
+		return op.ID
+	}
+	return "client:" + req.ClientID
+}
+
+// limitedByResponsibleGambling answers a spin refused by a player's limits
+func limitedByResponsibleGambling(c *fiber.Ctx, gameID string, err error) error {
+	var violation *responsible.Violation
+	if !errors.As(err, &violation) {
+		slog.ErrorContext(c.UserContext(), "Error checking responsible gambling limits", slog.Any("error", err))
+		return c.Status(fiber.StatusInternalServerError).JSON(SpinResponse{
+			Status:  "error",
+			Message: "Internal server error",
+		})
+	}
+	slog.WarnContext(c.UserContext(), "Spin refused by responsible gambling limits",
+		slog.String("code", violation.Code), slog.String("reason", violation.Message))
+	metrics.RecordRejection(gameID, violation.Code)
+
+	message := violation.Message
+	if !violation.Until.IsZero() {
+		message += " (until " + violation.Until.UTC().Format(time.RFC3339) + ")"
+	}
+	return c.Status(fiber.StatusForbidden).JSON(SpinResponse{
+		Status:  "error",
+		Code:    violation.Code,
+		Message: message,
+	})
+}
+
+// invalidBet answers a spin whose bet the game refused
+func invalidBet(c *fiber.Ctx, gameID string, req SpinRequest, err error) error {
+	var betErr *BetError
+	if !errors.As(err, &betErr) {
+		return err
+	}
+	slog.WarnContext(c.UserContext(), "Validation error: "+betErr.Message,
+		slog.Float64("bet_amount", req.BetAmount), slog.Int("bet_level", req.BetLevel))
+	metrics.RecordRejection(gameID, betErr.Code)
+	return c.Status(fiber.StatusBadRequest).JSON(SpinResponse{
+		Status:  "error",
+		Message: betErr.Message,
+	})
+}
+
+// rateLimited answers a spin refused by a rate or concurrency limit
+func rateLimited(c *fiber.Ctx, gameID, scope string, retryAfter time.Duration) error {
+	seconds := max(1, int(math.Ceil(retryAfter.Seconds())))
+	slog.WarnContext(c.UserContext(), "Spin rate limited", slog.String("scope", scope), slog.Int("retry_after", seconds))
+	metrics.RecordRateLimited(gameID, scope)
+
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(SpinResponse{
+		Status:  "error",
+		Code:    "rate_limited",
+		Message: fmt.Sprintf("Too many spins, retry after %d seconds", seconds),
+	})
+}
+
+// cancelledSpin records a spin abandoned before completion and answers it.
+// The log line carries status "cancelled" and the stage reached: a spin
+// cancelled at the "rng" stage may already have been processed by the RNG
+// service, though its round is voided and the bet rolled back.
+func cancelledSpin(c *fiber.Ctx, stage string, cause error) error {
+	slog.WarnContext(c.UserContext(), "Spin cancelled",
+		slog.String("status", "cancelled"),
+		slog.String("stage", stage),
+		slog.Any("reason", cause),
+	)
+
+	code := fiber.StatusServiceUnavailable
+	message := "Spin cancelled before completion"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		code = fiber.StatusGatewayTimeout
+		message = "Spin timed out before completion"
+	}
+	return c.Status(code).JSON(SpinResponse{
+		Status:  "cancelled",
+		Message: message,
+	})
+}
@@ -0,0 +1,146 @@
+package games
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/rounds"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/wallet"
+)
+
+// flakyStore fails the next failures writes, as a briefly locked database would
+type flakyStore struct {
+	store.Store
+	failures int
+}
+
+func (s *flakyStore) Update(ctx context.Context, fn func(store.Tx) error) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("database is locked")
+	}
+	return s.Store.Update(ctx, fn)
+}
+
+// resolvedRound plays a round up to the RNG's decision
+func resolvedRound(t *testing.T, l *rounds.Lifecycle) *rounds.Round {
+	t.Helper()
+	ctx := context.Background()
+	round, err := l.Begin(ctx, store.Round{BetID: "b1", ClientID: "1001", PlayerID: "p1", GameID: "slot", Environment: "prod", BetAmount: 1, StartedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := round.Wager(ctx); err != nil {
+		t.Fatal(err)
+	}
+	err = round.Resolve(ctx, rounds.Outcome{Outcome: "win", WinAmount: 2, RNGResponse: json.RawMessage(`{"pref_outcome":"win"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return round
+}
+
+func TestSettleRetries(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		failures      int
+		wantState     string
+		wantDelivered bool
+		wantErr       bool
+	}{
+		{"first attempt", 0, store.StateSettled, false, false},
+		{"after failed writes", settleRetries, store.StateSettled, false, false},
+		{"writes keep failing", settleRetries + 1, store.StateResolved, true, false},
+		{"not even delivered", settleRetries + 2, store.StateResolved, false, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := &flakyStore{Store: store.NewMemory()}
+			round := resolvedRound(t, rounds.NewLifecycle(st, 0))
+			rg := &RouteGroup{Store: st, IdempotencyTTL: time.Hour}
+
+			st.failures = tt.failures
+			err := rg.settle(ctx, round, SpinResponse{Status: "success", WinAmount: 2})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("settle error = %v, want error %v", err, tt.wantErr)
+			}
+
+			var state store.RoundState
+			err = st.View(ctx, func(tx store.Tx) (err error) {
+				state, err = tx.GetRoundState("1001", "b1")
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.State != tt.wantState || state.Delivered != tt.wantDelivered {
+				t.Errorf("round = %s delivered %v, want %s delivered %v", state.State, state.Delivered, tt.wantState, tt.wantDelivered)
+			}
+		})
+	}
+}
+
+func TestSettleOutlivesSpin(t *testing.T) {
+	st := &flakyStore{Store: store.NewMemory()}
+	round := resolvedRound(t, rounds.NewLifecycle(st, 0))
+	rg := &RouteGroup{Store: st, IdempotencyTTL: time.Hour}
+
+	// A spin out of time still gets its retries
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	st.failures = 1
+	if err := rg.settle(ctx, round, SpinResponse{Status: "success"}); err != nil {
+		t.Fatal(err)
+	}
+	if round.State().State != store.StateSettled {
+		t.Errorf("round state = %s after a cancelled spin, want settled", round.State().State)
+	}
+}
+
+// notifyCounter counts the wallet transactions it is sent
+type notifyCounter struct {
+	sent []wallet.Transaction
+}
+
+func (w *notifyCounter) Notify(_ context.Context, tx wallet.Transaction) (wallet.Receipt, error) {
+	w.sent = append(w.sent, tx)
+	return wallet.Receipt{}, nil
+}
+
+func TestUnsettledSpinPaidOnce(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		failures    int
+		wantCredits int
+	}{
+		// The operator pays the win from the spin response
+		{"delivered", settleRetries + 1, 0},
+		// The spin answered cancelled, so only the wallet pays it
+		{"not delivered", settleRetries + 2, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := &flakyStore{Store: store.NewMemory()}
+			lifecycle := rounds.NewLifecycle(st, 0)
+			round := resolvedRound(t, lifecycle)
+			rg := &RouteGroup{Store: st, IdempotencyTTL: time.Hour}
+
+			st.failures = tt.failures
+			rg.settle(ctx, round, SpinResponse{Status: "success", WinAmount: 2})
+
+			w := &notifyCounter{}
+			r := &rounds.Reconciler{Lifecycle: lifecycle, Wallets: map[string]rounds.Wallet{"prod": w}, Settled: rg.KeepReplay}
+			report, err := r.Run(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Settled != 1 || len(w.sent) != tt.wantCredits {
+				t.Errorf("reconciled %d rounds with %d credits, want 1 with %d", report.Settled, len(w.sent), tt.wantCredits)
+			}
+		})
+	}
+}
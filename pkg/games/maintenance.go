@@ -0,0 +1,53 @@
+package games
+
+import (
+	"slices"
+	"sync"
+)
+
+// MaintenanceState is what is under maintenance
+type MaintenanceState struct {
+	Enabled bool     `json:"enabled"`         // every game
+	Games   []string `json:"games,omitempty"` // only these, when not Enabled
+	Message string   `json:"message,omitempty"`
+}
+
+// Maintenance refuses new spins of every game, or of some, while the server
+// keeps answering everything else
+type Maintenance struct {
+	mu    sync.RWMutex
+	state MaintenanceState
+}
+
+// Set replaces what is under maintenance
+func (m *Maintenance) Set(state MaintenanceState) {
+	state.Games = slices.Clone(state.Games)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = state
+}
+
+// State returns what is under maintenance
+func (m *Maintenance) State() MaintenanceState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	state := m.state
+	state.Games = slices.Clone(state.Games)
+	return state
+}
+
+// Blocks reports whether spins of gameID are refused, with the message to show
+func (m *Maintenance) Blocks(gameID string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.state.Enabled && !slices.Contains(m.state.Games, gameID) {
+		return "", false
+	}
+	if m.state.Message != "" {
+		return m.state.Message, true
+	}
+	return "The game is under maintenance", true
+}
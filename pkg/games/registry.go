@@ -0,0 +1,171 @@
+package games
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry errors
+var (
+	ErrGameNotFound    = errors.New("game not found")
+	ErrVersionNotFound = errors.New("definition version not found")
+)
+
+// registered is a game definition together with the Info it was registered under
+type registered struct {
+	Game
+	info Info
+}
+
+// versions holds every definition version of one game and which one is served
+type versions struct {
+	byVersion map[string]registered
+	active    string
+}
+
+// Registry holds the games the server offers, keyed by game ID. A game may
+// have several definition versions; spins are played on the active one, which
+// can be switched while the server runs.
+type Registry struct {
+	mu    sync.RWMutex
+	games map[string]*versions
+}
+
+// NewRegistry creates a registry holding games
+func NewRegistry(games ...Game) (*Registry, error) {
+	r := &Registry{games: make(map[string]*versions)}
+	for _, g := range games {
+		if err := r.Register(g); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a game, its definition version becoming the active one; its
+// ID must be unique and its definition usable
+func (r *Registry) Register(g Game) error {
+	info, err := checkGame(g)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.games[info.ID]; exists {
+		return fmt.Errorf("game %q is registered twice", info.ID)
+	}
+	r.games[info.ID] = &versions{
+		byVersion: map[string]registered{info.DefinitionVersion: {Game: g, info: info}},
+		active:    info.DefinitionVersion,
+	}
+	return nil
+}
+
+// AddVersion adds another definition version of a registered game without
+// activating it
+func (r *Registry) AddVersion(g Game) error {
+	info, err := checkGame(g)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.games[info.ID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrGameNotFound, info.ID)
+	}
+	if _, exists := v.byVersion[info.DefinitionVersion]; exists {
+		return fmt.Errorf("game %q definition %q is registered twice", info.ID, info.DefinitionVersion)
+	}
+	v.byVersion[info.DefinitionVersion] = registered{Game: g, info: info}
+	return nil
+}
+
+// Activate makes version the definition spins of game id are played on
+func (r *Registry) Activate(id, version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.games[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrGameNotFound, id)
+	}
+	if _, ok := v.byVersion[version]; !ok {
+		return fmt.Errorf("%w: %s %s", ErrVersionNotFound, id, version)
+	}
+	v.active = version
+	return nil
+}
+
+// checkGame returns the Info of a game that can be registered
+func checkGame(g Game) (Info, error) {
+	info := g.Info()
+	if info.ID == "" {
+		return info, fmt.Errorf("game %q has no ID", info.Name)
+	}
+	if err := g.CheckDefinition(); err != nil {
+		return info, fmt.Errorf("game %q: %w", info.ID, err)
+	}
+	return info, nil
+}
+
+// Get returns the active definition of the game registered under id
+func (r *Registry) Get(id string) (Game, bool) {
+	g, _, ok := r.Active(id)
+	return g, ok
+}
+
+// Active returns the active definition of the game registered under id with its Info
+func (r *Registry) Active(id string) (Game, Info, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.games[id]
+	if !ok {
+		return nil, Info{}, false
+	}
+	g := v.byVersion[v.active]
+	return g.Game, g.info, true
+}
+
+// Versions returns the Info of every definition version of game id, ordered
+// by version, and the active version
+func (r *Registry) Versions(id string) ([]Info, string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.games[id]
+	if !ok {
+		return nil, "", false
+	}
+	infos := make([]Info, 0, len(v.byVersion))
+	for _, g := range v.byVersion {
+		infos = append(infos, g.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].DefinitionVersion < infos[j].DefinitionVersion })
+	return infos, v.active, true
+}
+
+// IDs returns the registered game IDs in order
+func (r *Registry) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.games))
+	for id := range r.games {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Infos returns the Info of the active definition of every registered game,
+// ordered by ID
+func (r *Registry) Infos() []Info {
+	ids := r.IDs()
+	infos := make([]Info, 0, len(ids))
+	for _, id := range ids {
+		if _, info, ok := r.Active(id); ok {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
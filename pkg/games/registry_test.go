@@ -0,0 +1,81 @@
+package games
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+type stubGame struct {
+	id      string
+	version string
+	err     error
+}
+
+func (g stubGame) Info() Info                                      { return Info{ID: g.id, DefinitionVersion: g.version} }
+func (stubGame) ValidateBet(int, float64) error                    { return nil }
+func (stubGame) Multiplier(float64, int) (int, bool)               { return 1, true }
+func (stubGame) WinningReels() []string                            { return nil }
+func (stubGame) LosingReels() []string                             { return nil }
+func (stubGame) Evaluate([]string, int, float64) (float64, string) { return 0, "" }
+func (g stubGame) CheckDefinition() error                          { return g.err }
+
+func TestRegistry(t *testing.T) {
+	r, err := NewRegistry(stubGame{id: "b"}, stubGame{id: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := r.IDs(); !slices.Equal(ids, []string{"a", "b"}) {
+		t.Fatalf("IDs() = %v, want [a b]", ids)
+	}
+	if _, ok := r.Get("a"); !ok {
+		t.Fatal("Get(a) found nothing")
+	}
+	if _, ok := r.Get("c"); ok {
+		t.Fatal("Get(c) found an unregistered game")
+	}
+
+	for name, g := range map[string]Game{
+		"duplicate":  stubGame{id: "a"},
+		"no id":      stubGame{},
+		"definition": stubGame{id: "c", err: errors.New("empty paytable")},
+	} {
+		if err := r.Register(g); err == nil {
+			t.Errorf("%s: Register succeeded", name)
+		}
+	}
+}
+
+func TestRegistryVersions(t *testing.T) {
+	r, err := NewRegistry(stubGame{id: "a", version: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddVersion(stubGame{id: "a", version: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddVersion(stubGame{id: "a", version: "2"}); err == nil {
+		t.Error("AddVersion of an existing version succeeded")
+	}
+	if err := r.AddVersion(stubGame{id: "b", version: "1"}); !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("AddVersion of an unregistered game = %v, want ErrGameNotFound", err)
+	}
+
+	// A new version is only served once activated
+	if _, info, _ := r.Active("a"); info.DefinitionVersion != "1" {
+		t.Fatalf("active version = %q, want 1", info.DefinitionVersion)
+	}
+	if err := r.Activate("a", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, info, _ := r.Active("a"); info.DefinitionVersion != "2" {
+		t.Errorf("active version after Activate = %q, want 2", info.DefinitionVersion)
+	}
+	infos, active, ok := r.Versions("a")
+	if !ok || active != "2" || len(infos) != 2 || infos[0].DefinitionVersion != "1" {
+		t.Errorf("Versions = %v, %q, %v", infos, active, ok)
+	}
+	if err := r.Activate("a", "3"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("Activate of an unknown version = %v, want ErrVersionNotFound", err)
+	}
+}
@@ -0,0 +1,154 @@
+package games
+
+import (
+	"context"
+	"time"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/auth"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/compliance"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/environment"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/ratelimit"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/responsible"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/rounds"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/session"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RouteGroup holds the dependencies shared by every game's routes
+type RouteGroup struct {
+	Games        *Registry
+	Environments *environment.Router
+	SpinTimeout  time.Duration
+
+	// Sessions, when set, makes spins authenticate with a player session
+	// token and take the player, client and environment from it
+	Sessions *session.Manager
+
+	// Limits throttles spins per IP, player and operator
+	Limits ratelimit.SpinLimits
+
+	// Responsible, when set, enforces players' loss, wager and session limits
+	Responsible *responsible.Service
+
+	// Compliance, when set, applies each operator's market rules to spins
+	Compliance *compliance.Profiles
+	pacer      *compliance.Pacer
+
+	// Maintenance refuses spins of the games it covers
+	Maintenance *Maintenance
+
+	// Selections holds each player's current bet, used by spins that omit it.
+	// It starts in memory; pass the server's store to keep selections across restarts.
+	Selections *Selections
+
+	// Store, when set, replays the response to a retried bet_id for
+	// IdempotencyTTL
+	Store          store.Store
+	IdempotencyTTL time.Duration
+
+	// Rounds, when set, persists each spin's round lifecycle with its ledger
+	// entries and keeps a player to one round at a time
+	Rounds *rounds.Lifecycle
+
+	// Lifetime, when set, aborts in-flight spins once it is cancelled. The
+	// server cancels it only after the graceful shutdown deadline has passed.
+	Lifetime context.Context
+}
+
+// NewRouteGroup creates the route group serving the games in registry
+func NewRouteGroup(registry *Registry, environments *environment.Router, spinTimeout time.Duration) *RouteGroup {
+	return &RouteGroup{
+		Games:        registry,
+		Selections:   NewSelections(store.NewMemory(), SelectionIdle),
+		Maintenance:  &Maintenance{},
+		pacer:        compliance.NewPacer(),
+		Environments: environments,
+		SpinTimeout:  spinTimeout,
+	}
+}
+
+// spinContext derives the context for a spin's outbound calls from the Fiber
+// request. It ends at the spin deadline, when the client disconnects or when
+// Lifetime is cancelled.
+func (rg *RouteGroup) spinContext(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.UserContext(), rg.SpinTimeout)
+	unwatch := watchDisconnect(c.Context().Conn(), cancel)
+	stop := func() bool { return true }
+	if rg.Lifetime != nil {
+		stop = context.AfterFunc(rg.Lifetime, cancel)
+	}
+	return ctx, func() {
+		unwatch()
+		stop()
+		cancel()
+	}
+}
+
+// environmentFor selects the environment serving a spin: the one bound to the
+// player's session, or otherwise the one chosen by the routing rules. Operator
+// rules only match an authenticated operator, never the client_id a caller
+// puts in the body.
+func (rg *RouteGroup) environmentFor(c *fiber.Ctx, claims *session.Claims) (*environment.Clients, bool) {
+	if claims != nil {
+		return rg.Environments.Get(claims.Environment)
+	}
+	var operatorID string
+	if op, ok := auth.OperatorFrom(c.UserContext()); ok {
+		operatorID = op.ID
+	}
+	return rg.Environments.Resolve(environment.Request{
+		OperatorID: operatorID,
+		APIKey:     c.Get(environment.APIKeyHeader),
+		Origin:     c.Get(fiber.HeaderOrigin),
+	}), true
+}
+
+// Register mounts /spin/{game} and its bet selection routes for every
+// registered game, and the game listings
+func (rg *RouteGroup) Register(app *fiber.App) {
+	for _, id := range rg.Games.IDs() {
+		app.Post("/spin/"+id, rg.SpinHandler(id))
+		app.Get("/spin/"+id+"/bet", rg.BetHandler(id, keepSelection))
+		app.Post("/spin/"+id+"/bet-one", rg.BetHandler(id, betOne))
+		app.Post("/spin/"+id+"/bet-max", rg.BetHandler(id, betMax))
+		app.Post("/spin/"+id+"/denomination", rg.BetHandler(id, setDenomination))
+		app.Get("/spin/"+id+"/rules", rg.RulesHandler(id))
+	}
+	app.Get("/games", rg.listGames)
+	app.Get("/games/:game", rg.getGame)
+}
+
+// RulesHandler returns the handler answering the compliance profile that
+// applies to the player, so the client can hide disabled features
+func (rg *RouteGroup) RulesHandler(gameID string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := SpinRequest{ClientID: c.Query("client_id"), PlayerID: c.Query("player_id"), GameID: gameID}
+		claims, done, err := rg.authenticatePlayer(c, gameID, &req)
+		if done {
+			return err
+		}
+		if done, err := clientNotAllowed(c, gameID, req.ClientID); done {
+			return err
+		}
+		profile := rg.Compliance.For(operatorKey(c, req, claims))
+		if profile == nil {
+			profile = &compliance.Profile{GambleAllowed: true, AutoplayAllowed: true}
+		}
+		return c.JSON(profile)
+	}
+}
+
+// listGames describes every registered game
+func (rg *RouteGroup) listGames(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"games": rg.Games.Infos()})
+}
+
+// getGame describes one game
+func (rg *RouteGroup) getGame(c *fiber.Ctx) error {
+	_, info, ok := rg.Games.Active(c.Params("game"))
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "game not found")
+	}
+	return c.JSON(info)
+}
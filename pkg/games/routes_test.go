@@ -0,0 +1,163 @@
+package games
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/auth"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/config"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/environment"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/ratelimit"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/responsible"
+	"github.com/JILI-GAMES/b_backend_games11/pkg/common/store"
+)
+
+func newTestOperators(t *testing.T) *auth.Store {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "operators.json")
+	operators := `{"operators": [
+		{"id": "op-test", "client_ids": ["1001"], "api_keys": ["key-test"]},
+		{"id": "op-prod", "client_ids": ["2001"], "api_keys": ["key-prod"]}
+	]}`
+	if err := os.WriteFile(path, []byte(operators), 0600); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := auth.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds
+}
+
+func TestEnvironmentForIgnoresBodyClientID(t *testing.T) {
+	creds := newTestOperators(t)
+
+	router, err := environment.NewRouter(config.Routing{
+		Default:   "prod",
+		Operators: map[string]string{"op-test": "test", "2001": "test"},
+	}, &environment.Clients{Name: "prod"}, &environment.Clients{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg := &RouteGroup{Environments: router}
+
+	app := fiber.New()
+	app.Post("/spin", auth.NewAuthenticator(creds, auth.Config{}).Middleware(), func(c *fiber.Ctx) error {
+		env, _ := rg.environmentFor(c, nil)
+		return c.SendString(env.Name)
+	})
+
+	for _, tt := range []struct {
+		name, apiKey, body, want string
+	}{
+		{"routed operator", "key-test", `{"client_id":"1001"}`, "test"},
+		{"client_id naming a routed value", "key-prod", `{"client_id":"2001"}`, "prod"},
+		{"client_id of another operator", "key-prod", `{"client_id":"1001"}`, "prod"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/spin", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			req.Header.Set(auth.APIKeyHeader, tt.apiKey)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(body); got != tt.want {
+				t.Errorf("environment = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitIPSkipsAuthenticatedOperators(t *testing.T) {
+	rg := &RouteGroup{Limits: ratelimit.SpinLimits{IP: ratelimit.NewLimiter(0.001, 1)}}
+	handler := func(c *fiber.Ctx) error {
+		if limited, err := rg.limitIP(c, "test"); limited {
+			return err
+		}
+		return c.SendStatus(fiber.StatusOK)
+	}
+
+	app := fiber.New()
+	app.Post("/anonymous", handler)
+	app.Post("/operator", auth.NewAuthenticator(newTestOperators(t), auth.Config{}).Middleware(), handler)
+
+	status := func(path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set(auth.APIKeyHeader, "key-test")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := status("/anonymous"); got != http.StatusOK {
+		t.Fatalf("first anonymous request: %d, want 200", got)
+	}
+	if got := status("/anonymous"); got != http.StatusTooManyRequests {
+		t.Fatalf("second anonymous request: %d, want 429", got)
+	}
+	for i := 0; i < 3; i++ {
+		if got := status("/operator"); got != http.StatusOK {
+			t.Fatalf("operator request %d from the limited IP: %d, want 200", i+1, got)
+		}
+	}
+}
+
+func TestRefusedSpinKeepsSelection(t *testing.T) {
+	ctx := context.Background()
+	registry, err := NewRegistry(stubGame{id: "slot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := environment.NewRouter(config.Routing{Default: "prod"}, &environment.Clients{Name: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := store.NewMemory()
+	rg := NewRouteGroup(registry, router, time.Second)
+	rg.Selections = NewSelections(st, time.Hour)
+	rg.Responsible = responsible.NewService(st, responsible.Limits{}, time.Hour)
+	if _, err := rg.Responsible.CoolOff(ctx, responsible.PlayerKey("1001", "p1"), time.Now().Add(time.Hour), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	rg.Register(app)
+
+	body := `{"client_id":"1001","player_id":"p1","bet_id":"b1","bet_level":2,"bet_amount":0.2}`
+	req := httptest.NewRequest(http.MethodPost, "/spin/slot", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("spin during a cool-off = %d, want 403", resp.StatusCode)
+	}
+
+	err = st.View(ctx, func(tx store.Tx) error {
+		_, err := tx.GetPlayerState(selectionKind, selectionKey("slot", "1001", "p1"), time.Now())
+		return err
+	})
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("selection after a refused spin: %v, want none", err)
+	}
+}
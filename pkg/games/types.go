@@ -0,0 +1,50 @@
+package games
+
+import "github.com/JILI-GAMES/b_backend_games11/pkg/common/responsible"
+
+// SpinRequest represents the request body for the /spin endpoints
+type SpinRequest struct {
+	ClientID  string  `json:"client_id"`
+	GameID    string  `json:"game_id"` // optional; defaults to the game in the path
+	PlayerID  string  `json:"player_id"`
+	BetID     string  `json:"bet_id"`
+	BetAmount float64 `json:"bet_amount"`
+	BetLevel  int     `json:"bet_level"` // paytable selection
+	Autoplay  bool    `json:"autoplay,omitempty"`
+}
+
+// SpinResponse represents the response body for the /spin endpoints
+type SpinResponse struct {
+	Status             string   `json:"status"`
+	Code               string   `json:"code,omitempty"` // machine-readable reason for session errors
+	Message            string   `json:"message"`
+	Reels              []string `json:"reels"`
+	WinAmount          float64  `json:"win_amount"`
+	WinningCombination string   `json:"winning_combination"`
+	WinCapped          bool     `json:"win_capped,omitempty"` // win reduced to the operator's maximum
+	PaytableUsed       int      `json:"paytable_used"`
+	BetLevel           int      `json:"bet_level"`
+	Currency           string   `json:"currency,omitempty"` // from the player's session
+
+	// RealityCheck is set when the player is due a reminder of their session
+	RealityCheck *responsible.RealityCheck `json:"reality_check,omitempty"`
+}
+
+// BetRequest is the body of the bet selection endpoints. Client and player
+// come from the session token when sessions are enabled.
+type BetRequest struct {
+	ClientID     string  `json:"client_id"`
+	PlayerID     string  `json:"player_id"`
+	Denomination float64 `json:"denomination"` // set-denomination only: the coin value at the first bet level
+}
+
+// BetResponse is the player's bet selection after a bet selection request
+type BetResponse struct {
+	Status       string         `json:"status"`
+	Code         string         `json:"code,omitempty"`
+	Message      string         `json:"message"`
+	BetLevel     int            `json:"bet_level"`
+	BetAmount    float64        `json:"bet_amount"`
+	Denomination float64        `json:"denomination"`
+	Paytable     map[string]int `json:"paytable"` // credits per combination at BetLevel
+}